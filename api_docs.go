@@ -0,0 +1,663 @@
+package main
+
+import (
+	"net/http"
+
+	"gin-doniai/handlers"
+	"gin-doniai/models"
+	"gin-doniai/openapi"
+	"gin-doniai/serializers"
+)
+
+// describeAPIRoutes 为 OpenAPI 文档登记接口说明，请求体和响应结构通过反射生成
+func describeAPIRoutes() {
+	openapi.Describe(handlers.GetOnlineUserCount, openapi.Endpoint{
+		Summary: "在线用户数",
+		Tags:    []string{"users"},
+		Response: struct {
+			OnlineCount int64 `json:"online_count"`
+		}{},
+	})
+	openapi.Describe(handlers.ForgotPassword, openapi.Endpoint{
+		Summary: "发送重置密码邮件",
+		Tags:    []string{"auth"},
+		Request: handlers.ForgotPasswordRequest{},
+	})
+	openapi.Describe(handlers.ProcessResetPassword, openapi.Endpoint{
+		Summary: "通过重置令牌设置新密码",
+		Tags:    []string{"auth"},
+		Request: handlers.ResetPasswordRequest{},
+	})
+
+	// 评论
+	openapi.Describe(handlers.CreateComment, openapi.Endpoint{
+		Summary:  "发表评论，需要已验证邮箱",
+		Tags:     []string{"comments"},
+		Scope:    models.ScopeCommentsWrite,
+		Auth:     true,
+		Request:  handlers.CreateCommentRequest{},
+		Response: serializers.Comment{},
+	})
+	openapi.Describe(handlers.GetComments, openapi.Endpoint{
+		Summary:  "文章的评论列表",
+		Tags:     []string{"comments"},
+		Scope:    models.ScopePostsRead,
+		Query:    []openapi.Parameter{{Name: "post_id", Required: true, Description: "文章ID"}},
+		Response: serializers.Comment{},
+		List:     true,
+	})
+	openapi.Describe(handlers.GetComment, openapi.Endpoint{
+		Summary:  "获取评论",
+		Tags:     []string{"comments"},
+		Scope:    models.ScopePostsRead,
+		Response: serializers.Comment{},
+	})
+	openapi.Describe(handlers.UpdateComment, openapi.Endpoint{
+		Summary:  "修改评论（作者或版主）",
+		Tags:     []string{"comments"},
+		Scope:    models.ScopeCommentsWrite,
+		Auth:     true,
+		Request:  handlers.UpdateCommentRequest{},
+		Response: serializers.Comment{},
+	})
+	openapi.Describe(handlers.DeleteComment, openapi.Endpoint{
+		Summary: "删除评论（作者或版主）",
+		Tags:    []string{"comments"},
+		Scope:   models.ScopeCommentsWrite,
+		Auth:    true,
+	})
+	openapi.Describe(handlers.RestoreComment, openapi.Endpoint{
+		Summary:  "从回收站恢复评论（自己删除的评论或版主）",
+		Tags:     []string{"comments"},
+		Scope:    models.ScopeCommentsWrite,
+		Auth:     true,
+		Response: serializers.Comment{},
+	})
+	openapi.Describe(handlers.LikeComment, openapi.Endpoint{
+		Summary: "评论点赞",
+		Tags:    []string{"comments"},
+		Scope:   models.ScopeCommentsWrite,
+		Auth:    true,
+		Request: handlers.LikeCommentRequest{},
+		Response: struct {
+			LikeCount int `json:"like_count"`
+		}{},
+	})
+	openapi.Describe(handlers.ReportComment, openapi.Endpoint{
+		Summary:  "举报评论，同一用户只能举报一次，重复举报返回 409",
+		Tags:     []string{"comments"},
+		Scope:    models.ScopeCommentsWrite,
+		Auth:     true,
+		Request:  handlers.ReportRequest{},
+		Response: serializers.Report{},
+		Status:   http.StatusCreated,
+	})
+
+	// 用户，返回的字段随查看者身份不同：本人可见 SelfUser，管理员可见 AdminUser
+	openapi.Describe(handlers.CreateUser, openapi.Endpoint{
+		Summary:    "创建用户",
+		Tags:       []string{"users"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+		Request:    handlers.CreateUserRequest{},
+		Response:   serializers.AdminUser{},
+		Status:     http.StatusCreated,
+	})
+	openapi.Describe(handlers.GetUsers, openapi.Endpoint{
+		Summary:  "用户列表",
+		Tags:     []string{"users"},
+		Response: serializers.PublicUser{},
+		List:     true,
+	})
+	openapi.Describe(handlers.GetUser, openapi.Endpoint{
+		Summary:  "获取用户",
+		Tags:     []string{"users"},
+		Response: serializers.PublicUser{},
+	})
+	openapi.Describe(handlers.UpdateUser, openapi.Endpoint{
+		Summary:    "更新用户",
+		Tags:       []string{"users"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+		Request:    handlers.UpdateUserRequest{},
+		Response:   serializers.AdminUser{},
+	})
+	openapi.Describe(handlers.DeleteUser, openapi.Endpoint{
+		Summary:    "删除用户（软删除）",
+		Tags:       []string{"users"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+	})
+	openapi.Describe(handlers.ForceDeleteUser, openapi.Endpoint{
+		Summary:    "永久删除用户",
+		Tags:       []string{"users"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermForceDelete),
+	})
+	openapi.Describe(handlers.RestoreUser, openapi.Endpoint{
+		Summary:    "从回收站恢复用户",
+		Tags:       []string{"users"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+		Response:   serializers.AdminUser{},
+	})
+	openapi.Describe(handlers.UpdateUserProfile, openapi.Endpoint{
+		Summary: "更新个人资料",
+		Tags:    []string{"users"},
+		Scope:   models.ScopeProfileWrite,
+		Auth:    true,
+		Request: handlers.UpdateProfileRequest{},
+	})
+	openapi.Describe(handlers.UpdateUserPassword, openapi.Endpoint{
+		Summary: "修改密码",
+		Tags:    []string{"users"},
+		Session: true,
+		Request: handlers.UpdatePasswordRequest{},
+	})
+	openapi.Describe(handlers.ChangeEmail, openapi.Endpoint{
+		Summary: "修改邮箱，向新邮箱发送确认邮件，确认后生效",
+		Tags:    []string{"users"},
+		Session: true,
+		Request: handlers.ChangeEmailRequest{},
+	})
+	openapi.Describe(handlers.ResendEmailVerification, openapi.Endpoint{
+		Summary: "重新发送邮箱验证邮件",
+		Tags:    []string{"users"},
+		Session: true,
+	})
+
+	// 文章
+	openapi.Describe(handlers.CreatePost, openapi.Endpoint{
+		Summary:  "发布文章，需要已验证邮箱",
+		Tags:     []string{"posts"},
+		Scope:    models.ScopePostsWrite,
+		Auth:     true,
+		Request:  handlers.CreatePostRequest{},
+		Response: serializers.Post{},
+		Status:   http.StatusCreated,
+	})
+	openapi.Describe(handlers.GetPosts, openapi.Endpoint{
+		Summary:  "文章列表（仅包含当前用户可读的文章）",
+		Tags:     []string{"posts"},
+		Scope:    models.ScopePostsRead,
+		Response: serializers.Post{},
+		List:     true,
+	})
+	openapi.Describe(handlers.SearchPosts, openapi.Endpoint{
+		Summary: "全文搜索文章（标题、正文、标签和公开评论），按相关度或发布时间排序",
+		Tags:    []string{"posts"},
+		Scope:   models.ScopePostsRead,
+		Query: []openapi.Parameter{
+			{Name: "q", Description: "搜索词，多个词之间为“且”的关系；为空时列出符合筛选条件的文章"},
+			{Name: "category_id", Description: "分类ID，包含子分类"},
+			{Name: "tag", Description: "标签"},
+			{Name: "author", Description: "作者用户名"},
+			{Name: "from", Description: "发布日期不早于，格式 2006-01-02"},
+			{Name: "to", Description: "发布日期不晚于（包含当天），格式 2006-01-02"},
+			{Name: "sort", Description: "relevance 按相关度（默认），newest 按发布时间"},
+		},
+		Response: serializers.SearchResult{},
+		List:     true,
+	})
+	openapi.Describe(handlers.GetPost, openapi.Endpoint{
+		Summary:  "获取文章",
+		Tags:     []string{"posts"},
+		Scope:    models.ScopePostsRead,
+		Response: serializers.Post{},
+	})
+	openapi.Describe(handlers.UpdatePost, openapi.Endpoint{
+		Summary:  "更新文章（作者或版主）",
+		Tags:     []string{"posts"},
+		Scope:    models.ScopePostsWrite,
+		Auth:     true,
+		Request:  handlers.UpdatePostRequest{},
+		Response: serializers.Post{},
+	})
+	openapi.Describe(handlers.DeletePost, openapi.Endpoint{
+		Summary: "删除文章（软删除，作者或版主）",
+		Tags:    []string{"posts"},
+		Scope:   models.ScopePostsWrite,
+		Auth:    true,
+	})
+	openapi.Describe(handlers.LikePost, openapi.Endpoint{
+		Summary: "文章点赞",
+		Tags:    []string{"posts"},
+		Scope:   models.ScopePostsWrite,
+		Auth:    true,
+		Request: handlers.LikePostRequest{},
+		Response: struct {
+			Likes int `json:"likes"`
+		}{},
+	})
+	openapi.Describe(handlers.ForceDeletePost, openapi.Endpoint{
+		Summary:    "永久删除文章",
+		Tags:       []string{"posts"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermForceDelete),
+	})
+	openapi.Describe(handlers.RestorePost, openapi.Endpoint{
+		Summary:  "从回收站恢复文章（自己删除的文章或版主），同时重新计算回复数",
+		Tags:     []string{"posts"},
+		Scope:    models.ScopePostsWrite,
+		Auth:     true,
+		Response: serializers.Post{},
+	})
+	openapi.Describe(handlers.FavoritePost, openapi.Endpoint{
+		Summary: "文章收藏",
+		Tags:    []string{"posts"},
+		Scope:   models.ScopePostsWrite,
+		Auth:    true,
+		Request: handlers.FavoritePostRequest{},
+		Response: struct {
+			Favorites int `json:"favorites"`
+		}{},
+	})
+	openapi.Describe(handlers.ReportPost, openapi.Endpoint{
+		Summary:  "举报文章，同一用户只能举报一次，重复举报返回 409",
+		Tags:     []string{"posts"},
+		Scope:    models.ScopePostsWrite,
+		Auth:     true,
+		Request:  handlers.ReportRequest{},
+		Response: serializers.Report{},
+		Status:   http.StatusCreated,
+	})
+
+	// 分类
+	openapi.Describe(handlers.GetCategories, openapi.Endpoint{
+		Summary:  "分类列表，按手动排序，子分类放在上级分类的 children 中",
+		Tags:     []string{"categories"},
+		Scope:    models.ScopePostsRead,
+		Response: []serializers.Category{},
+	})
+	openapi.Describe(handlers.GetCategory, openapi.Endpoint{
+		Summary:  "获取单个分类及其子分类",
+		Tags:     []string{"categories"},
+		Scope:    models.ScopePostsRead,
+		Response: serializers.Category{},
+	})
+	openapi.Describe(handlers.CreateCategory, openapi.Endpoint{
+		Summary:    "创建分类，parent_id 为上级分类（只支持两级），min_post_level 为发帖所需的最低等级",
+		Tags:       []string{"categories"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageCategories),
+		Request:    handlers.CategoryRequest{},
+		Response:   serializers.Category{},
+		Status:     http.StatusCreated,
+	})
+	openapi.Describe(handlers.UpdateCategory, openapi.Endpoint{
+		Summary:    "修改分类，整体替换可编辑的字段",
+		Tags:       []string{"categories"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageCategories),
+		Request:    handlers.CategoryRequest{},
+		Response:   serializers.Category{},
+	})
+	openapi.Describe(handlers.DeleteCategory, openapi.Endpoint{
+		Summary:    "删除分类（软删除），有子分类时需要先删除或移走子分类",
+		Tags:       []string{"categories"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageCategories),
+	})
+	openapi.Describe(handlers.SortCategories, openapi.Endpoint{
+		Summary:    "按 ids 的顺序重新设置分类排序",
+		Tags:       []string{"categories"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageCategories),
+		Request:    handlers.SortCategoriesRequest{},
+		Response:   []serializers.Category{},
+	})
+
+	// 回收站
+	openapi.Describe(handlers.ListTrashedPosts, openapi.Endpoint{
+		Summary:  "回收站中的文章，没有文章管理权限时只列出自己的文章",
+		Tags:     []string{"trash"},
+		Scope:    models.ScopePostsRead,
+		Auth:     true,
+		Query:    []openapi.Parameter{{Name: "q", Description: "按标题模糊搜索"}},
+		Response: serializers.Post{},
+		List:     true,
+	})
+	openapi.Describe(handlers.ListTrashedComments, openapi.Endpoint{
+		Summary:  "回收站中的评论，没有评论管理权限时只列出自己的评论",
+		Tags:     []string{"trash"},
+		Scope:    models.ScopePostsRead,
+		Auth:     true,
+		Query:    []openapi.Parameter{{Name: "q", Description: "按内容模糊搜索"}},
+		Response: serializers.Comment{},
+		List:     true,
+	})
+	openapi.Describe(handlers.ListTrashedUsers, openapi.Endpoint{
+		Summary:    "已删除的用户",
+		Tags:       []string{"trash"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+		Query:      []openapi.Parameter{{Name: "q", Description: "按用户名或邮箱模糊搜索"}},
+		Response:   serializers.AdminUser{},
+		List:       true,
+	})
+
+	// 审核
+	statusQuery := []openapi.Parameter{{Name: "status", Description: "pending（默认，待审核）或 rejected（未通过）"}}
+	openapi.Describe(handlers.ListModerationPosts, openapi.Endpoint{
+		Summary:    "审核队列中的文章",
+		Tags:       []string{"moderation"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Query:      statusQuery,
+		Response:   serializers.Post{},
+		List:       true,
+	})
+	openapi.Describe(handlers.ReviewPost, openapi.Endpoint{
+		Summary:    "通过或拒绝文章，拒绝时需要填写原因",
+		Tags:       []string{"moderation"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Request:    handlers.ModerationRequest{},
+		Response:   serializers.Post{},
+	})
+	openapi.Describe(handlers.ListModerationComments, openapi.Endpoint{
+		Summary:    "审核队列中的评论",
+		Tags:       []string{"moderation"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Query:      statusQuery,
+		Response:   serializers.Comment{},
+		List:       true,
+	})
+	openapi.Describe(handlers.ReviewComment, openapi.Endpoint{
+		Summary:    "通过或拒绝评论，拒绝时需要填写原因",
+		Tags:       []string{"moderation"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Request:    handlers.ModerationRequest{},
+		Response:   serializers.Comment{},
+	})
+	openapi.Describe(handlers.ListReports, openapi.Endpoint{
+		Summary:    "举报列表",
+		Tags:       []string{"moderation"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Query:      []openapi.Parameter{{Name: "status", Description: "open（默认，待处理）、resolved（已下架）或 dismissed（已驳回）"}},
+		Response:   serializers.Report{},
+		List:       true,
+	})
+	openapi.Describe(handlers.HandleReport, openapi.Endpoint{
+		Summary:    "处理举报：remove 下架内容，dismiss 驳回举报，对同一内容的所有待处理举报生效",
+		Tags:       []string{"moderation"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Request:    handlers.ReportActionRequest{},
+	})
+
+	// 敏感词
+	openapi.Describe(handlers.GetSensitiveWords, openapi.Endpoint{
+		Summary:    "敏感词列表",
+		Tags:       []string{"sensitive-words"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageWords),
+		Query:      []openapi.Parameter{{Name: "q", Description: "按词语模糊搜索"}},
+		Response:   serializers.SensitiveWord{},
+		List:       true,
+	})
+	openapi.Describe(handlers.CreateSensitiveWords, openapi.Endpoint{
+		Summary:    "批量添加敏感词，已存在的词语更新处理方式",
+		Tags:       []string{"sensitive-words"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageWords),
+		Request:    handlers.CreateSensitiveWordsRequest{},
+		Response: struct {
+			Saved int `json:"saved"`
+		}{},
+		Status: http.StatusCreated,
+	})
+	openapi.Describe(handlers.UpdateSensitiveWord, openapi.Endpoint{
+		Summary:    "修改敏感词的处理方式",
+		Tags:       []string{"sensitive-words"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageWords),
+		Request:    handlers.UpdateSensitiveWordRequest{},
+		Response:   serializers.SensitiveWord{},
+	})
+	openapi.Describe(handlers.DeleteSensitiveWord, openapi.Endpoint{
+		Summary:    "删除敏感词",
+		Tags:       []string{"sensitive-words"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageWords),
+	})
+
+	// 管理后台
+	type bulkResult struct {
+		Affected int64 `json:"affected"`
+	}
+	openapi.Describe(handlers.AdminStats, openapi.Endpoint{
+		Summary:    "站点统计，缓存1分钟",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermModerate),
+		Response:   handlers.SiteStats{},
+	})
+	openapi.Describe(handlers.AdminListUsers, openapi.Endpoint{
+		Summary:    "用户列表，包含管理员可见的信息",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+		Query: []openapi.Parameter{
+			{Name: "q", Description: "按用户名或邮箱模糊搜索"},
+			{Name: "role", Description: "角色：admin、moderator 或 member"},
+			{Name: "deleted", Description: "only 只列出已删除的，all 包含已删除的"},
+		},
+		Response: serializers.AdminUser{},
+		List:     true,
+	})
+	openapi.Describe(handlers.AdminBulkUsers, openapi.Endpoint{
+		Summary:    "批量删除（软删除）或恢复用户，action 为 delete 或 restore",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageUsers),
+		Request:    handlers.AdminBulkRequest{},
+		Response:   bulkResult{},
+	})
+	openapi.Describe(handlers.AdminListPosts, openapi.Endpoint{
+		Summary:    "文章列表，包含待审核、未通过和已删除的文章",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManagePosts),
+		Query: []openapi.Parameter{
+			{Name: "q", Description: "按标题模糊搜索"},
+			{Name: "category_id", Description: "分类ID"},
+			{Name: "user_id", Description: "作者ID"},
+			{Name: "status", Description: "状态：1 正常 2 禁用 3 待审核"},
+			{Name: "deleted", Description: "only 只列出已删除的，all 包含已删除的"},
+		},
+		Response: serializers.Post{},
+		List:     true,
+	})
+	openapi.Describe(handlers.AdminBulkPosts, openapi.Endpoint{
+		Summary:    "批量删除、恢复文章，move 修改分类，set_status 修改状态",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManagePosts),
+		Request:    handlers.AdminBulkRequest{},
+		Response:   bulkResult{},
+	})
+	openapi.Describe(handlers.AdminListComments, openapi.Endpoint{
+		Summary:    "评论列表，包含待审核、未通过和已删除的评论",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageComments),
+		Query: []openapi.Parameter{
+			{Name: "q", Description: "按内容模糊搜索"},
+			{Name: "post_id", Description: "文章ID"},
+			{Name: "user_id", Description: "作者ID"},
+			{Name: "status", Description: "状态：1 正常 2 禁用 3 待审核"},
+			{Name: "deleted", Description: "only 只列出已删除的，all 包含已删除的"},
+		},
+		Response: serializers.Comment{},
+		List:     true,
+	})
+	openapi.Describe(handlers.AdminBulkComments, openapi.Endpoint{
+		Summary:    "批量删除、恢复评论或修改评论状态，同时更新文章的回复数",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageComments),
+		Request:    handlers.AdminBulkRequest{},
+		Response:   bulkResult{},
+	})
+	openapi.Describe(handlers.AdminListCategories, openapi.Endpoint{
+		Summary:    "分类列表，包含每个分类下的文章数",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageCategories),
+		Query: []openapi.Parameter{
+			{Name: "q", Description: "按名称或别名模糊搜索"},
+			{Name: "status", Description: "状态：1 正常 2 禁用 3 待审核"},
+			{Name: "deleted", Description: "only 只列出已删除的，all 包含已删除的"},
+		},
+		Response: serializers.Category{},
+		List:     true,
+	})
+	openapi.Describe(handlers.AdminBulkCategories, openapi.Endpoint{
+		Summary:    "批量删除、恢复分类或修改分类状态",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermManageCategories),
+		Request:    handlers.AdminBulkRequest{},
+		Response:   bulkResult{},
+	})
+
+	// 审计日志
+	auditQuery := []openapi.Parameter{
+		{Name: "q", Description: "按操作人、对象名称模糊搜索，或按 IP 精确搜索"},
+		{Name: "action", Description: "操作类型，如 user.login、post.force_delete"},
+		{Name: "actor_id", Description: "操作人ID"},
+		{Name: "target_type", Description: "对象类型：user、post、comment、category、report、sensitive_word、sanction"},
+		{Name: "target_id", Description: "对象ID"},
+		{Name: "from", Description: "开始日期，格式 2006-01-02"},
+		{Name: "to", Description: "结束日期（包含当天），格式 2006-01-02"},
+	}
+	openapi.Describe(handlers.AdminListAuditLogs, openapi.Endpoint{
+		Summary:    "审计日志列表，按时间倒序",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermViewAuditLog),
+		Query:      auditQuery,
+		Response:   serializers.AuditLog{},
+		List:       true,
+	})
+	openapi.Describe(handlers.ExportAuditLogs, openapi.Endpoint{
+		Summary:    "按筛选条件导出审计日志，响应为 JSON Lines 文件（application/x-ndjson），每行一条，按时间正序",
+		Tags:       []string{"admin"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermViewAuditLog),
+		Query:      auditQuery,
+	})
+
+	// 用户处罚
+	openapi.Describe(handlers.ListSanctions, openapi.Endpoint{
+		Summary:    "处罚列表，默认只列出生效中的禁言和封禁",
+		Tags:       []string{"sanctions"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermSanction),
+		Query: []openapi.Parameter{
+			{Name: "status", Description: "为 all 时包含已到期和已撤销的处罚"},
+			{Name: "user_id", Description: "只列出指定用户的处罚"},
+		},
+		Response: serializers.Sanction{},
+		List:     true,
+	})
+	openapi.Describe(handlers.CreateSanction, openapi.Endpoint{
+		Summary:    "禁言、封禁用户或封禁 IP，duration_hours 为0表示永久",
+		Tags:       []string{"sanctions"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermSanction),
+		Request:    handlers.CreateSanctionRequest{},
+		Response:   serializers.Sanction{},
+		Status:     http.StatusCreated,
+	})
+	openapi.Describe(handlers.RevokeSanction, openapi.Endpoint{
+		Summary:    "提前解除处罚",
+		Tags:       []string{"sanctions"},
+		Scope:      models.ScopeAdmin,
+		Permission: string(models.PermSanction),
+	})
+
+	// 个人访问令牌
+	openapi.Describe(handlers.GetAccessTokens, openapi.Endpoint{
+		Summary:  "我的访问令牌",
+		Tags:     []string{"tokens"},
+		Session:  true,
+		Response: []serializers.AccessToken{},
+	})
+	openapi.Describe(handlers.CreateAccessToken, openapi.Endpoint{
+		Summary:  "创建访问令牌，明文令牌只在响应中出现一次",
+		Tags:     []string{"tokens"},
+		Session:  true,
+		Request:  handlers.CreateAccessTokenRequest{},
+		Response: serializers.CreatedAccessToken{},
+		Status:   http.StatusCreated,
+	})
+	openapi.Describe(handlers.DeleteAccessToken, openapi.Endpoint{
+		Summary: "撤销访问令牌",
+		Tags:    []string{"tokens"},
+		Session: true,
+	})
+
+	// 登录设备
+	openapi.Describe(handlers.GetSessions, openapi.Endpoint{
+		Summary:  "已登录的设备",
+		Tags:     []string{"sessions"},
+		Session:  true,
+		Response: []serializers.Session{},
+	})
+	openapi.Describe(handlers.DeleteSession, openapi.Endpoint{
+		Summary: "让指定设备下线",
+		Tags:    []string{"sessions"},
+		Session: true,
+	})
+	openapi.Describe(handlers.DeleteOtherSessions, openapi.Endpoint{
+		Summary: "让当前设备以外的所有设备下线",
+		Tags:    []string{"sessions"},
+		Session: true,
+	})
+
+	// 关联的第三方账号
+	openapi.Describe(handlers.GetIdentities, openapi.Endpoint{
+		Summary:  "已关联的第三方账号",
+		Tags:     []string{"identities"},
+		Session:  true,
+		Response: []serializers.Identity{},
+	})
+	openapi.Describe(handlers.UnlinkIdentity, openapi.Endpoint{
+		Summary: "解除关联第三方账号，账户至少需要保留一种登录方式",
+		Tags:    []string{"identities"},
+		Session: true,
+	})
+
+	// 两步验证
+	openapi.Describe(handlers.SetupTwoFactor, openapi.Endpoint{
+		Summary:  "生成两步验证密钥和二维码",
+		Tags:     []string{"two-factor"},
+		Session:  true,
+		Response: serializers.TwoFactorSetup{},
+	})
+	openapi.Describe(handlers.EnableTwoFactor, openapi.Endpoint{
+		Summary:  "用验证码确认并启用两步验证，返回恢复码",
+		Tags:     []string{"two-factor"},
+		Session:  true,
+		Request:  handlers.TwoFactorCodeRequest{},
+		Response: serializers.RecoveryCodes{},
+	})
+	openapi.Describe(handlers.DisableTwoFactor, openapi.Endpoint{
+		Summary: "关闭两步验证",
+		Tags:    []string{"two-factor"},
+		Session: true,
+		Request: handlers.TwoFactorCodeRequest{},
+	})
+	openapi.Describe(handlers.RegenerateRecoveryCodes, openapi.Endpoint{
+		Summary:  "重新生成恢复码，旧的恢复码全部失效",
+		Tags:     []string{"two-factor"},
+		Session:  true,
+		Request:  handlers.TwoFactorCodeRequest{},
+		Response: serializers.RecoveryCodes{},
+	})
+}
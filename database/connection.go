@@ -56,7 +56,16 @@ func initDB() {
 	sqlDB.SetConnMaxLifetime(time.Hour)      // 连接最大存活时间
 
 	// 自动迁移（创建表）
+	// 邮箱验证上线前注册的用户视为已验证，区分密码来源之前的用户视为已设置密码
+	grandfatherEmailVerified := !DB.Migrator().HasColumn(&models.User{}, "EmailVerified")
+	grandfatherPasswordSet := !DB.Migrator().HasColumn(&models.User{}, "PasswordSet")
 	DB.AutoMigrate(&models.User{})
+	if grandfatherEmailVerified {
+		DB.Exec("UPDATE users SET email_verified = ?, email_verified_at = created_at", true)
+	}
+	if grandfatherPasswordSet {
+		DB.Exec("UPDATE users SET password_set = ?", true)
+	}
     DB.AutoMigrate(&models.Category{})
 	DB.AutoMigrate(&models.Post{})
 	DB.AutoMigrate(&models.Comment{})
@@ -64,6 +73,16 @@ func initDB() {
 	DB.AutoMigrate(&models.PostFavorite{})
 	DB.AutoMigrate(&models.UserOnlineStatus{})
     DB.AutoMigrate(&models.PasswordReset{})
+	DB.AutoMigrate(&models.AccessToken{})
+	DB.AutoMigrate(&models.UserSession{})
+	DB.AutoMigrate(&models.RecoveryCode{})
+	DB.AutoMigrate(&models.LoginAttempt{})
+	DB.AutoMigrate(&models.EmailVerification{})
+	DB.AutoMigrate(&models.UserIdentity{})
+	DB.AutoMigrate(&models.Report{})
+	DB.AutoMigrate(&models.SensitiveWord{})
+	DB.AutoMigrate(&models.UserSanction{})
+	DB.AutoMigrate(&models.AuditLog{})
 }
 
 func InitDB() {
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlDuplicateEntry MySQL 违反唯一索引的错误码
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateKey 判断错误是否由违反唯一索引引起，未开启 TranslateError 时按 MySQL 错误码判断
+func IsDuplicateKey(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestIsDuplicateKey(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'post-1-2' for key 'idx_reports_target_user'"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"MySQL 1062", duplicate, true},
+		{"包装后的 MySQL 1062", fmt.Errorf("create report: %w", duplicate), true},
+		{"TranslateError 转换后的错误", gorm.ErrDuplicatedKey, true},
+		{"其他 MySQL 错误", &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, false},
+		{"其他错误", errors.New("connection refused"), false},
+		{"记录不存在", gorm.ErrRecordNotFound, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsDuplicateKey(tt.err); got != tt.want {
+			t.Errorf("%s: IsDuplicateKey = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
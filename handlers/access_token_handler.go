@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每个用户最多持有的令牌数
+const maxAccessTokensPerUser = 20
+
+// GetAccessTokens 当前用户的个人访问令牌列表
+func GetAccessTokens(c *gin.Context) {
+	user := UserFromContext(c)
+
+	tokens, err := ListAccessTokens(user.ID)
+	if err != nil {
+		responses.Internal(c, "获取令牌失败")
+		return
+	}
+	responses.OK(c, "", serializers.NewAccessTokens(tokens))
+}
+
+// CreateAccessToken 创建个人访问令牌，明文令牌只返回这一次
+func CreateAccessToken(c *gin.Context) {
+	user := UserFromContext(c)
+
+	var requestData CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	scopes := make([]string, 0, len(requestData.Scopes))
+	seen := map[string]bool{}
+	for _, scope := range requestData.Scopes {
+		if !models.IsValidScope(scope) {
+			responses.BadRequest(c, "无效的权限范围: "+scope)
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	var count int64
+	database.DB.Model(&models.AccessToken{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= maxAccessTokensPerUser {
+		responses.BadRequest(c, "令牌数量已达上限，请先撤销不再使用的令牌")
+		return
+	}
+
+	plain, hash, err := utils.GenerateAccessToken()
+	if err != nil {
+		responses.Internal(c, "生成令牌失败")
+		return
+	}
+
+	token := models.AccessToken{
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(requestData.Name),
+		TokenHash: hash,
+		Prefix:    plain[:len(utils.AccessTokenPrefix)+6],
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if requestData.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, requestData.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		responses.Internal(c, "创建令牌失败")
+		return
+	}
+	recordAudit(c, accountAuditEntry(models.AuditTokenCreate, user, tokenAuditValues(&token)))
+
+	responses.Created(c, "令牌创建成功，请立即复制保存，关闭后将无法再次查看", serializers.CreatedAccessToken{
+		AccessToken: serializers.NewAccessToken(&token),
+		Token:       plain,
+	})
+}
+
+// DeleteAccessToken 撤销个人访问令牌，只能撤销自己的令牌
+func DeleteAccessToken(c *gin.Context) {
+	user := UserFromContext(c)
+
+	var token models.AccessToken
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&token).Error; err != nil {
+		responses.NotFound(c, "令牌不存在")
+		return
+	}
+	if err := database.DB.Delete(&token).Error; err != nil {
+		responses.Internal(c, "撤销令牌失败")
+		return
+	}
+
+	entry := accountAuditEntry(models.AuditTokenDelete, user, nil)
+	entry.Before = tokenAuditValues(&token)
+	recordAudit(c, entry)
+	responses.OK(c, "令牌已撤销", nil)
+}
+
+// tokenAuditValues 审计日志中记录的令牌信息，不包含令牌本身
+func tokenAuditValues(token *models.AccessToken) auditValues {
+	return auditValues{
+		"token_id": token.ID,
+		"name":     token.Name,
+		"prefix":   token.Prefix,
+		"scopes":   token.Scopes,
+	}
+}
+
+// ListAccessTokens 查询用户的全部令牌，设置页面也会用到
+func ListAccessTokens(userID uint) ([]models.AccessToken, error) {
+	var tokens []models.AccessToken
+	err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
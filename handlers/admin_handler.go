@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 管理后台：站点统计，用户、文章、评论和分类的列表和批量操作。
+// 页面和 /api/v1/admin 下的接口使用同样的筛选参数。
+
+const adminPageSize = 20
+
+var errCategoryNotFound = errors.New("category not found")
+
+// AdminDashboardPage 管理后台首页，展示站点统计
+func AdminDashboardPage(c *gin.Context) {
+	user, ok := adminPageUser(c, models.PermModerate, "/admin")
+	if !ok {
+		return
+	}
+	responses.HTML(c, http.StatusOK, "admin.tmpl", gin.H{
+		"user":    user,
+		"section": "dashboard",
+		"stats":   CachedSiteStats(),
+	})
+}
+
+// AdminUsersPage 用户管理页面
+func AdminUsersPage(c *gin.Context) {
+	user, ok := adminPageUser(c, models.PermManageUsers, "/admin/users")
+	if !ok {
+		return
+	}
+	users, meta, _ := queryAdminUsers(c)
+	data := adminPageData(c, user, "users", meta, "q", "role", "deleted")
+	data["users"] = users
+	responses.HTML(c, http.StatusOK, "admin.tmpl", data)
+}
+
+// AdminPostsPage 文章管理页面
+func AdminPostsPage(c *gin.Context) {
+	user, ok := adminPageUser(c, models.PermManagePosts, "/admin/posts")
+	if !ok {
+		return
+	}
+	posts, meta, _ := queryAdminPosts(c)
+	data := adminPageData(c, user, "posts", meta, "q", "category_id", "status", "user_id", "deleted")
+	data["posts"] = posts
+	data["categories"] = allCategories()
+	responses.HTML(c, http.StatusOK, "admin.tmpl", data)
+}
+
+// AdminCommentsPage 评论管理页面
+func AdminCommentsPage(c *gin.Context) {
+	user, ok := adminPageUser(c, models.PermManageComments, "/admin/comments")
+	if !ok {
+		return
+	}
+	comments, meta, _ := queryAdminComments(c)
+	data := adminPageData(c, user, "comments", meta, "q", "post_id", "user_id", "status", "deleted")
+	data["comments"] = comments
+	data["postTitles"] = postTitles(comments)
+	responses.HTML(c, http.StatusOK, "admin.tmpl", data)
+}
+
+// AdminCategoriesPage 分类管理页面
+func AdminCategoriesPage(c *gin.Context) {
+	user, ok := adminPageUser(c, models.PermManageCategories, "/admin/categories")
+	if !ok {
+		return
+	}
+	categories, meta, _ := queryAdminCategories(c)
+	data := adminPageData(c, user, "categories", meta, "q", "status", "deleted")
+	var parents []models.Category
+	database.DB.Scopes(policies.OrderedCategories()).Where("parent_id = ?", 0).Find(&parents)
+	parentNames := make(map[uint]string, len(parents))
+	for _, parent := range parents {
+		parentNames[parent.ID] = parent.Name
+	}
+	data["categories"] = categories
+	data["postCounts"] = categoryPostCounts(categories)
+	data["parents"] = parents
+	data["parentNames"] = parentNames
+	responses.HTML(c, http.StatusOK, "admin.tmpl", data)
+}
+
+// adminPageUser 检查当前用户能否打开管理后台页面，未登录时跳转到登录页
+func adminPageUser(c *gin.Context, perm models.Permission, path string) (*models.User, bool) {
+	user := UserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login?redirect_to="+path)
+		return nil, false
+	}
+	if !user.Can(perm) {
+		responses.HTML(c, http.StatusForbidden, "403.tmpl", gin.H{"Message": "没有访问管理后台的权限", "user": user})
+		return nil, false
+	}
+	return user, true
+}
+
+// adminPageData 列表页面共用的数据：当前的筛选条件和保留筛选条件的翻页链接
+func adminPageData(c *gin.Context, user *models.User, section string, meta *responses.Meta, filters ...string) gin.H {
+	filter := make(map[string]string, len(filters))
+	for _, key := range filters {
+		filter[key] = strings.TrimSpace(c.Query(key))
+	}
+	pageURL := func(page int) string {
+		query := c.Request.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		return c.Request.URL.Path + "?" + query.Encode()
+	}
+	data := gin.H{
+		"user":    user,
+		"section": section,
+		"filter":  filter,
+		"meta":    meta,
+	}
+	if meta.Page > 1 {
+		data["prevURL"] = pageURL(meta.Page - 1)
+	}
+	if meta.Page < meta.TotalPages {
+		data["nextURL"] = pageURL(meta.Page + 1)
+	}
+	return data
+}
+
+// AdminStats 站点统计，缓存1分钟
+func AdminStats(c *gin.Context) {
+	responses.OK(c, "", CachedSiteStats())
+}
+
+// AdminListUsers 用户列表，q 按用户名或邮箱搜索，role 按角色筛选
+func AdminListUsers(c *gin.Context) {
+	users, meta, err := queryAdminUsers(c)
+	if err != nil {
+		responses.Internal(c, "获取用户列表失败")
+		return
+	}
+	responses.List(c, serializers.UsersFor(UserFromContext(c), AccessTokenFromContext(c), users), meta)
+}
+
+// AdminListPosts 文章列表，q 按标题搜索，可按分类、状态和作者筛选
+func AdminListPosts(c *gin.Context) {
+	posts, meta, err := queryAdminPosts(c)
+	if err != nil {
+		responses.Internal(c, "获取文章列表失败")
+		return
+	}
+	responses.List(c, serializers.NewPosts(posts), meta)
+}
+
+// AdminListComments 评论列表，q 按内容搜索，可按文章、作者和状态筛选
+func AdminListComments(c *gin.Context) {
+	comments, meta, err := queryAdminComments(c)
+	if err != nil {
+		responses.Internal(c, "获取评论列表失败")
+		return
+	}
+	responses.List(c, serializers.NewComments(comments), meta)
+}
+
+// AdminListCategories 分类列表，包含每个分类下的文章数
+func AdminListCategories(c *gin.Context) {
+	categories, meta, err := queryAdminCategories(c)
+	if err != nil {
+		responses.Internal(c, "获取分类列表失败")
+		return
+	}
+	responses.List(c, serializers.NewCategories(categories, categoryPostCounts(categories)), meta)
+}
+
+func queryAdminUsers(c *gin.Context) ([]models.User, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, adminPageSize)
+	query := adminDeleted(c, database.DB.Model(&models.User{}))
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("name LIKE ? OR email LIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	var total int64
+	query.Count(&total)
+
+	var users []models.User
+	err := query.Order("id DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&users).Error
+	return users, responses.NewMeta(page, perPage, total), err
+}
+
+func queryAdminPosts(c *gin.Context) ([]models.Post, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, adminPageSize)
+	query := adminDeleted(c, database.DB.Model(&models.Post{}))
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("title LIKE ?", "%"+q+"%")
+	}
+	for _, key := range []string{"category_id", "user_id"} {
+		if id, err := strconv.Atoi(c.Query(key)); err == nil && id > 0 {
+			query = query.Where(key+" = ?", id)
+		}
+	}
+	query = adminStatus(c, query)
+	var total int64
+	query.Count(&total)
+
+	var posts []models.Post
+	err := query.Preload("User").Order("id DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&posts).Error
+	return posts, responses.NewMeta(page, perPage, total), err
+}
+
+func queryAdminComments(c *gin.Context) ([]models.Comment, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, adminPageSize)
+	query := adminDeleted(c, database.DB.Model(&models.Comment{}))
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("markdown LIKE ?", "%"+q+"%")
+	}
+	for _, key := range []string{"post_id", "user_id"} {
+		if id, err := strconv.Atoi(c.Query(key)); err == nil && id > 0 {
+			query = query.Where(key+" = ?", id)
+		}
+	}
+	query = adminStatus(c, query)
+	var total int64
+	query.Count(&total)
+
+	var comments []models.Comment
+	err := query.Preload("User").Order("id DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&comments).Error
+	return comments, responses.NewMeta(page, perPage, total), err
+}
+
+func queryAdminCategories(c *gin.Context) ([]models.Category, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, adminPageSize)
+	query := adminDeleted(c, database.DB.Model(&models.Category{}))
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("name LIKE ? OR alias LIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+	query = adminStatus(c, query)
+	var total int64
+	query.Count(&total)
+
+	var categories []models.Category
+	err := query.Scopes(policies.OrderedCategories()).Offset((page - 1) * perPage).Limit(perPage).Find(&categories).Error
+	return categories, responses.NewMeta(page, perPage, total), err
+}
+
+// adminDeleted 按 deleted 参数筛选软删除的记录：only 只列出已删除的，all 包含已删除的，默认不包含
+func adminDeleted(c *gin.Context, query *gorm.DB) *gorm.DB {
+	switch c.Query("deleted") {
+	case "only":
+		return query.Unscoped().Where("deleted_at IS NOT NULL")
+	case "all":
+		return query.Unscoped()
+	}
+	return query
+}
+
+// adminStatus 按 status 参数（1 正常 2 禁用 3 待审核）筛选
+func adminStatus(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if status, err := strconv.Atoi(c.Query("status")); err == nil && status > 0 {
+		query = query.Where("status_code = ?", status)
+	}
+	return query
+}
+
+// allCategories 文章筛选和修改分类时可选的分类
+func allCategories() []models.Category {
+	var categories []models.Category
+	database.DB.Scopes(policies.OrderedCategories()).Find(&categories)
+	return categories
+}
+
+// AdminBulkUsers 批量删除或恢复用户
+func AdminBulkUsers(c *gin.Context) {
+	requestData, ok := bindAdminBulk(c, "delete", "restore")
+	if !ok {
+		return
+	}
+
+	if requestData.Action == "delete" && slices.Contains(requestData.IDs, UserFromContext(c).ID) {
+		responses.BadRequest(c, "不能删除自己")
+		return
+	}
+
+	before := userAuditSnapshots(requestData.IDs)
+	var result *gorm.DB
+	switch requestData.Action {
+	case "delete":
+		result = database.DB.Where("id IN ?", requestData.IDs).Delete(&models.User{})
+	case "restore":
+		result = restoreDeleted(database.DB, &models.User{}, requestData.IDs, false)
+	}
+	if result.Error != nil {
+		responses.Internal(c, "批量操作失败")
+		return
+	}
+	auditBulk(c, adminBulkAuditActions[models.AuditTargetUser][requestData.Action], models.AuditTargetUser, before, userAuditSnapshots(requestData.IDs))
+	bulkDone(c, result.RowsAffected)
+}
+
+// AdminBulkPosts 批量删除、恢复文章，修改文章分类或状态
+func AdminBulkPosts(c *gin.Context) {
+	requestData, ok := bindAdminBulk(c, "delete", "restore", "move", "set_status")
+	if !ok {
+		return
+	}
+
+	before := postAuditSnapshots(requestData.IDs)
+	var affected int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		switch requestData.Action {
+		case "delete":
+			result = trashContent(tx, &models.Post{}, requestData.IDs, UserFromContext(c))
+		case "restore":
+			result = restoreDeleted(tx, &models.Post{}, requestData.IDs, true)
+			if result.Error == nil {
+				if err := recountReplies(tx, requestData.IDs); err != nil {
+					return err
+				}
+			}
+		case "move":
+			var category models.Category
+			if err := tx.First(&category, requestData.CategoryID).Error; err != nil {
+				return errCategoryNotFound
+			}
+			// 文章同时保存分类名称，修改分类时一起更新
+			result = tx.Model(&models.Post{}).Where("id IN ?", requestData.IDs).Updates(map[string]interface{}{
+				"category_id": category.ID,
+				"category":    category.Name,
+			})
+		case "set_status":
+			reviewer := UserFromContext(c)
+			result = tx.Model(&models.Post{}).Where("id IN ?", requestData.IDs).
+				Updates(moderationUpdates(requestData.StatusCode, strings.TrimSpace(requestData.Reason), reviewer))
+			if result.Error == nil && requestData.StatusCode != models.StatusPending {
+				for _, id := range requestData.IDs {
+					if err := closeReports(tx, models.ReportTargetPost, id, reportOutcome(requestData.StatusCode), reviewer); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if errors.Is(err, errCategoryNotFound) {
+		responses.BadRequest(c, "分类不存在")
+		return
+	}
+	if err != nil {
+		responses.Internal(c, "批量操作失败")
+		return
+	}
+	reindexPosts(requestData.IDs...)
+	auditBulk(c, adminBulkAuditActions[models.AuditTargetPost][requestData.Action], models.AuditTargetPost, before, postAuditSnapshots(requestData.IDs))
+	bulkDone(c, affected)
+}
+
+// AdminBulkComments 批量删除、恢复评论或修改评论状态，同时更新文章的回复数
+func AdminBulkComments(c *gin.Context) {
+	requestData, ok := bindAdminBulk(c, "delete", "restore", "set_status")
+	if !ok {
+		return
+	}
+
+	before := commentAuditSnapshots(requestData.IDs)
+	var affected int64
+	var comments []models.Comment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		switch requestData.Action {
+		case "delete":
+			if err := tx.Where("id IN ?", requestData.IDs).Find(&comments).Error; err != nil || len(comments) == 0 {
+				return err
+			}
+			if err := trashContent(tx, &models.Comment{}, requestData.IDs, UserFromContext(c)).Error; err != nil {
+				return err
+			}
+			affected = int64(len(comments))
+			return adjustReplies(tx, comments, -1)
+		case "restore":
+			if err := tx.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", requestData.IDs).Find(&comments).Error; err != nil || len(comments) == 0 {
+				return err
+			}
+			result := restoreDeleted(tx, &models.Comment{}, requestData.IDs, true)
+			if result.Error != nil {
+				return result.Error
+			}
+			affected = result.RowsAffected
+			return adjustReplies(tx, comments, 1)
+		default:
+			if err := tx.Where("id IN ?", requestData.IDs).Find(&comments).Error; err != nil {
+				return err
+			}
+			reviewer := UserFromContext(c)
+			reason := strings.TrimSpace(requestData.Reason)
+			for i := range comments {
+				if err := updateCommentStatus(tx, &comments[i], requestData.StatusCode, reason, reviewer); err != nil {
+					return err
+				}
+				if requestData.StatusCode != models.StatusPending {
+					if err := closeReports(tx, models.ReportTargetComment, comments[i].ID, reportOutcome(requestData.StatusCode), reviewer); err != nil {
+						return err
+					}
+				}
+			}
+			affected = int64(len(comments))
+			return nil
+		}
+	})
+	if err != nil {
+		responses.Internal(c, "批量操作失败")
+		return
+	}
+	reindexCommentPosts(comments...)
+	auditBulk(c, adminBulkAuditActions[models.AuditTargetComment][requestData.Action], models.AuditTargetComment, before, commentAuditSnapshots(requestData.IDs))
+	bulkDone(c, affected)
+}
+
+// AdminBulkCategories 批量删除、恢复分类或修改分类状态，分类下的文章不受影响；有子分类的分类需要和子分类一起删除
+func AdminBulkCategories(c *gin.Context) {
+	requestData, ok := bindAdminBulk(c, "delete", "restore", "set_status")
+	if !ok {
+		return
+	}
+
+	before := categoryAuditSnapshots(requestData.IDs)
+	var result *gorm.DB
+	switch requestData.Action {
+	case "delete":
+		var children int64
+		database.DB.Model(&models.Category{}).Where("parent_id IN ? AND id NOT IN ?", requestData.IDs, requestData.IDs).Count(&children)
+		if children > 0 {
+			responses.BadRequest(c, "请先删除或移走选中分类下的子分类")
+			return
+		}
+		result = database.DB.Where("id IN ?", requestData.IDs).Delete(&models.Category{})
+	case "restore":
+		result = restoreDeleted(database.DB, &models.Category{}, requestData.IDs, false)
+	case "set_status":
+		result = database.DB.Model(&models.Category{}).Where("id IN ?", requestData.IDs).Update("status_code", requestData.StatusCode)
+	}
+	if result.Error != nil {
+		responses.Internal(c, "批量操作失败")
+		return
+	}
+	auditBulk(c, adminBulkAuditActions[models.AuditTargetCategory][requestData.Action], models.AuditTargetCategory, before, categoryAuditSnapshots(requestData.IDs))
+	bulkDone(c, result.RowsAffected)
+}
+
+// bindAdminBulk 解析批量操作请求，检查对象是否支持该操作
+func bindAdminBulk(c *gin.Context, actions ...string) (*AdminBulkRequest, bool) {
+	var requestData AdminBulkRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return nil, false
+	}
+	if !slices.Contains(actions, requestData.Action) {
+		responses.BadRequest(c, "不支持的操作: "+requestData.Action)
+		return nil, false
+	}
+	if requestData.Action == "move" && requestData.CategoryID == 0 {
+		responses.BadRequest(c, "请选择分类")
+		return nil, false
+	}
+	if requestData.Action == "set_status" && requestData.StatusCode == 0 {
+		responses.BadRequest(c, "请选择状态")
+		return nil, false
+	}
+	return &requestData, true
+}
+
+// adjustReplies 删除或恢复评论后修改文章的回复数，回复数只统计公开的评论
+func adjustReplies(tx *gorm.DB, comments []models.Comment, delta int) error {
+	changes := map[uint]int{}
+	for _, comment := range comments {
+		if comment.StatusCode == models.StatusNormal {
+			changes[comment.PostID] += delta
+		}
+	}
+	for postID, change := range changes {
+		err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			UpdateColumn("replies", gorm.Expr("GREATEST(replies + ?, 0)", change)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bulkDone 批量操作完成，清除统计缓存并返回受影响的记录数
+func bulkDone(c *gin.Context, affected int64) {
+	InvalidateSiteStats()
+	responses.OK(c, fmt.Sprintf("已处理 %d 条记录", affected), gin.H{"affected": affected})
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 审计日志：管理操作和登录、修改密码等账号安全相关的操作写入 audit_logs 表，
+// 只能新增，管理员可以在后台搜索和导出为 JSON Lines。
+
+// auditValues 操作对象中需要记录的字段
+type auditValues map[string]interface{}
+
+// auditEntry 一条待写入的审计日志。Before 为 nil 表示新建，After 为 nil 表示永久删除
+type auditEntry struct {
+	Action      string
+	TargetType  string
+	TargetID    uint
+	TargetLabel string
+	Before      auditValues
+	After       auditValues
+}
+
+// recordAudit 以当前登录用户的身份写入审计日志
+func recordAudit(c *gin.Context, entries ...auditEntry) {
+	recordAuditAs(c, UserFromContext(c), entries...)
+}
+
+// recordAuditAs 以指定用户的身份写入审计日志，用于登录、重置密码等上下文中还没有当前用户的场景。
+// 写入失败只打印错误，不影响已经完成的操作。
+func recordAuditAs(c *gin.Context, actor *models.User, entries ...auditEntry) {
+	saveAuditLogs(actor, c.ClientIP(), c.Request.UserAgent(), entries)
+}
+
+// recordSystemAudit 写入定时任务等没有请求上下文的操作，操作人记为「系统」
+func recordSystemAudit(entries ...auditEntry) {
+	saveAuditLogs(&models.User{Name: "系统"}, "", "", entries)
+}
+
+func saveAuditLogs(actor *models.User, ip, userAgent string, entries []auditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	records := make([]models.AuditLog, 0, len(entries))
+	for _, entry := range entries {
+		record := models.AuditLog{
+			Action:      entry.Action,
+			TargetType:  entry.TargetType,
+			TargetID:    entry.TargetID,
+			TargetLabel: truncate(entry.TargetLabel, 255),
+			IPAddress:   ip,
+			UserAgent:   truncate(userAgent, 500),
+		}
+		if actor != nil {
+			record.ActorID = actor.ID
+			record.ActorName = actor.Name
+		}
+		if changes := auditDiff(entry.Before, entry.After); len(changes) > 0 {
+			if data, err := json.Marshal(changes); err == nil {
+				record.Changes = string(data)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := database.DB.Create(&records).Error; err != nil {
+		fmt.Printf("写入审计日志失败: %v\n", err)
+	}
+}
+
+// auditDiff 比较操作前后的字段，只保留发生变化的部分
+func auditDiff(before, after auditValues) map[string]models.AuditChange {
+	changes := map[string]models.AuditChange{}
+	for key, from := range before {
+		if to, ok := after[key]; !ok || !reflect.DeepEqual(from, to) {
+			changes[key] = models.AuditChange{From: from, To: to}
+		}
+	}
+	for key, to := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = models.AuditChange{To: to}
+		}
+	}
+	return changes
+}
+
+// auditBulk 批量操作完成后调用，比较操作前后查询到的记录，为每条发生变化的记录写入一条审计日志
+func auditBulk(c *gin.Context, action, targetType string, before, after map[uint]auditValues) {
+	ids := make([]uint, 0, len(after))
+	for id := range after {
+		if len(auditDiff(before[id], after[id])) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	entries := make([]auditEntry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, auditEntry{
+			Action:      action,
+			TargetType:  targetType,
+			TargetID:    id,
+			TargetLabel: auditLabel(after[id]),
+			Before:      before[id],
+			After:       after[id],
+		})
+	}
+	recordAudit(c, entries...)
+}
+
+// accountAuditEntry 以用户本人为对象的审计日志，用于登录、修改密码等账号安全相关的操作
+func accountAuditEntry(action string, user *models.User, after auditValues) auditEntry {
+	return auditEntry{
+		Action:      action,
+		TargetType:  models.AuditTargetUser,
+		TargetID:    user.ID,
+		TargetLabel: user.Name,
+		After:       after,
+	}
+}
+
+// auditLabel 从记录的字段中取标题或名称作为对象说明
+func auditLabel(values auditValues) string {
+	for _, key := range []string{"title", "name", "word", "content"} {
+		if label, ok := values[key].(string); ok && label != "" {
+			return label
+		}
+	}
+	return ""
+}
+
+func userAuditValues(user *models.User) auditValues {
+	return auditValues{
+		"name":           user.Name,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"role":           user.Role,
+		"level":          user.Level,
+		"deleted":        user.DeletedAt.Valid,
+	}
+}
+
+func postAuditValues(post *models.Post) auditValues {
+	return auditValues{
+		"title":       post.Title,
+		"user_id":     post.UserId,
+		"category_id": post.CategoryId,
+		"status_code":       post.StatusCode,
+		"moderation_reason": post.ModerationReason,
+		"deleted":           post.DeletedAt.Valid,
+	}
+}
+
+func commentAuditValues(comment *models.Comment) auditValues {
+	return auditValues{
+		"content":     excerpt(comment.Markdown, 100),
+		"post_id":     comment.PostID,
+		"user_id":     comment.UserID,
+		"status_code":       comment.StatusCode,
+		"moderation_reason": comment.ModerationReason,
+		"deleted":           comment.DeletedAt.Valid,
+	}
+}
+
+func categoryAuditValues(category *models.Category) auditValues {
+	return auditValues{
+		"name":           category.Name,
+		"alias":          category.Alias,
+		"parent_id":      category.ParentID,
+		"description":    category.Description,
+		"icon":           category.Icon,
+		"color":          category.Color,
+		"sort_order":     category.SortOrder,
+		"min_post_level": category.MinPostLevel,
+		"is_recommended": category.IsRecommended,
+		"recommend_rank": category.RecommendRank,
+		"status_code":    category.StatusCode,
+		"deleted":        category.DeletedAt.Valid,
+	}
+}
+
+func wordAuditValues(word *models.SensitiveWord) auditValues {
+	return auditValues{
+		"word":   word.Word,
+		"action": word.Action,
+	}
+}
+
+func sanctionAuditValues(sanction *models.UserSanction) auditValues {
+	return auditValues{
+		"type":       sanction.Type,
+		"user_id":    sanction.UserID,
+		"ip_address": sanction.IPAddress,
+		"reason":     sanction.Reason,
+		"expires_at": sanction.ExpiresAt,
+		"revoked":    sanction.RevokedAt != nil,
+	}
+}
+
+// 批量操作前后查询记录的字段，包含已删除的记录
+
+func userAuditSnapshots(ids []uint) map[uint]auditValues {
+	var users []models.User
+	database.DB.Unscoped().Where("id IN ?", ids).Find(&users)
+	result := make(map[uint]auditValues, len(users))
+	for i := range users {
+		result[users[i].ID] = userAuditValues(&users[i])
+	}
+	return result
+}
+
+func postAuditSnapshots(ids []uint) map[uint]auditValues {
+	var posts []models.Post
+	database.DB.Unscoped().Where("id IN ?", ids).Find(&posts)
+	result := make(map[uint]auditValues, len(posts))
+	for i := range posts {
+		result[posts[i].ID] = postAuditValues(&posts[i])
+	}
+	return result
+}
+
+func commentAuditSnapshots(ids []uint) map[uint]auditValues {
+	var comments []models.Comment
+	database.DB.Unscoped().Where("id IN ?", ids).Find(&comments)
+	result := make(map[uint]auditValues, len(comments))
+	for i := range comments {
+		result[comments[i].ID] = commentAuditValues(&comments[i])
+	}
+	return result
+}
+
+func categoryAuditSnapshots(ids []uint) map[uint]auditValues {
+	var categories []models.Category
+	database.DB.Unscoped().Where("id IN ?", ids).Find(&categories)
+	result := make(map[uint]auditValues, len(categories))
+	for i := range categories {
+		result[categories[i].ID] = categoryAuditValues(&categories[i])
+	}
+	return result
+}
+
+// wordAuditSnapshots 按词语查询敏感词，批量添加时用词语而不是ID定位
+func wordAuditSnapshots(texts []string) map[uint]auditValues {
+	var words []models.SensitiveWord
+	database.DB.Where("word IN ?", texts).Find(&words)
+	result := make(map[uint]auditValues, len(words))
+	for i := range words {
+		result[words[i].ID] = wordAuditValues(&words[i])
+	}
+	return result
+}
+
+// adminBulkAuditActions 批量操作对应的审计日志操作类型
+var adminBulkAuditActions = map[string]map[string]string{
+	models.AuditTargetUser: {
+		"delete":  models.AuditUserDelete,
+		"restore": models.AuditUserRestore,
+	},
+	models.AuditTargetPost: {
+		"delete":     models.AuditPostDelete,
+		"restore":    models.AuditPostRestore,
+		"move":       models.AuditPostMove,
+		"set_status": models.AuditPostStatus,
+	},
+	models.AuditTargetComment: {
+		"delete":     models.AuditCommentDelete,
+		"restore":    models.AuditCommentRestore,
+		"set_status": models.AuditCommentStatus,
+	},
+	models.AuditTargetCategory: {
+		"delete":     models.AuditCategoryDelete,
+		"restore":    models.AuditCategoryRestore,
+		"set_status": models.AuditCategoryUpdate,
+	},
+}
+
+// AdminAuditPage 审计日志页面
+func AdminAuditPage(c *gin.Context) {
+	user, ok := adminPageUser(c, models.PermViewAuditLog, "/admin/audit")
+	if !ok {
+		return
+	}
+	logs, meta, _ := queryAdminAuditLogs(c)
+	data := adminPageData(c, user, "audit", meta, "q", "action", "actor_id", "target_type", "target_id", "from", "to")
+	query := c.Request.URL.Query()
+	query.Del("page")
+	data["logs"] = logs
+	data["actions"] = models.AuditActions
+	data["targetTypes"] = models.AuditTargetTypes
+	data["exportURL"] = "/api/v1/admin/audit-logs/export?" + query.Encode()
+	responses.HTML(c, http.StatusOK, "admin.tmpl", data)
+}
+
+// AdminListAuditLogs 审计日志列表，q 按操作人、对象说明或 IP 搜索，可按操作类型、操作人、对象和日期筛选
+func AdminListAuditLogs(c *gin.Context) {
+	logs, meta, err := queryAdminAuditLogs(c)
+	if err != nil {
+		responses.Internal(c, "获取审计日志失败")
+		return
+	}
+	responses.List(c, serializers.NewAuditLogs(logs), meta)
+}
+
+// ExportAuditLogs 按列表的筛选条件导出审计日志，每行一条 JSON（JSON Lines），按时间先后排列
+func ExportAuditLogs(c *gin.Context) {
+	filename := "audit-logs-" + time.Now().Format("20060102-150405") + ".jsonl"
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	encoder.SetEscapeHTML(false)
+	exported := 0
+	var logs []models.AuditLog
+	err := filterAuditLogs(c, database.DB.Model(&models.AuditLog{})).FindInBatches(&logs, 500, func(tx *gorm.DB, batch int) error {
+		for i := range logs {
+			if err := encoder.Encode(serializers.NewAuditLog(&logs[i])); err != nil {
+				return err
+			}
+			exported++
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error
+	if err != nil {
+		// 响应头已经发出，只能记录错误，下载到的文件不完整
+		fmt.Printf("导出审计日志失败: %v\n", err)
+	}
+
+	recordAudit(c, auditEntry{
+		Action:     models.AuditLogExport,
+		TargetType: models.AuditTargetAuditLog,
+		After:      auditValues{"filter": c.Request.URL.RawQuery, "count": exported},
+	})
+}
+
+func queryAdminAuditLogs(c *gin.Context) ([]models.AuditLog, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, adminPageSize)
+	query := filterAuditLogs(c, database.DB.Model(&models.AuditLog{}))
+	var total int64
+	query.Count(&total)
+
+	var logs []models.AuditLog
+	err := query.Order("id DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&logs).Error
+	return logs, responses.NewMeta(page, perPage, total), err
+}
+
+// filterAuditLogs 审计日志的筛选条件，from 和 to 为 2006-01-02 格式的日期，包含当天
+func filterAuditLogs(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("actor_name LIKE ? OR target_label LIKE ? OR ip_address = ?", "%"+q+"%", "%"+q+"%", q)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	for _, key := range []string{"actor_id", "target_id"} {
+		if id, err := strconv.Atoi(c.Query(key)); err == nil && id > 0 {
+			query = query.Where(key+" = ?", id)
+		}
+	}
+	if from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local); err == nil {
+		query = query.Where("created_at >= ?", from)
+	}
+	if to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local); err == nil {
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+	return query
+}
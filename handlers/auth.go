@@ -5,35 +5,39 @@ import (
     "encoding/base64"
     "fmt"
     "net/http"
+    "net/url"
     "time"
     "gin-doniai/database"
+    "gin-doniai/mailer"
     "gin-doniai/models"
+    "gin-doniai/responses"
     "gin-doniai/utils"
     "github.com/gin-gonic/gin"
 )
 
+// 无论邮箱是否已注册都返回同一提示，避免被用来探测账号
+const forgotPasswordMessage = "如果该邮箱已注册，重置密码的链接已发送到您的邮箱，请查收"
+
 func ForgotPassword(c *gin.Context) {
-    var requestData struct {
-        Email string `json:"email" binding:"required,email"`
-    }
+    var requestData ForgotPasswordRequest
 
     // 绑定请求数据
     if err := c.ShouldBindJSON(&requestData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求数据格式错误",
-        })
+        responses.BadRequest(c, "请求数据格式错误")
+        return
+    }
+
+    // 限制同一邮箱和同一IP的发送频率
+    if wait, ok := checkForgotPassword(c, requestData.Email); !ok {
+        responses.TooManyRequests(c, wait, fmt.Sprintf("请求过于频繁，请%s后再试", formatWait(wait)))
         return
     }
 
     // 检查用户是否存在
     var user models.User
     if err := database.DB.Where("email = ?", requestData.Email).First(&user).Error; err != nil {
-        // 为了安全起见，即使用户不存在也返回成功消息
-        c.JSON(http.StatusOK, gin.H{
-            "success": true,
-            "message": "如果该邮箱存在，重置密码的链接已发送到您的邮箱",
-        })
+        // 为了安全起见，即使用户不存在也返回相同的消息
+        responses.OK(c, forgotPasswordMessage, nil)
         return
     }
 
@@ -50,34 +54,34 @@ func ForgotPassword(c *gin.Context) {
         ExpiresAt: expiresAt,
     }
 
-    // 保存到数据库
+    // 保存到数据库并把邮件加入发送队列，失败时只记录日志，
+    // 与邮箱不存在时返回相同的响应，避免被用来判断邮箱是否已注册
     if err := database.DB.Create(&passwordReset).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "服务器内部错误，请稍后重试",
-        })
+        fmt.Printf("保存重置密码记录失败: %v\n", err)
+        responses.OK(c, forgotPasswordMessage, nil)
         return
     }
 
-    // 构建重置链接
-    resetLink := fmt.Sprintf("http://%s/reset-password?token=%s", c.Request.Host, token)
-
-    // TODO: 发送邮件（这里只是模拟）
-    // 实际项目中应该集成邮件服务，如SMTP
-    fmt.Printf("发送重置密码邮件到: %s\n重置链接: %s\n", requestData.Email, resetLink)
-
-    // 返回成功响应
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "message": "重置密码的链接已发送到您的邮箱，请查收",
+    // 链接使用配置的站点地址而不是请求的 Host 头
+    resetLink := utils.AbsoluteURL("/reset-password?token=" + url.QueryEscape(token))
+    err := mailer.SendTemplate(user.Email, "reset_password", gin.H{
+        "SiteName":  "Doniai",
+        "Name":      user.Name,
+        "Link":      resetLink,
+        "ExpiresIn": "1小时",
     })
+    if err != nil {
+        fmt.Printf("重置密码邮件加入发送队列失败: %v\n", err)
+    }
+
+    responses.OK(c, forgotPasswordMessage, nil)
 }
 
 // ResetPassword 处理重置密码请求
 func ResetPassword(c *gin.Context) {
     token := c.Query("token")
     if token == "" {
-        c.HTML(http.StatusBadRequest, "reset-password.tmpl", gin.H{
+        responses.HTML(c, http.StatusBadRequest, "reset-password.tmpl", gin.H{
             "error": "无效的重置链接",
         })
         return
@@ -86,7 +90,7 @@ func ResetPassword(c *gin.Context) {
     // 查找重置记录
     var passwordReset models.PasswordReset
     if err := database.DB.Where("token = ? AND used = ?", token, false).First(&passwordReset).Error; err != nil {
-        c.HTML(http.StatusBadRequest, "reset-password.tmpl", gin.H{
+        responses.HTML(c, http.StatusBadRequest, "reset-password.tmpl", gin.H{
             "error": "重置链接无效或已过期",
         })
         return
@@ -94,79 +98,63 @@ func ResetPassword(c *gin.Context) {
 
     // 检查令牌是否过期
     if time.Now().After(passwordReset.ExpiresAt) {
-        c.HTML(http.StatusBadRequest, "reset-password.tmpl", gin.H{
+        responses.HTML(c, http.StatusBadRequest, "reset-password.tmpl", gin.H{
             "error": "重置链接已过期",
         })
         return
     }
 
     // 渲染重置密码页面
-    c.HTML(http.StatusOK, "reset-password.tmpl", gin.H{
+    responses.HTML(c, http.StatusOK, "reset-password.tmpl", gin.H{
         "token": token,
     })
 }
 
 // ProcessResetPassword 处理重置密码表单提交
 func ProcessResetPassword(c *gin.Context) {
-    var requestData struct {
-        Token    string `json:"token" binding:"required"`
-        Password string `json:"password" binding:"required,min=6"`
-    }
+    var requestData ResetPasswordRequest
 
     // 绑定请求数据
     if err := c.ShouldBindJSON(&requestData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求数据格式错误",
-        })
+        responses.BadRequest(c, "请求数据格式错误")
         return
     }
 
     // 查找重置记录
     var passwordReset models.PasswordReset
     if err := database.DB.Where("token = ? AND used = ?", requestData.Token, false).First(&passwordReset).Error; err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "重置链接无效或已过期",
-        })
+        responses.BadRequest(c, "重置链接无效或已过期")
         return
     }
 
     // 检查令牌是否过期
     if time.Now().After(passwordReset.ExpiresAt) {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "重置链接已过期",
-        })
+        responses.BadRequest(c, "重置链接已过期")
         return
     }
 
     // 查找用户
     var user models.User
     if err := database.DB.Where("email = ?", passwordReset.Email).First(&user).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "用户不存在",
-        })
+        responses.Internal(c, "用户不存在")
         return
     }
 
     // 加密新密码
     hashedPassword, err := utils.HashPassword(requestData.Password)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "密码加密失败",
-        })
+        responses.Internal(c, "密码加密失败")
         return
     }
 
-    // 更新用户密码
-    if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "更新密码失败",
-        })
+    // 更新用户密码，能收到重置邮件说明邮箱属于该用户，顺便标记为已验证
+    updates := map[string]interface{}{"password": hashedPassword, "password_set": true}
+    if !user.EmailVerified {
+        updates["email_verified"] = true
+        updates["email_verified_at"] = time.Now()
+    }
+    if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
+        responses.Internal(c, "更新密码失败")
         return
     }
 
@@ -176,11 +164,15 @@ func ProcessResetPassword(c *gin.Context) {
         fmt.Printf("标记密码重置令牌为已使用失败: %v\n", err)
     }
 
+    // 重置密码后所有设备都需要重新登录
+    if err := RevokeUserSessions(user.ID, ""); err != nil {
+        fmt.Printf("撤销用户会话失败: %v\n", err)
+    }
+    // 重置密码时没有登录，以账户本人的身份记录
+    recordAuditAs(c, &user, accountAuditEntry(models.AuditPasswordReset, &user, nil))
+
     // 返回成功响应
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "message": "密码重置成功，您可以使用新密码登录了",
-    })
+    responses.OK(c, "密码重置成功，您可以使用新密码登录了", nil)
 }
 
 func generateResetToken() string {
@@ -1,12 +1,273 @@
 package handlers
 
 import (
+	"net/http"
+	"regexp"
+	"strings"
+
 	"gin-doniai/database"
 	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// categoryAliasPattern 分类别名用在 /categories/:alias 中
+var categoryAliasPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
 func GetRecommendedCategories() ([]models.Category, error) {
 	var categories []models.Category
-	err := database.DB.Where("is_recommended = ? AND status_code = ?", 1, 1).Find(&categories).Error
+	err := database.DB.Scopes(policies.OrderedCategories()).Where("is_recommended = ? AND status_code = ?", 1, 1).Find(&categories).Error
 	return categories, err
 }
+
+// CategoryTree 正常状态的分类，按手动排序组织成两级
+func CategoryTree() []serializers.Category {
+	var categories []models.Category
+	database.DB.Scopes(policies.OrderedCategories()).Where("status_code = ?", models.StatusNormal).Find(&categories)
+	return serializers.NewCategoryTree(categories, categoryPostCounts(categories))
+}
+
+// CategoryAndChildren 分类及其正常状态的子分类的ID，分类页面的文章列表包含子分类的文章
+func CategoryAndChildren(category *models.Category) []uint {
+	var children []uint
+	database.DB.Model(&models.Category{}).
+		Where("parent_id = ? AND status_code = ?", category.ID, models.StatusNormal).
+		Pluck("id", &children)
+	return append([]uint{category.ID}, children...)
+}
+
+// Subcategories 正常状态的子分类，按手动排序
+func Subcategories(category *models.Category) []models.Category {
+	var children []models.Category
+	database.DB.Scopes(policies.OrderedCategories()).
+		Where("parent_id = ? AND status_code = ?", category.ID, models.StatusNormal).
+		Find(&children)
+	return children
+}
+
+// categoryPostCounts 统计分类下公开的文章数，不包含子分类和已删除的文章
+func categoryPostCounts(categories []models.Category) map[uint]int64 {
+	counts := make(map[uint]int64, len(categories))
+	if len(categories) == 0 {
+		return counts
+	}
+	ids := make([]uint, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	var rows []struct {
+		CategoryID uint
+		Total      int64
+	}
+	database.DB.Model(&models.Post{}).
+		Select("category_id, COUNT(*) AS total").
+		Where("category_id IN ? AND status_code = ?", ids, models.StatusNormal).
+		Group("category_id").
+		Scan(&rows)
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Total
+	}
+	return counts
+}
+
+// GetCategories 分类列表，子分类放在上级分类的 children 中
+func GetCategories(c *gin.Context) {
+	responses.OK(c, "", CategoryTree())
+}
+
+// GetCategory 获取单个分类及其子分类，禁用的分类只有管理员可见
+func GetCategory(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "分类不存在")
+		return
+	}
+	if category.StatusCode != models.StatusNormal && !UserFromContext(c).Can(models.PermManageCategories) {
+		responses.NotFound(c, "分类不存在")
+		return
+	}
+
+	children := Subcategories(&category)
+	counts := categoryPostCounts(append(children, category))
+	result := serializers.NewCategory(&category, counts[category.ID])
+	result.Children = serializers.NewCategories(children, counts)
+	responses.OK(c, "", result)
+}
+
+// CreateCategory 创建分类
+func CreateCategory(c *gin.Context) {
+	var requestData CategoryRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	var category models.Category
+	if !applyCategoryRequest(c, &category, &requestData) {
+		return
+	}
+	if err := database.DB.Create(&category).Error; err != nil {
+		responses.Internal(c, "分类创建失败")
+		return
+	}
+	recordAudit(c, categoryAuditEntry(models.AuditCategoryCreate, &category, nil))
+	InvalidateSiteStats()
+	responses.Created(c, "分类创建成功", serializers.NewCategory(&category, 0))
+}
+
+// UpdateCategory 修改分类，修改名称时同步更新文章中保存的分类名称
+func UpdateCategory(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "分类不存在")
+		return
+	}
+	var requestData CategoryRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	oldName := category.Name
+	before := categoryAuditValues(&category)
+	if !applyCategoryRequest(c, &category, &requestData) {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+		if category.Name == oldName {
+			return nil
+		}
+		return tx.Model(&models.Post{}).Where("category_id = ?", category.ID).Update("category", category.Name).Error
+	})
+	if err != nil {
+		responses.Internal(c, "分类更新失败")
+		return
+	}
+	recordAudit(c, categoryAuditEntry(models.AuditCategoryUpdate, &category, before))
+	InvalidateSiteStats()
+	responses.OK(c, "分类更新成功", serializers.NewCategory(&category, categoryPostCounts([]models.Category{category})[category.ID]))
+}
+
+// DeleteCategory 删除分类（软删除），有子分类时需要先删除或移走子分类，分类下的文章保持不变
+func DeleteCategory(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "分类不存在")
+		return
+	}
+	var children int64
+	database.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	if children > 0 {
+		responses.BadRequest(c, "请先删除或移走该分类下的子分类")
+		return
+	}
+	before := categoryAuditValues(&category)
+	if err := database.DB.Delete(&category).Error; err != nil {
+		responses.Internal(c, "分类删除失败")
+		return
+	}
+	recordAudit(c, categoryAuditEntry(models.AuditCategoryDelete, &category, before))
+	InvalidateSiteStats()
+	responses.OK(c, "分类删除成功", nil)
+}
+
+// SortCategories 按 ids 的顺序重新设置排序，未列出的分类保持不变
+func SortCategories(c *gin.Context) {
+	var requestData SortCategoriesRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	before := categoryAuditSnapshots(requestData.IDs)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range requestData.IDs {
+			if err := tx.Model(&models.Category{}).Where("id = ?", id).Update("sort_order", (i+1)*10).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		responses.Internal(c, "排序失败")
+		return
+	}
+	auditBulk(c, models.AuditCategorySort, models.AuditTargetCategory, before, categoryAuditSnapshots(requestData.IDs))
+	responses.OK(c, "排序已保存", CategoryTree())
+}
+
+// categoryAuditEntry 单个分类的审计日志，before 为 nil 表示新建
+func categoryAuditEntry(action string, category *models.Category, before auditValues) auditEntry {
+	return auditEntry{
+		Action:      action,
+		TargetType:  models.AuditTargetCategory,
+		TargetID:    category.ID,
+		TargetLabel: category.Name,
+		Before:      before,
+		After:       categoryAuditValues(category),
+	}
+}
+
+// applyCategoryRequest 检查别名和上级分类，把请求中的字段写入 category
+func applyCategoryRequest(c *gin.Context, category *models.Category, requestData *CategoryRequest) bool {
+	name := strings.TrimSpace(requestData.Name)
+	if name == "" {
+		responses.BadRequest(c, "请填写分类名称")
+		return false
+	}
+	alias := strings.ToLower(strings.TrimSpace(requestData.Alias))
+	if !categoryAliasPattern.MatchString(alias) {
+		responses.BadRequest(c, "别名只能包含小写字母、数字和连字符")
+		return false
+	}
+	var exists int64
+	database.DB.Model(&models.Category{}).Where("alias = ? AND id <> ?", alias, category.ID).Count(&exists)
+	if exists > 0 {
+		responses.Error(c, http.StatusConflict, responses.CodeConflict, "别名已被其他分类使用")
+		return false
+	}
+
+	// 只支持两级：上级分类必须是顶级分类，有子分类的分类不能再放到其他分类下
+	if requestData.ParentID != 0 {
+		var parent models.Category
+		if requestData.ParentID == category.ID || database.DB.First(&parent, requestData.ParentID).Error != nil {
+			responses.BadRequest(c, "上级分类不存在")
+			return false
+		}
+		if parent.ParentID != 0 {
+			responses.BadRequest(c, "上级分类必须是顶级分类")
+			return false
+		}
+		if category.ID != 0 {
+			var children int64
+			database.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+			if children > 0 {
+				responses.BadRequest(c, "该分类下有子分类，不能设为其他分类的子分类")
+				return false
+			}
+		}
+	}
+
+	status := requestData.StatusCode
+	if status == 0 {
+		status = models.StatusNormal
+	}
+	category.ParentID = requestData.ParentID
+	category.Name = name
+	category.Alias = alias
+	category.Description = strings.TrimSpace(requestData.Description)
+	category.Icon = strings.TrimSpace(requestData.Icon)
+	category.Color = requestData.Color
+	category.SortOrder = requestData.SortOrder
+	category.MinPostLevel = requestData.MinPostLevel
+	category.IsRecommended = requestData.IsRecommended
+	category.RecommendRank = requestData.RecommendRank
+	category.StatusCode = status
+	return true
+}
@@ -2,11 +2,12 @@ package handlers
 
 // 需要添加正确的导入
 import (
-	"fmt"
 	"gin-doniai/database"
 	"gin-doniai/models"
-	"net/http"
-	"regexp"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+	"gin-doniai/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -18,111 +19,123 @@ func CreateComment(c *gin.Context) {
 	userObj, exists := c.Get("user")
 	var user *models.User
 	if !exists || userObj == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户未登录",
-		})
+		responses.Unauthorized(c, "用户未登录")
 		return
 	}
 
     // 添加额外的类型检查
     user, ok := userObj.(*models.User)
     if !ok || user == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
+        responses.Unauthorized(c, "用户未登录")
         return
     }
 
 	// 解析请求数据
-	var requestData struct {
-		Content  string `json:"content" binding:"required"`
-		PostID   uint   `json:"post_id" binding:"required"`
-		ParentID uint   `json:"parent_id" binding:"omitempty"`
-	}
+	var requestData CreateCommentRequest
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "请求参数错误: " + err.Error(),
-		})
+		responses.BadRequest(c, "请求参数错误: " + err.Error())
+		return
+	}
+
+	// 是否需要审核按屏蔽敏感词前的原文判断
+	reviewText := requestData.Content
+	if !filterWords(c, &requestData.Content) {
+		return
+	}
+
+	// 只能评论自己能看到的文章
+	var post models.Post
+	if err := database.DB.First(&post, requestData.PostID).Error; err != nil || !policies.CanViewPost(user, &post) {
+		responses.NotFound(c, "文章不存在")
 		return
 	}
 
 	// 解析和转换评论内容
 	processedContent := processCommentContent(requestData.Content)
 
-	// 创建评论对象
+	// 创建评论对象，低等级用户或可疑内容需要审核后才会显示
 	comment := models.Comment{
 		Content:  processedContent,
+		Markdown: requestData.Content,
 		PostID:   requestData.PostID,
 		UserID:   user.ID,
 		ParentID: requestData.ParentID,
 	}
+	comment.StatusCode, comment.ModerationReason = policies.InitialStatus(user, reviewText)
 
 	// 保存到数据库
 	if err := database.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "评论创建失败: " + err.Error(),
-		})
+		responses.Internal(c, "评论创建失败: " + err.Error())
+		return
+	}
+
+	comment.User = *user
+	if comment.StatusCode == models.StatusPending {
+		responses.OK(c, "评论已提交，审核通过后显示", serializers.NewComment(&comment))
 		return
 	}
 
-	// 更新帖子的回复数
+	// 更新帖子的回复数，只统计审核通过的评论
 	database.DB.Model(&models.Post{}).Where("id = ?", requestData.PostID).UpdateColumn("replies", gorm.Expr("replies + ?", 1))
+	reindexPosts(comment.PostID)
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "评论发表成功",
-		"data":    comment,
-	})
+	responses.OK(c, "评论发表成功", serializers.NewComment(&comment))
 }
 
 // getComments 获取评论列表
 func GetComments(c *gin.Context) {
 	postID := c.Query("post_id")
 	if postID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "缺少post_id参数",
-		})
+		responses.BadRequest(c, "缺少post_id参数")
+		return
+	}
+
+	// 文章不可见时其评论同样不可见
+	var post models.Post
+	if err := database.DB.First(&post, postID).Error; err != nil || !policies.CanViewPost(UserFromContext(c), &post) {
+		responses.NotFound(c, "文章不存在")
 		return
 	}
 
+	page, perPage := responses.PageParams(c, 20)
+	query := database.DB.Model(&models.Comment{}).Scopes(policies.VisibleComments()).Where("post_id = ?", postID)
+	var total int64
+	query.Count(&total)
+
 	var comments []models.Comment
-	if err := database.DB.Where("post_id = ?", postID).Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "获取评论失败: " + err.Error(),
-		})
+	if err := query.Preload("User").Order("created_at ASC").Offset((page - 1) * perPage).Limit(perPage).Find(&comments).Error; err != nil {
+		responses.Internal(c, "获取评论失败: " + err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    comments,
-	})
+	responses.List(c, serializers.NewComments(comments), responses.NewMeta(page, perPage, total))
 }
 
 // getComment 获取单个评论
 func GetComment(c *gin.Context) {
 	id := c.Param("id")
 
-	var comment models.Comment
-	if err := database.DB.First(&comment, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
+	comment, ok := findVisibleComment(UserFromContext(c), id, database.DB.Preload("User"))
+	if !ok {
+		responses.NotFound(c, "评论未找到")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    comment,
-	})
+	responses.OK(c, "", serializers.NewComment(comment))
+}
+
+// findVisibleComment 查找访问者能看到的评论，所属文章看不到（私有、等级不足、待审核或已删除）时同样视为不存在
+func findVisibleComment(viewer *models.User, id string, query *gorm.DB) (*models.Comment, bool) {
+	var comment models.Comment
+	if err := query.First(&comment, id).Error; err != nil {
+		return nil, false
+	}
+	var post models.Post
+	if err := database.DB.First(&post, comment.PostID).Error; err != nil {
+		return nil, false
+	}
+	return &comment, policies.CanViewComment(viewer, &comment, &post)
 }
 
 // updateComment 更新评论
@@ -131,10 +144,7 @@ func UpdateComment(c *gin.Context) {
 	userObj, exists := c.Get("user")
 	var user *models.User
 	if !exists || userObj == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户未登录",
-		})
+		responses.Unauthorized(c, "用户未登录")
 		return
 	}
 	user = userObj.(*models.User)
@@ -143,48 +153,64 @@ func UpdateComment(c *gin.Context) {
 
 	var comment models.Comment
 	if err := database.DB.First(&comment, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
+		responses.NotFound(c, "评论未找到")
 		return
 	}
 
-	// 检查是否有权限更新评论（必须是评论作者）
-	if comment.UserID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "无权限更新此评论",
-		})
+	// 检查是否有权限更新评论（必须是评论作者或版主）
+	if !policies.CanModifyComment(user, &comment) {
+		responses.Forbidden(c, "无权限更新此评论")
 		return
 	}
 
-	var requestData struct {
-		Content string `json:"content" binding:"required"`
-	}
+	var requestData UpdateCommentRequest
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "请求参数错误: " + err.Error(),
-		})
+		responses.BadRequest(c, "请求参数错误: " + err.Error())
 		return
 	}
 
-	comment.Content = requestData.Content
+	// 是否需要审核按屏蔽敏感词前的原文判断
+	reviewText := requestData.Content
+	if !filterWords(c, &requestData.Content) {
+		return
+	}
+
+	before := commentAuditValues(&comment)
+	comment.Markdown = requestData.Content
+	comment.Content = processCommentContent(requestData.Content)
+	// 作者修改后重新判断是否需要审核，已公开的评论转为待审核时从回复数中扣除
+	wasVisible := comment.StatusCode == models.StatusNormal
+	if comment.UserID == user.ID {
+		if reason := policies.ReviewReason(user, reviewText); reason != "" {
+			comment.StatusCode = models.StatusPending
+			comment.ModerationReason = reason
+		} else if comment.StatusCode == models.StatusDisabled {
+			comment.StatusCode = models.StatusPending
+			comment.ModerationReason = policies.ResubmitReason
+		}
+	}
 	if err := database.DB.Save(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "更新评论失败: " + err.Error(),
-		})
+		responses.Internal(c, "更新评论失败: " + err.Error())
 		return
 	}
+	if wasVisible && comment.StatusCode != models.StatusNormal {
+		database.DB.Model(&models.Post{}).Where("id = ? AND replies > 0", comment.PostID).UpdateColumn("replies", gorm.Expr("replies - ?", 1))
+	}
+	reindexPosts(comment.PostID)
+	// 版主修改他人评论时记录审计日志
+	if comment.UserID != user.ID {
+		recordAudit(c, auditEntry{
+			Action:      models.AuditCommentUpdate,
+			TargetType:  models.AuditTargetComment,
+			TargetID:    comment.ID,
+			TargetLabel: excerpt(comment.Markdown, 100),
+			Before:      before,
+			After:       commentAuditValues(&comment),
+		})
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "评论更新成功",
-		"data":    comment,
-	})
+	responses.OK(c, "评论更新成功", serializers.NewComment(&comment))
 }
 
 // deleteComment 删除评论
@@ -193,10 +219,7 @@ func DeleteComment(c *gin.Context) {
 	userObj, exists := c.Get("user")
 	var user *models.User
 	if !exists || userObj == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户未登录",
-		})
+		responses.Unauthorized(c, "用户未登录")
 		return
 	}
 	user = userObj.(*models.User)
@@ -205,34 +228,39 @@ func DeleteComment(c *gin.Context) {
 
 	var comment models.Comment
 	if err := database.DB.First(&comment, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
+		responses.NotFound(c, "评论未找到")
 		return
 	}
 
-	// 检查是否有权限删除评论（必须是评论作者或管理员）
-	if comment.UserID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "无权限删除此评论",
-		})
+	// 检查是否有权限删除评论（必须是评论作者或版主）
+	if !policies.CanModifyComment(user, &comment) {
+		responses.Forbidden(c, "无权限删除此评论")
 		return
 	}
 
-	if err := database.DB.Delete(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "删除评论失败: " + err.Error(),
-		})
+	// 软删除，评论进入回收站，同时减少文章的回复数
+	before := commentAuditValues(&comment)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := trashContent(tx, &comment, []uint{comment.ID}, user).Error; err != nil {
+			return err
+		}
+		return adjustReplies(tx, []models.Comment{comment}, -1)
+	})
+	if err != nil {
+		responses.Internal(c, "删除评论失败: " + err.Error())
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "评论删除成功",
+	reindexPosts(comment.PostID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditCommentDelete,
+		TargetType:  models.AuditTargetComment,
+		TargetID:    comment.ID,
+		TargetLabel: before["content"].(string),
+		Before:      before,
+		After:       commentAuditValues(&comment),
 	})
+
+	responses.OK(c, "评论删除成功", nil)
 }
 
 // LikeComment 评论点赞
@@ -240,10 +268,7 @@ func LikeComment(c *gin.Context) {
 	// 从上下文获取用户信息
 	userObj, exists := c.Get("user")
 	if !exists || userObj == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户未登录",
-		})
+		responses.Unauthorized(c, "用户未登录")
 		return
 	}
 	user := userObj.(*models.User)
@@ -252,63 +277,42 @@ func LikeComment(c *gin.Context) {
 	commentId := c.Param("id")
 
 	// 解析请求数据
-	var requestData struct {
-		Action string `json:"action"` // like 或 unlike
-	}
+	var requestData LikeCommentRequest
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "请求参数错误: " + err.Error(),
-		})
+		responses.BadRequest(c, "请求参数错误: " + err.Error())
 		return
 	}
 
-	// 查询评论
-	var comment models.Comment
-	if err := database.DB.First(&comment, commentId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
+	// 查询评论，只能操作自己能看到的评论
+	comment, ok := findVisibleComment(user, commentId, database.DB)
+	if !ok {
+		responses.NotFound(c, "评论未找到")
 		return
 	}
 
 	// 检查用户是否在给自己点赞
 	if comment.UserID == user.ID && requestData.Action == "like" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "不能给自己的评论点赞",
-		})
+		responses.BadRequest(c, "不能给自己的评论点赞")
 		return
 	}
 
 	// 更新点赞数
 	if requestData.Action == "like" {
-		database.DB.Model(&comment).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1))
+		database.DB.Model(comment).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1))
 	} else if requestData.Action == "unlike" {
-		database.DB.Model(&comment).UpdateColumn("like_count", gorm.Expr("like_count - ?", 1))
+		database.DB.Model(comment).UpdateColumn("like_count", gorm.Expr("like_count - ?", 1))
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "操作成功",
-		"data": gin.H{
-			"like_count": comment.LikeCount,
-		},
+	responses.OK(c, "操作成功", gin.H{
+		"like_count": comment.LikeCount,
 	})
 }
 
+// processCommentContent 将评论Markdown渲染为过滤后的HTML，@用户名 和 #ID 转换为链接
 func processCommentContent(content string) string {
-	// 使用正则表达式匹配 @用户名 和 #ID 模式
-	// 匹配 @用户名 (假设用户名不包含空格)
-	reUser := regexp.MustCompile(`@(\S+)`)
-	content = reUser.ReplaceAllString(content, `<a href="/user/$1" target="_blank">@$1</a>`)
-
-	// 匹配 #ID (数字)
-	reComment := regexp.MustCompile(`#(\d+)`)
-	content = reComment.ReplaceAllString(content, `<a href="#comment-$1">#$1</a>`)
-
-	// 将整个内容包装在 <p> 标签中
-	return fmt.Sprintf("<p>%s</p>", content)
+	return utils.RenderMarkdown(content, utils.MarkdownOptions{
+		HardWraps: true,
+		Mentions:  true,
+	})
 }
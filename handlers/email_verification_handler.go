@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/mailer"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTTL      = 24 * time.Hour
+	emailVerificationInterval = time.Minute // 同一用户两次发送验证邮件的最小间隔
+)
+
+// errVerificationTooFrequent 发送验证邮件过于频繁
+var errVerificationTooFrequent = errors.New("验证邮件发送过于频繁，请稍后再试")
+
+// SendEmailVerification 生成验证令牌并发送验证邮件，同一用途未使用的旧令牌全部作废。
+// purpose 为 models.EmailVerifyChange 时 email 是待确认的新地址。
+func SendEmailVerification(user *models.User, email, purpose string) error {
+	var last models.EmailVerification
+	err := database.DB.Where("user_id = ?", user.ID).Order("created_at DESC").First(&last).Error
+	if err == nil && time.Since(last.CreatedAt) < emailVerificationInterval {
+		return errVerificationTooFrequent
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(b)
+
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerification{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerification{
+			UserID:    user.ID,
+			Email:     email,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(emailVerificationTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	template := "verify_email"
+	if purpose == models.EmailVerifyChange {
+		template = "change_email"
+	}
+	return mailer.SendTemplate(email, template, gin.H{
+		"SiteName":  "Doniai",
+		"Name":      user.Name,
+		"Email":     email,
+		"Link":      utils.AbsoluteURL("/verify-email?token=" + url.QueryEscape(token)),
+		"ExpiresIn": "24小时",
+	})
+}
+
+// VerifyEmail 打开验证邮件中的链接，确认邮箱
+func VerifyEmail(c *gin.Context) {
+	render := func(status int, message string, ok bool) {
+		responses.HTML(c, status, "verify-email.tmpl", gin.H{
+			"message": message,
+			"success": ok,
+		})
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		render(http.StatusBadRequest, "无效的验证链接", false)
+		return
+	}
+
+	var verification models.EmailVerification
+	err := database.DB.Where("token_hash = ? AND used_at IS NULL", utils.HashToken(token)).First(&verification).Error
+	if err != nil || time.Now().After(verification.ExpiresAt) {
+		render(http.StatusBadRequest, "验证链接无效或已过期，请重新发送验证邮件", false)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, verification.UserID).Error; err != nil {
+		render(http.StatusBadRequest, "用户不存在", false)
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"email_verified":    true,
+		"email_verified_at": now,
+	}
+	if verification.Purpose == models.EmailVerifyChange {
+		// 确认时再次检查新邮箱是否已被其他账户占用
+		var count int64
+		database.DB.Model(&models.User{}).Where("email = ? AND id <> ?", verification.Email, user.ID).Count(&count)
+		if count > 0 {
+			render(http.StatusConflict, "该邮箱已被其他账户使用", false)
+			return
+		}
+		updates["email"] = verification.Email
+	} else if !strings.EqualFold(user.Email, verification.Email) {
+		// 注册验证发出后邮箱已被修改，旧链接不再有效
+		render(http.StatusBadRequest, "验证链接已失效，请重新发送验证邮件", false)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证同一链接只能使用一次
+		result := tx.Model(&verification).Where("used_at IS NULL").Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		render(http.StatusBadRequest, "验证链接无效或已过期，请重新发送验证邮件", false)
+		return
+	}
+
+	if verification.Purpose == models.EmailVerifyChange {
+		render(http.StatusOK, fmt.Sprintf("邮箱已修改为 %s", verification.Email), true)
+		return
+	}
+	render(http.StatusOK, "邮箱验证成功", true)
+}
+
+// ResendEmailVerification 重新发送注册验证邮件
+func ResendEmailVerification(c *gin.Context) {
+	user := UserFromContext(c)
+	if user.EmailVerified {
+		responses.BadRequest(c, "邮箱已验证")
+		return
+	}
+
+	if err := SendEmailVerification(user, user.Email, models.EmailVerifyRegister); err != nil {
+		sendVerificationError(c, err)
+		return
+	}
+	responses.OK(c, "验证邮件已发送，请查收", nil)
+}
+
+// ChangeEmail 修改邮箱，需要验证当前密码，新邮箱确认后才会生效
+func ChangeEmail(c *gin.Context) {
+	user := UserFromContext(c)
+
+	var requestData ChangeEmailRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请输入有效的邮箱地址和当前密码")
+		return
+	}
+	if !user.PasswordSet {
+		responses.BadRequest(c, "请先在安全设置中设置密码")
+		return
+	}
+	if !utils.CheckPassword(requestData.Password, user.Password) {
+		responses.Forbidden(c, "当前密码错误")
+		return
+	}
+
+	email := strings.TrimSpace(requestData.Email)
+	if strings.EqualFold(email, user.Email) {
+		responses.BadRequest(c, "新邮箱与当前邮箱相同")
+		return
+	}
+	var count int64
+	database.DB.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	if count > 0 {
+		responses.Error(c, http.StatusConflict, responses.CodeConflict, "该邮箱已被其他账户使用")
+		return
+	}
+
+	if err := SendEmailVerification(user, email, models.EmailVerifyChange); err != nil {
+		sendVerificationError(c, err)
+		return
+	}
+	responses.OK(c, "确认邮件已发送到新邮箱，点击邮件中的链接后修改生效", nil)
+}
+
+func sendVerificationError(c *gin.Context, err error) {
+	if errors.Is(err, errVerificationTooFrequent) {
+		responses.TooManyRequests(c, emailVerificationInterval, err.Error())
+		return
+	}
+	fmt.Printf("发送验证邮件失败: %v\n", err)
+	responses.Internal(c, "验证邮件发送失败，请稍后重试")
+}
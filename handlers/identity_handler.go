@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 关联第三方账号后跳回设置页时的提示消息
+const (
+	flashSuccess = "flash_success"
+	flashError   = "flash_error"
+)
+
+// FlashKeys 设置页需要读取的提示消息类型
+var FlashKeys = []string{flashSuccess, flashError}
+
+// GetIdentities 当前用户关联的第三方账号
+func GetIdentities(c *gin.Context) {
+	identities, err := ListUserIdentities(UserFromContext(c).ID)
+	if err != nil {
+		responses.Internal(c, "获取关联账号失败")
+		return
+	}
+	responses.OK(c, "", serializers.NewIdentities(identities))
+}
+
+// UnlinkIdentity 解除关联第三方账号，至少要保留密码或另一个第三方账号作为登录方式
+func UnlinkIdentity(c *gin.Context) {
+	user := UserFromContext(c)
+	provider := c.Param("provider")
+
+	var identity models.UserIdentity
+	if err := database.DB.Where("user_id = ? AND provider = ?", user.ID, provider).First(&identity).Error; err != nil {
+		responses.NotFound(c, "未关联该平台的账号")
+		return
+	}
+
+	if !user.PasswordSet {
+		var others int64
+		database.DB.Model(&models.UserIdentity{}).Where("user_id = ? AND id <> ?", user.ID, identity.ID).Count(&others)
+		if others == 0 {
+			responses.BadRequest(c, "请先设置密码或关联其他第三方账号，账户至少需要保留一种登录方式")
+			return
+		}
+	}
+
+	if err := database.DB.Delete(&identity).Error; err != nil {
+		responses.Internal(c, "解除关联失败")
+		return
+	}
+	syncIdentityField(user.ID, provider, "")
+
+	entry := accountAuditEntry(models.AuditIdentityUnlink, user, nil)
+	entry.Before = identityAuditValues(&identity)
+	recordAudit(c, entry)
+	responses.OK(c, fmt.Sprintf("已解除关联 %s 账号", models.ProviderLabel(provider)), nil)
+}
+
+// ListUserIdentities 用户关联的第三方账号
+func ListUserIdentities(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := database.DB.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// LinkIdentity 为用户关联第三方账号，同一个第三方账号只能关联一个用户，每个平台只能关联一个账号。
+// 关联成功后以该用户的身份写入审计日志
+func LinkIdentity(c *gin.Context, user *models.User, profile OAuthProfile) error {
+	label := models.ProviderLabel(profile.Provider)
+
+	var existing models.UserIdentity
+	err := database.DB.Where("provider = ? AND provider_user_id = ?", profile.Provider, profile.ProviderUserID).First(&existing).Error
+	if err == nil {
+		if existing.UserID != user.ID {
+			return fmt.Errorf("该 %s 账号已关联其他账户", label)
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var count int64
+	database.DB.Model(&models.UserIdentity{}).Where("user_id = ? AND provider = ?", user.ID, profile.Provider).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("已关联其他 %s 账号，请先解除关联", label)
+	}
+
+	identity := newIdentity(user.ID, profile)
+	if err := database.DB.Create(identity).Error; err != nil {
+		return err
+	}
+	syncIdentityField(user.ID, profile.Provider, profile.Username)
+	recordAuditAs(c, user, accountAuditEntry(models.AuditIdentityLink, user, identityAuditValues(identity)))
+	return nil
+}
+
+// identityAuditValues 审计日志中记录的第三方账号信息
+func identityAuditValues(identity *models.UserIdentity) auditValues {
+	return auditValues{
+		"provider":         identity.Provider,
+		"provider_user_id": identity.ProviderUserID,
+		"username":         identity.Username,
+	}
+}
+
+func newIdentity(userID uint, profile OAuthProfile) *models.UserIdentity {
+	return &models.UserIdentity{
+		UserID:         userID,
+		Provider:       profile.Provider,
+		ProviderUserID: profile.ProviderUserID,
+		Username:       profile.Username,
+		Email:          profile.Email,
+	}
+}
+
+// syncIdentityField 资料中的 GitHub、Google 账户由关联的第三方账号决定
+func syncIdentityField(userID uint, provider, value string) {
+	column := ""
+	switch provider {
+	case models.ProviderGitHub:
+		column = "github"
+	case models.ProviderGoogle:
+		column = "google_account"
+	default:
+		return
+	}
+	database.DB.Model(&models.User{}).Where("id = ?", userID).Update(column, value)
+}
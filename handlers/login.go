@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// 两步验证待完成的登录信息保存在会话中的键
+const (
+	sessionPendingUserID   = "2fa_user_id"
+	sessionPendingRemember = "2fa_remember"
+	sessionPendingExpires  = "2fa_expires"
+	sessionPendingAttempts = "2fa_attempts"
+	sessionPendingRedirect = "2fa_redirect"
+)
+
+const (
+	pendingLoginTTL         = 5 * time.Minute // 第二步验证的有效时间
+	maxTwoFactorAttempts    = 5               // 第二步允许的错误次数
+	rememberMeSessionMaxAge = 30 * 24 * 60 * 60
+)
+
+// StartLogin 在密码或第三方账号验证通过后调用。
+// 未启用两步验证时直接建立登录会话；已启用时只记录待验证的用户，返回 true，
+// 调用方应引导用户到 /login/two-factor 输入验证码，redirectTo 为验证完成后返回的站内路径。
+func StartLogin(c *gin.Context, user *models.User, remember bool, redirectTo string) (bool, error) {
+	session := sessions.Default(c)
+	if !user.TwoFactorEnabled {
+		if err := finishLogin(c, user, remember); err != nil {
+			return false, err
+		}
+		// 登录完成后才清除账号的失败次数；启用两步验证的账号在第二步验证通过后清除
+		loginAccountLimiter.Reset(userAccountKey(user.ID))
+		return false, nil
+	}
+
+	session.Delete("user_id")
+	session.Set(sessionPendingUserID, user.ID)
+	session.Set(sessionPendingRemember, remember)
+	session.Set(sessionPendingExpires, time.Now().Add(pendingLoginTTL).Unix())
+	session.Set(sessionPendingAttempts, 0)
+	session.Set(sessionPendingRedirect, redirectTo)
+	return true, session.Save()
+}
+
+// finishLogin 建立登录会话并写入审计日志，remember 为 true 时保持30天，否则浏览器关闭后失效
+func finishLogin(c *gin.Context, user *models.User, remember bool) error {
+	session := sessions.Default(c)
+	clearPendingLogin(session)
+	session.Set("user_id", user.ID)
+
+	maxAge := 0
+	if remember {
+		maxAge = rememberMeSessionMaxAge
+	}
+	session.Options(sessions.Options{
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   maxAge,
+	})
+	if err := session.Save(); err != nil {
+		return err
+	}
+	recordAuditAs(c, user, accountAuditEntry(models.AuditLogin, user, nil))
+	return nil
+}
+
+// pendingLogin 返回等待第二步验证的用户ID，已过期或不存在时返回 0
+func pendingLogin(session sessions.Session) (userID uint, remember bool) {
+	userID, _ = session.Get(sessionPendingUserID).(uint)
+	expires, _ := session.Get(sessionPendingExpires).(int64)
+	if userID == 0 || time.Now().Unix() > expires {
+		return 0, false
+	}
+	remember, _ = session.Get(sessionPendingRemember).(bool)
+	return userID, remember
+}
+
+func clearPendingLogin(session sessions.Session) {
+	session.Delete(sessionPendingUserID)
+	session.Delete(sessionPendingRemember)
+	session.Delete(sessionPendingExpires)
+	session.Delete(sessionPendingAttempts)
+	session.Delete(sessionPendingRedirect)
+}
+
+// pendingRedirect 返回第二步验证完成后跳转的地址
+func pendingRedirect(session sessions.Session) string {
+	if redirectTo, _ := session.Get(sessionPendingRedirect).(string); redirectTo != "" {
+		return redirectTo
+	}
+	return "/"
+}
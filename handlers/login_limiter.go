@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidCredentials 账号不存在和密码错误统一返回该错误，避免暴露账号是否存在
+var ErrInvalidCredentials = errors.New("账号或密码错误")
+
+// LoginThrottledError 失败次数过多，需要等待 Wait 后再试
+type LoginThrottledError struct {
+	Wait time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("尝试次数过多，请%s后再试", formatWait(e.Wait))
+}
+
+const loginAttemptRetention = 90 * 24 * time.Hour // 登录失败记录保留时间
+
+var (
+	// 同一账号：3次内不限制，之后等待时间从2秒开始翻倍，10次后锁定30分钟
+	loginAccountLimiter = utils.NewAttemptLimiter(utils.AttemptPolicy{
+		FreeAttempts: 3,
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxAttempts:  10,
+		LockDuration: 30 * time.Minute,
+		Window:       time.Hour,
+	})
+	// 同一IP：允许在多个账号间输错，但总数受限
+	loginIPLimiter = utils.NewAttemptLimiter(utils.AttemptPolicy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxAttempts:  50,
+		LockDuration: time.Hour,
+		Window:       time.Hour,
+	})
+	// 找回密码按邮箱和IP限制发送频率，防止用来轰炸他人邮箱
+	forgotPasswordEmailLimiter = utils.NewAttemptLimiter(utils.AttemptPolicy{
+		FreeAttempts: 1,
+		BaseDelay:    time.Minute,
+		MaxDelay:     15 * time.Minute,
+		MaxAttempts:  5,
+		LockDuration: time.Hour,
+		Window:       time.Hour,
+	})
+	forgotPasswordIPLimiter = utils.NewAttemptLimiter(utils.AttemptPolicy{
+		FreeAttempts: 5,
+		BaseDelay:    time.Minute,
+		MaxDelay:     15 * time.Minute,
+		MaxAttempts:  20,
+		LockDuration: time.Hour,
+		Window:       time.Hour,
+	})
+)
+
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// AuthenticatePassword 校验账号密码，账号可以是邮箱或用户名。
+// 失败次数按账号和IP分别限制，超出后返回 *LoginThrottledError，其余失败统一返回 ErrInvalidCredentials。
+func AuthenticatePassword(c *gin.Context, identifier, password string) (*models.User, error) {
+	var user models.User
+	found := database.DB.Where("email = ? OR name = ?", identifier, identifier).First(&user).Error == nil
+
+	accountKey := loginAccountKey(identifier)
+	if found {
+		accountKey = userAccountKey(user.ID)
+	}
+	if wait, ok := checkLoginAllowed(c, accountKey); !ok {
+		return nil, &LoginThrottledError{Wait: wait}
+	}
+
+	if !found {
+		// 账号不存在时同样计算一次哈希，避免通过响应时间判断账号是否存在
+		dummyPasswordHashOnce.Do(func() {
+			dummyPasswordHash, _ = utils.HashPassword("dummy-password")
+		})
+		utils.CheckPassword(password, dummyPasswordHash)
+		recordLoginFailure(c, accountKey, identifier, 0, models.LoginFailureUnknownUser)
+		return nil, ErrInvalidCredentials
+	}
+	// 第三方登录创建的账号在设置密码之前不能用密码登录
+	if !user.PasswordSet || !utils.CheckPassword(password, user.Password) {
+		recordLoginFailure(c, accountKey, identifier, user.ID, models.LoginFailureWrongPassword)
+		return nil, ErrInvalidCredentials
+	}
+	// 密码正确时不清除失败次数，登录完成（包括两步验证）后才清除，见 StartLogin
+	return &user, nil
+}
+
+// ListLoginFailures 用户最近的登录失败记录
+func ListLoginFailures(userID uint, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&attempts).Error
+	return attempts, err
+}
+
+// CleanupLoginAttempts 清理过期的失败计数和登录失败记录
+func CleanupLoginAttempts() {
+	loginAccountLimiter.Cleanup()
+	loginIPLimiter.Cleanup()
+	forgotPasswordEmailLimiter.Cleanup()
+	forgotPasswordIPLimiter.Cleanup()
+	database.DB.Where("created_at < ?", time.Now().Add(-loginAttemptRetention)).Delete(&models.LoginAttempt{})
+}
+
+// checkLoginAllowed 账号和IP都未被限制时才允许尝试，返回需要等待的较长时间
+func checkLoginAllowed(c *gin.Context, accountKey string) (time.Duration, bool) {
+	accountWait, accountOK := loginAccountLimiter.Allow(accountKey)
+	ipWait, ipOK := loginIPLimiter.Allow(c.ClientIP())
+	return max(accountWait, ipWait), accountOK && ipOK
+}
+
+// recordLoginFailure 累计失败次数并保存失败记录
+func recordLoginFailure(c *gin.Context, accountKey, identifier string, userID uint, reason string) {
+	loginAccountLimiter.Fail(accountKey)
+	loginIPLimiter.Fail(c.ClientIP())
+
+	attempt := models.LoginAttempt{
+		UserID:     userID,
+		Identifier: truncate(identifier, 255),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 500),
+		Reason:     reason,
+	}
+	if err := database.DB.Create(&attempt).Error; err != nil {
+		fmt.Printf("保存登录失败记录失败: %v\n", err)
+	}
+}
+
+// checkForgotPassword 找回密码的频率限制，每次请求都计入次数
+func checkForgotPassword(c *gin.Context, email string) (time.Duration, bool) {
+	emailKey := strings.ToLower(strings.TrimSpace(email))
+	emailWait, emailOK := forgotPasswordEmailLimiter.Allow(emailKey)
+	ipWait, ipOK := forgotPasswordIPLimiter.Allow(c.ClientIP())
+	if !emailOK || !ipOK {
+		return max(emailWait, ipWait), false
+	}
+	forgotPasswordEmailLimiter.Fail(emailKey)
+	forgotPasswordIPLimiter.Fail(c.ClientIP())
+	return 0, true
+}
+
+// userAccountKey 已存在的账号按用户ID计数，用邮箱和用户名交替尝试也会累计
+func userAccountKey(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func loginAccountKey(identifier string) string {
+	return "identifier:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// formatWait 把等待时间格式化为便于阅读的文字
+func formatWait(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d秒", int((d+time.Second-1)/time.Second))
+	}
+	return fmt.Sprintf("%d分钟", int((d+time.Minute-1)/time.Minute))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 审核页面每类内容最多显示的数量
+const moderationPageLimit = 50
+
+// ModerationPage 审核队列页面，列出待审核的文章、评论、待处理的举报和生效中的处罚
+func ModerationPage(c *gin.Context) {
+	user := UserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login?redirect_to=/moderation")
+		return
+	}
+	if !user.Can(models.PermModerate) {
+		responses.HTML(c, http.StatusForbidden, "403.tmpl", gin.H{"Message": "没有审核权限", "user": user})
+		return
+	}
+
+	var posts []models.Post
+	var postTotal int64
+	pendingPosts := database.DB.Model(&models.Post{}).Where("status_code = ?", models.StatusPending)
+	pendingPosts.Count(&postTotal)
+	pendingPosts.Preload("User").Order("created_at ASC").Limit(moderationPageLimit).Find(&posts)
+
+	var comments []models.Comment
+	var commentTotal int64
+	pendingComments := database.DB.Model(&models.Comment{}).Where("status_code = ?", models.StatusPending)
+	pendingComments.Count(&commentTotal)
+	pendingComments.Preload("User").Order("created_at ASC").Limit(moderationPageLimit).Find(&comments)
+
+	reports, reportTotal := openReportGroups(moderationPageLimit * 4)
+
+	responses.HTML(c, http.StatusOK, "moderation.tmpl", gin.H{
+		"user":         user,
+		"posts":        posts,
+		"postTotal":    postTotal,
+		"comments":     comments,
+		"commentTotal": commentTotal,
+		"postTitles":   postTitles(comments),
+		"reports":      reports,
+		"reportTotal":  reportTotal,
+		"sanctions":    activeSanctionsForPage(moderationPageLimit),
+	})
+}
+
+// ListModerationPosts 审核队列中的文章，status 为 pending（默认）或 rejected
+func ListModerationPosts(c *gin.Context) {
+	page, perPage := responses.PageParams(c, 20)
+	query := database.DB.Model(&models.Post{}).Where("status_code = ?", moderationStatus(c))
+	var total int64
+	query.Count(&total)
+
+	var posts []models.Post
+	if err := query.Preload("User").Order("created_at ASC").Offset((page - 1) * perPage).Limit(perPage).Find(&posts).Error; err != nil {
+		responses.Internal(c, "获取审核队列失败")
+		return
+	}
+	responses.List(c, serializers.NewPosts(posts), responses.NewMeta(page, perPage, total))
+}
+
+// ListModerationComments 审核队列中的评论，status 为 pending（默认）或 rejected
+func ListModerationComments(c *gin.Context) {
+	page, perPage := responses.PageParams(c, 20)
+	query := database.DB.Model(&models.Comment{}).Where("status_code = ?", moderationStatus(c))
+	var total int64
+	query.Count(&total)
+
+	var comments []models.Comment
+	if err := query.Preload("User").Order("created_at ASC").Offset((page - 1) * perPage).Limit(perPage).Find(&comments).Error; err != nil {
+		responses.Internal(c, "获取审核队列失败")
+		return
+	}
+	responses.List(c, serializers.NewComments(comments), responses.NewMeta(page, perPage, total))
+}
+
+// ReviewPost 通过或拒绝文章，已公开的文章也可以被拒绝（下架），同时处理该文章的待处理举报
+func ReviewPost(c *gin.Context) {
+	var requestData ModerationRequest
+	status, reason, ok := bindModeration(c, &requestData)
+	if !ok {
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.First(&post, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "文章不存在")
+		return
+	}
+
+	reviewer := UserFromContext(c)
+	before := postAuditValues(&post)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updatePostStatus(tx, &post, status, reason, reviewer); err != nil {
+			return err
+		}
+		return closeReports(tx, models.ReportTargetPost, post.ID, reportOutcome(status), reviewer)
+	})
+	if err != nil {
+		responses.Internal(c, "审核失败")
+		return
+	}
+	reindexPosts(post.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditPostStatus,
+		TargetType:  models.AuditTargetPost,
+		TargetID:    post.ID,
+		TargetLabel: post.Title,
+		Before:      before,
+		After:       postAuditValues(&post),
+	})
+	responses.OK(c, moderationMessage(status), serializers.NewPost(&post))
+}
+
+// ReviewComment 通过或拒绝评论，同时更新文章的回复数并处理该评论的待处理举报
+func ReviewComment(c *gin.Context) {
+	var requestData ModerationRequest
+	status, reason, ok := bindModeration(c, &requestData)
+	if !ok {
+		return
+	}
+
+	var comment models.Comment
+	if err := database.DB.First(&comment, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "评论不存在")
+		return
+	}
+
+	reviewer := UserFromContext(c)
+	before := commentAuditValues(&comment)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateCommentStatus(tx, &comment, status, reason, reviewer); err != nil {
+			return err
+		}
+		return closeReports(tx, models.ReportTargetComment, comment.ID, reportOutcome(status), reviewer)
+	})
+	if err != nil {
+		responses.Internal(c, "审核失败")
+		return
+	}
+	reindexPosts(comment.PostID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditCommentStatus,
+		TargetType:  models.AuditTargetComment,
+		TargetID:    comment.ID,
+		TargetLabel: before["content"].(string),
+		Before:      before,
+		After:       commentAuditValues(&comment),
+	})
+	responses.OK(c, moderationMessage(status), serializers.NewComment(&comment))
+}
+
+// bindModeration 解析审核请求，返回新的状态和原因
+func bindModeration(c *gin.Context, requestData *ModerationRequest) (status int, reason string, ok bool) {
+	if err := c.ShouldBindJSON(requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return 0, "", false
+	}
+	reason = strings.TrimSpace(requestData.Reason)
+	if requestData.Action == "reject" {
+		if reason == "" {
+			responses.BadRequest(c, "请填写拒绝原因")
+			return 0, "", false
+		}
+		return models.StatusDisabled, reason, true
+	}
+	return models.StatusNormal, reason, true
+}
+
+// updatePostStatus 修改文章的审核状态，reviewer 为 nil 表示系统自动处理
+func updatePostStatus(tx *gorm.DB, post *models.Post, status int, reason string, reviewer *models.User) error {
+	return tx.Model(post).Updates(moderationUpdates(status, reason, reviewer)).Error
+}
+
+// updateCommentStatus 修改评论的审核状态，回复数只统计公开的评论
+func updateCommentStatus(tx *gorm.DB, comment *models.Comment, status int, reason string, reviewer *models.User) error {
+	wasVisible := comment.StatusCode == models.StatusNormal
+	if err := tx.Model(comment).Updates(moderationUpdates(status, reason, reviewer)).Error; err != nil {
+		return err
+	}
+	isVisible := status == models.StatusNormal
+	switch {
+	case isVisible && !wasVisible:
+		return tx.Model(&models.Post{}).Where("id = ?", comment.PostID).UpdateColumn("replies", gorm.Expr("replies + ?", 1)).Error
+	case !isVisible && wasVisible:
+		return tx.Model(&models.Post{}).Where("id = ? AND replies > 0", comment.PostID).UpdateColumn("replies", gorm.Expr("replies - ?", 1)).Error
+	}
+	return nil
+}
+
+func moderationUpdates(status int, reason string, reviewer *models.User) map[string]interface{} {
+	updates := map[string]interface{}{
+		"status_code":       status,
+		"moderation_reason": reason,
+	}
+	if reviewer != nil {
+		updates["reviewed_by"] = reviewer.ID
+		updates["reviewed_at"] = time.Now()
+	}
+	return updates
+}
+
+func moderationStatus(c *gin.Context) int {
+	if c.Query("status") == "rejected" {
+		return models.StatusDisabled
+	}
+	return models.StatusPending
+}
+
+func moderationMessage(status int) string {
+	if status == models.StatusNormal {
+		return "已通过审核"
+	}
+	return "已拒绝"
+}
+
+// postTitles 评论所属文章的标题，包括已删除的文章
+func postTitles(comments []models.Comment) map[uint]string {
+	ids := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.PostID)
+	}
+	titles := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return titles
+	}
+	var posts []models.Post
+	database.DB.Unscoped().Select("id", "title").Where("id IN ?", ids).Find(&posts)
+	for _, post := range posts {
+		titles[post.ID] = post.Title
+	}
+	return titles
+}
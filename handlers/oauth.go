@@ -2,282 +2,272 @@
 package handlers
 
 import (
-	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
+	"time"
 
 	"gin-doniai/database"
 	"gin-doniai/models"
+	"gin-doniai/responses"
 	"gin-doniai/utils"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/github"
-	"golang.org/x/oauth2/google"
-	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
-// OAuth配置
-var (
-	githubOAuthConfig *oauth2.Config
-	googleOAuthConfig *oauth2.Config
-	oauthStateString  = "oauthstate"
-)
-
-// GitHub用户信息结构体
-type GitHubUser struct {
-	ID        int    `json:"id"`
-	Login     string `json:"login"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	AvatarURL string `json:"avatar_url"`
+// OAuthProfile 第三方平台返回的用户信息
+type OAuthProfile struct {
+	Provider       string
+	ProviderUserID string
+	Username       string // 平台上的用户名，GitHub 为 login，Google 为邮箱
+	Email          string
+	EmailVerified  bool // 第三方平台是否已验证该邮箱
+	Name           string
+	AvatarURL      string
 }
 
-// Google用户信息结构体
-type GoogleUser struct {
-	ID            string `json:"id"`
-	Email         string `json:"email"`
-	VerifiedEmail bool   `json:"verified_email"`
-	Name          string `json:"name"`
-	Picture       string `json:"picture"`
-}
-
-func init() {
-    // 加载.env文件
-    if err := godotenv.Load(); err != nil {
-        fmt.Println("警告: 未能加载 .env 文件")
-    }
-	// GitHub OAuth配置
-	githubOAuthConfig = &oauth2.Config{
-		ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
-		ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"), // 如: http://localhost:8080/auth/github/callback
-		Scopes:       []string{"user:email"},
-		Endpoint:     github.Endpoint,
-	}
-
-	// Google OAuth配置
-	googleOAuthConfig = &oauth2.Config{
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"), // 如: http://localhost:8080/auth/google/callback
-		Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-		Endpoint:     google.Endpoint,
-	}
-}
+// errOAuthEmailTaken 第三方账号的邮箱已被本站其他账户使用，需要先登录后手动关联
+var errOAuthEmailTaken = errors.New("该邮箱已注册本站账户，请先使用密码登录，然后在账户设置中关联第三方账号")
 
 // 生成随机state字符串
 func generateState() string {
 	b := make([]byte, 32)
 	rand.Read(b)
-	return base64.URLEncoding.EncodeToString(b)
+	return base64.RawURLEncoding.EncodeToString(b)
 }
 
-// GitHub授权登录处理
-func GitHubLogin(c *gin.Context) {
-	state := generateState()
-	session := sessions.Default(c)
-	session.Set(oauthStateString, state)
-	err := session.Save()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+// OAuthLogin 跳转到第三方授权页面（/auth/:provider）。
+// 已登录用户带上 ?link=1 时为关联第三方账号；redirect_to 为完成后返回的站内路径。
+// 每次登录生成独立的 state 和 PKCE verifier，保存在会话中，10分钟内有效且只能使用一次。
+func OAuthLogin(c *gin.Context) {
+	provider, ok := oauthProviders[c.Param("provider")]
+	if !ok {
+		responses.HTML(c, http.StatusNotFound, "404.tmpl", gin.H{"Message": "不支持的登录方式"})
 		return
 	}
 
-	url := githubOAuthConfig.AuthCodeURL(state)
-	c.Redirect(http.StatusTemporaryRedirect, url)
-}
-
-// GitHub回调处理
-func GitHubCallback(c *gin.Context) {
-	session := sessions.Default(c)
-
-	// 验证state参数
-	state := c.Query("state")
-	savedState := session.Get(oauthStateString)
-	if state == "" || savedState == nil || state != savedState.(string) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state parameter"})
-		return
+	link := c.Query("link") == "1" && UserFromContext(c) != nil
+	redirectTo := utils.SafeRedirectPath(c.Query("redirect_to"))
+	if redirectTo == "" {
+		redirectTo = "/"
+		if link {
+			redirectTo = "/settings"
+		}
 	}
 
-	// 获取授权码
-	code := c.Query("code")
-	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code parameter"})
+	config, err := provider.Config(c.Request.Context())
+	if err != nil {
+		fmt.Printf("%s 登录配置读取失败: %v\n", provider.Name(), err)
+		oauthFailed(c, http.StatusBadGateway, "暂时无法连接登录服务，请稍后重试")
 		return
 	}
 
-	// 交换access token
-	token, err := githubOAuthConfig.Exchange(context.Background(), code)
+	state := generateState()
+	nonce := generateState()
+	verifier := oauth2.GenerateVerifier()
+	err = saveOAuthPending(sessions.Default(c), state, oauthPending{
+		Provider:   provider.Name(),
+		Verifier:   verifier,
+		Nonce:      nonce,
+		RedirectTo: redirectTo,
+		Link:       link,
+		ExpiresAt:  time.Now().Add(oauthStateTTL).Unix(),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
+		oauthFailed(c, http.StatusInternalServerError, "保存会话失败，请稍后重试")
 		return
 	}
 
-	// 获取用户信息
-	client := githubOAuthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://api.github.com/user")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
+	c.Redirect(http.StatusFound, config.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce)))
+}
+
+// OAuthCallback 第三方授权回调（/auth/:provider/callback），校验 state 后用授权码和 PKCE verifier 换取令牌
+func OAuthCallback(c *gin.Context) {
+	provider, ok := oauthProviders[c.Param("provider")]
+	if !ok {
+		responses.HTML(c, http.StatusNotFound, "404.tmpl", gin.H{"Message": "不支持的登录方式"})
 		return
 	}
-	defer resp.Body.Close()
 
-	var githubUser GitHubUser
-	if err := json.NewDecoder(resp.Body).Decode(&githubUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode user info"})
+	// state 只能使用一次，无论成功与否都从会话中移除
+	session := sessions.Default(c)
+	pending, ok := takeOAuthPending(session, c.Query("state"))
+	session.Save()
+	if !ok || pending.Provider != provider.Name() {
+		oauthFailed(c, http.StatusBadRequest, "登录请求无效或已过期，请重新登录")
+		return
+	}
+	if c.Query("error") != "" {
+		oauthFailed(c, http.StatusBadRequest, "已取消授权")
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		oauthFailed(c, http.StatusBadRequest, "缺少授权码，请重新登录")
 		return
 	}
 
-	// 处理用户登录/注册
-	user, err := handleOAuthUserLogin(c, githubUser.Login, githubUser.Email, githubUser.Name, githubUser.AvatarURL)
+	ctx := c.Request.Context()
+	config, err := provider.Config(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user login"})
+		fmt.Printf("%s 登录配置读取失败: %v\n", provider.Name(), err)
+		oauthFailed(c, http.StatusBadGateway, "暂时无法连接登录服务，请稍后重试")
 		return
 	}
-
-	// 设置session
-	session.Set("user_id", user.ID)
-	err = session.Save()
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(pending.Verifier))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+		fmt.Printf("%s 授权码换取令牌失败: %v\n", provider.Name(), err)
+		oauthFailed(c, http.StatusBadGateway, "第三方授权失败，请稍后重试")
 		return
 	}
-
-	// 重定向到首页
-	c.Redirect(http.StatusTemporaryRedirect, "/")
-}
-
-// Google授权登录处理
-func GoogleLogin(c *gin.Context) {
-	state := generateState()
-	session := sessions.Default(c)
-	session.Set(oauthStateString, state)
-	err := session.Save()
+	profile, err := provider.FetchProfile(ctx, token, pending.Nonce)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+		fmt.Printf("%s 获取用户信息失败: %v\n", provider.Name(), err)
+		oauthFailed(c, http.StatusBadGateway, "获取第三方账号信息失败，请稍后重试")
 		return
 	}
 
-	url := googleOAuthConfig.AuthCodeURL(state)
-	c.Redirect(http.StatusTemporaryRedirect, url)
+	completeOAuth(c, profile, pending)
 }
 
-// Google回调处理
-func GoogleCallback(c *gin.Context) {
+// completeOAuth 第三方授权完成后登录，或为当前用户关联第三方账号
+func completeOAuth(c *gin.Context, profile *OAuthProfile, pending oauthPending) {
 	session := sessions.Default(c)
 
-	// 验证state参数
-	state := c.Query("state")
-	savedState := session.Get(oauthStateString)
-	if state == "" || savedState == nil || state != savedState.(string) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state parameter"})
-		return
-	}
-
-	// 获取授权码
-	code := c.Query("code")
-	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code parameter"})
-		return
-	}
-
-	// 交换access token
-	token, err := googleOAuthConfig.Exchange(context.Background(), code)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
-		return
+	if pending.Link {
+		if user := UserFromContext(c); user != nil {
+			if err := LinkIdentity(c, user, *profile); err != nil {
+				session.AddFlash(err.Error(), flashError)
+			} else {
+				session.AddFlash(fmt.Sprintf("已关联 %s 账号 %s", models.ProviderLabel(profile.Provider), profile.Username), flashSuccess)
+			}
+			session.Save()
+			c.Redirect(http.StatusFound, pending.RedirectTo)
+			return
+		}
 	}
 
-	// 获取用户信息
-	client := googleOAuthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+	// 处理用户登录/注册
+	user, err := handleOAuthUserLogin(c, *profile)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
+		if errors.Is(err, errOAuthEmailTaken) {
+			oauthFailed(c, http.StatusConflict, err.Error())
+			return
+		}
+		fmt.Printf("第三方登录处理失败: %v\n", err)
+		oauthFailed(c, http.StatusInternalServerError, "登录失败，请稍后重试")
 		return
 	}
-	defer resp.Body.Close()
 
-	var googleUser GoogleUser
-	if err := json.NewDecoder(resp.Body).Decode(&googleUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode user info"})
+	if sanction := LoginSanction(c, user); sanction != nil {
+		oauthFailed(c, http.StatusForbidden, sanction.Notice())
 		return
 	}
 
-	// 处理用户登录/注册
-	user, err := handleOAuthUserLogin(c, googleUser.Email, googleUser.Email, googleUser.Name, googleUser.Picture)
+	// 设置session，启用两步验证的用户先跳转到验证码页面
+	twoFactorRequired, err := StartLogin(c, user, true, pending.RedirectTo)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user login"})
+		oauthFailed(c, http.StatusInternalServerError, "保存会话失败，请稍后重试")
 		return
 	}
-
-	// 设置session
-	session.Set("user_id", user.ID)
-	err = session.Save()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+	if twoFactorRequired {
+		c.Redirect(http.StatusFound, "/login/two-factor")
 		return
 	}
 
-	// 重定向到首页
-	c.Redirect(http.StatusTemporaryRedirect, "/")
+	c.Redirect(http.StatusFound, pending.RedirectTo)
 }
 
-// 处理OAuth用户登录/注册的通用函数
-func handleOAuthUserLogin(c *gin.Context, identifier, email, name, avatarURL string) (*models.User, error) {
-	var user models.User
+// oauthFailed 第三方登录是浏览器跳转流程，出错时显示错误页面而不是 JSON
+func oauthFailed(c *gin.Context, status int, message string) {
+	responses.HTML(c, status, "403.tmpl", gin.H{"Message": message})
+}
 
-	// 尝试查找现有用户
-	if email != "" {
-		// 优先通过邮箱查找
-		database.DB.Where("email = ?", email).First(&user)
+// 处理OAuth用户登录/注册的通用函数。
+// 只按 (平台, 平台用户ID) 查找已关联的账户；第三方邮箱与本站已验证的邮箱一致且双方都已验证时自动关联，
+// 否则邮箱已被使用时拒绝登录，需要用户登录后手动关联。
+func handleOAuthUserLogin(c *gin.Context, profile OAuthProfile) (*models.User, error) {
+	var identity models.UserIdentity
+	err := database.DB.Where("provider = ? AND provider_user_id = ?", profile.Provider, profile.ProviderUserID).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := database.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, err
+		}
+		// 同步平台上的用户名和邮箱
+		database.DB.Model(&identity).Updates(map[string]interface{}{
+			"username": profile.Username,
+			"email":    profile.Email,
+		})
+		return &user, nil
 	}
 
-	if user.ID == 0 && identifier != "" {
-		// 通过标识符查找
-		database.DB.Where("name = ?", identifier).First(&user)
+	if profile.Email != "" {
+		var existing models.User
+		if database.DB.Where("email = ?", profile.Email).First(&existing).Error == nil {
+			if !profile.EmailVerified || !existing.EmailVerified {
+				return nil, errOAuthEmailTaken
+			}
+			if err := LinkIdentity(c, &existing, profile); err != nil {
+				return nil, errOAuthEmailTaken
+			}
+			return &existing, nil
+		}
 	}
 
-	// 如果用户不存在，则创建新用户
-	if user.ID == 0 {
-		// 生成随机密码
-		passwordBytes := make([]byte, 32)
-		rand.Read(passwordBytes)
-		randomPassword := base64.URLEncoding.EncodeToString(passwordBytes)
+	// 用户不存在，创建新用户
+	// 生成随机密码，用户设置密码之前不能用密码登录
+	passwordBytes := make([]byte, 32)
+	rand.Read(passwordBytes)
+	randomPassword := base64.URLEncoding.EncodeToString(passwordBytes)
 
-		hashedPassword, err := utils.HashPassword(randomPassword)
-		if err != nil {
-			return nil, err
-		}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
 
-		// 如果没有名字，使用标识符
-		if name == "" {
-			name = identifier
-		}
+	// 如果没有名字，使用平台用户名
+	name := profile.Name
+	if name == "" {
+		name = profile.Username
+	}
 
-		// 如果没有头像，生成默认头像
-		if avatarURL == "" {
-			avatarURL = fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=random", url.QueryEscape(name))
-		}
+	// 如果没有头像，生成默认头像
+	avatarURL := profile.AvatarURL
+	if avatarURL == "" {
+		avatarURL = fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=random", url.QueryEscape(name))
+	}
 
-		user = models.User{
-			Name:       name,
-			Email:      email,
-			Password:   hashedPassword,
-			AgreeTerms: true, // OAuth用户默认同意条款
-			Avatar:     avatarURL,
-		}
+	user := models.User{
+		Name:       name,
+		Email:      profile.Email,
+		Password:   hashedPassword,
+		AgreeTerms: true, // OAuth用户默认同意条款
+		Avatar:     avatarURL,
+	}
+	if profile.EmailVerified {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
 
-		if err := database.DB.Create(&user).Error; err != nil {
-			return nil, err
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
 		}
+		return tx.Create(newIdentity(user.ID, profile)).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-
+	syncIdentityField(user.ID, profile.Provider, profile.Username)
 	return &user, nil
 }
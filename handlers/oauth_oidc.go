@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"gin-doniai/models"
+	"gin-doniai/oidc"
+	"gin-doniai/utils"
+
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider 通过 OpenID Connect 登录的平台，如 Keycloak、Gitea、Authentik
+type OIDCProvider struct {
+	*oidc.Provider
+}
+
+func (p *OIDCProvider) Name() string  { return p.Provider.Config.Name }
+func (p *OIDCProvider) Label() string { return p.Provider.Config.Label }
+
+func (p *OIDCProvider) Config(ctx context.Context) (*oauth2.Config, error) {
+	return p.OAuth2Config(ctx)
+}
+
+// FetchProfile 校验 ID Token 后按配置的声明名称读取用户信息
+func (p *OIDCProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (*OAuthProfile, error) {
+	claims, err := p.Claims(ctx, token, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := p.Provider.Config.Claims
+	profile := &OAuthProfile{
+		Provider:       p.Name(),
+		ProviderUserID: claims.String("sub"),
+		Username:       claims.String(mapping.Username),
+		Email:          claims.String(mapping.Email),
+		Name:           claims.String(mapping.Name),
+		AvatarURL:      utils.SafeURL(claims.String(mapping.Avatar)),
+	}
+	if profile.Email != "" {
+		profile.EmailVerified = p.Provider.Config.TrustEmail || claims.Bool(mapping.EmailVerified)
+	}
+	if profile.Username == "" {
+		profile.Username = profile.Email
+	}
+	return profile, nil
+}
+
+// registerOIDCProviders 注册环境变量 OIDC_PROVIDERS 中配置的平台，配置有误的平台跳过
+func registerOIDCProviders() {
+	configs, err := oidc.ConfigsFromEnv()
+	if err != nil {
+		fmt.Printf("警告: OpenID Connect 配置有误: %v\n", err)
+	}
+	for _, cfg := range configs {
+		if _, exists := oauthProviders[cfg.Name]; exists || cfg.Name == models.ProviderGitHub || cfg.Name == models.ProviderGoogle {
+			fmt.Printf("警告: OpenID Connect 平台名称 %s 与已有平台重复，已跳过\n", cfg.Name)
+			continue
+		}
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = utils.AbsoluteURL("/auth/" + cfg.Name + "/callback")
+		}
+		RegisterOAuthProvider(&OIDCProvider{Provider: oidc.NewProvider(cfg)})
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"gin-doniai/models"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+	"golang.org/x/oauth2/google"
+)
+
+// OAuthProvider 第三方登录平台
+type OAuthProvider interface {
+	// Name 平台标识，同时用于路由 /auth/:provider
+	Name() string
+	// Label 显示名称
+	Label() string
+	Config(ctx context.Context) (*oauth2.Config, error)
+	// FetchProfile 用换取到的令牌获取用户信息，nonce 为发起登录时生成的随机值
+	FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (*OAuthProfile, error)
+}
+
+// 已启用的第三方登录平台，在 init 中注册，之后只读
+var (
+	oauthProviders     = map[string]OAuthProvider{}
+	oauthProviderOrder []string
+)
+
+// RegisterOAuthProvider 注册第三方登录平台，同名的会被覆盖
+func RegisterOAuthProvider(p OAuthProvider) {
+	if _, ok := oauthProviders[p.Name()]; !ok {
+		oauthProviderOrder = append(oauthProviderOrder, p.Name())
+	}
+	oauthProviders[p.Name()] = p
+	models.RegisterProviderLabel(p.Name(), p.Label())
+}
+
+// OAuthProviderInfo 登录页和设置页显示的平台
+type OAuthProviderInfo struct {
+	Name  string
+	Label string
+}
+
+// OAuthProviders 已启用的第三方登录平台，按注册顺序排列
+func OAuthProviders() []OAuthProviderInfo {
+	list := make([]OAuthProviderInfo, 0, len(oauthProviderOrder))
+	for _, name := range oauthProviderOrder {
+		list = append(list, OAuthProviderInfo{Name: name, Label: oauthProviders[name].Label()})
+	}
+	return list
+}
+
+// GitHubProvider GitHub 登录，APIBaseURL 可以替换为测试用的模拟服务地址
+type GitHubProvider struct {
+	OAuth2     *oauth2.Config
+	APIBaseURL string
+}
+
+// NewGitHubProvider 创建 GitHub 登录，需要 user:email 权限获取已验证的邮箱
+func NewGitHubProvider(clientID, clientSecret, redirectURL string) *GitHubProvider {
+	return &GitHubProvider{
+		OAuth2: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL, // 如: http://localhost:8080/auth/github/callback
+			Scopes:       []string{"user:email"},
+			Endpoint:     github.Endpoint,
+		},
+		APIBaseURL: "https://api.github.com",
+	}
+}
+
+func (p *GitHubProvider) Name() string  { return models.ProviderGitHub }
+func (p *GitHubProvider) Label() string { return "GitHub" }
+
+func (p *GitHubProvider) Config(ctx context.Context) (*oauth2.Config, error) { return p.OAuth2, nil }
+
+// GitHub用户信息结构体
+type GitHubUser struct {
+	ID        int    `json:"id"`
+	Login     string `json:"login"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// GitHubEmail GitHub 账户的邮箱列表项
+type GitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// FetchProfile 获取 GitHub 用户信息，公开资料中的邮箱不保证已验证，优先使用已验证的主邮箱
+func (p *GitHubProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (*OAuthProfile, error) {
+	client := p.OAuth2.Client(ctx, token)
+	var githubUser GitHubUser
+	if err := getJSON(ctx, client, p.APIBaseURL+"/user", &githubUser); err != nil {
+		return nil, err
+	}
+	if githubUser.ID == 0 {
+		return nil, errors.New("GitHub 用户信息缺少ID")
+	}
+
+	profile := &OAuthProfile{
+		Provider:       models.ProviderGitHub,
+		ProviderUserID: strconv.Itoa(githubUser.ID),
+		Username:       githubUser.Login,
+		Email:          githubUser.Email,
+		Name:           githubUser.Name,
+		AvatarURL:      githubUser.AvatarURL,
+	}
+
+	// 获取失败时退回公开资料中的邮箱，视为未验证
+	var emails []GitHubEmail
+	if err := getJSON(ctx, client, p.APIBaseURL+"/user/emails", &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				profile.Email = e.Email
+				profile.EmailVerified = true
+				break
+			}
+		}
+	}
+	return profile, nil
+}
+
+// GoogleProvider Google 登录，UserInfoURL 可以替换为测试用的模拟服务地址
+type GoogleProvider struct {
+	OAuth2      *oauth2.Config
+	UserInfoURL string
+}
+
+// NewGoogleProvider 创建 Google 登录
+func NewGoogleProvider(clientID, clientSecret, redirectURL string) *GoogleProvider {
+	return &GoogleProvider{
+		OAuth2: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL, // 如: http://localhost:8080/auth/google/callback
+			Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
+			Endpoint:     google.Endpoint,
+		},
+		UserInfoURL: "https://www.googleapis.com/oauth2/v2/userinfo",
+	}
+}
+
+func (p *GoogleProvider) Name() string  { return models.ProviderGoogle }
+func (p *GoogleProvider) Label() string { return "Google" }
+
+func (p *GoogleProvider) Config(ctx context.Context) (*oauth2.Config, error) { return p.OAuth2, nil }
+
+// Google用户信息结构体
+type GoogleUser struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	VerifiedEmail bool   `json:"verified_email"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// FetchProfile 获取 Google 用户信息
+func (p *GoogleProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (*OAuthProfile, error) {
+	client := p.OAuth2.Client(ctx, token)
+	var googleUser GoogleUser
+	if err := getJSON(ctx, client, p.UserInfoURL, &googleUser); err != nil {
+		return nil, err
+	}
+	if googleUser.ID == "" {
+		return nil, errors.New("Google 用户信息缺少ID")
+	}
+	return &OAuthProfile{
+		Provider:       models.ProviderGoogle,
+		ProviderUserID: googleUser.ID,
+		Username:       googleUser.Email,
+		Email:          googleUser.Email,
+		EmailVerified:  googleUser.VerifiedEmail,
+		Name:           googleUser.Name,
+		AvatarURL:      googleUser.Picture,
+	}, nil
+}
+
+// getJSON 请求第三方接口并解析 JSON 响应
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 返回 %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func init() {
+	// 加载.env文件
+	if err := godotenv.Load(); err != nil {
+		fmt.Println("警告: 未能加载 .env 文件")
+	}
+
+	// 只启用配置了 Client ID 的平台
+	if id := os.Getenv("GITHUB_CLIENT_ID"); id != "" {
+		RegisterOAuthProvider(NewGitHubProvider(id, os.Getenv("GITHUB_CLIENT_SECRET"), os.Getenv("GITHUB_REDIRECT_URL")))
+	}
+	if id := os.Getenv("GOOGLE_CLIENT_ID"); id != "" {
+		RegisterOAuthProvider(NewGoogleProvider(id, os.Getenv("GOOGLE_CLIENT_SECRET"), os.Getenv("GOOGLE_REDIRECT_URL")))
+	}
+	registerOIDCProviders()
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/gob"
+	"sort"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+)
+
+const (
+	sessionOAuthPending = "oauth_pending"
+	oauthStateTTL       = 10 * time.Minute
+	maxOAuthPending     = 5 // 同一会话中同时进行的第三方登录数量上限（多个标签页）
+)
+
+// oauthPending 一次进行中的第三方登录，以 state 为键保存在会话中
+type oauthPending struct {
+	Provider   string
+	Verifier   string // PKCE code_verifier
+	Nonce      string // OpenID Connect 的 nonce，回调时与 ID Token 中的比对
+	RedirectTo string // 完成后返回的站内路径
+	Link       bool   // 是否为已登录用户关联账号
+	ExpiresAt  int64
+}
+
+func init() {
+	gob.Register(map[string]oauthPending{})
+}
+
+// saveOAuthPending 保存一次第三方登录，同时清理已过期的记录
+func saveOAuthPending(session sessions.Session, state string, pending oauthPending) error {
+	all := loadOAuthPending(session)
+	if len(all) >= maxOAuthPending {
+		// 超出上限时丢弃最早的
+		states := make([]string, 0, len(all))
+		for s := range all {
+			states = append(states, s)
+		}
+		sort.Slice(states, func(i, j int) bool { return all[states[i]].ExpiresAt < all[states[j]].ExpiresAt })
+		for _, s := range states[:len(all)-maxOAuthPending+1] {
+			delete(all, s)
+		}
+	}
+	all[state] = pending
+	session.Set(sessionOAuthPending, all)
+	return session.Save()
+}
+
+// takeOAuthPending 取出并删除 state 对应的登录，不存在或已过期时返回 false，调用方需要保存会话
+func takeOAuthPending(session sessions.Session, state string) (oauthPending, bool) {
+	all := loadOAuthPending(session)
+	pending, ok := all[state]
+	if state == "" || !ok {
+		return oauthPending{}, false
+	}
+	delete(all, state)
+	if len(all) == 0 {
+		session.Delete(sessionOAuthPending)
+	} else {
+		session.Set(sessionOAuthPending, all)
+	}
+	return pending, true
+}
+
+// loadOAuthPending 读取会话中未过期的登录
+func loadOAuthPending(session sessions.Session) map[string]oauthPending {
+	stored, _ := session.Get(sessionOAuthPending).(map[string]oauthPending)
+	now := time.Now().Unix()
+	all := make(map[string]oauthPending, len(stored))
+	for state, pending := range stored {
+		if pending.ExpiresAt > now {
+			all[state] = pending
+		}
+	}
+	return all
+}
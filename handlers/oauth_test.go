@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// fakeOAuthServer 模拟第三方授权服务：记录授权请求中的 PKCE challenge，换取令牌时校验 code_verifier
+type fakeOAuthServer struct {
+	*httptest.Server
+	mu         sync.Mutex
+	challenges map[string]string // code -> code_challenge
+	verifiers  []string          // 换取令牌时收到的 code_verifier
+}
+
+func newFakeOAuthServer(t *testing.T) *fakeOAuthServer {
+	s := &fakeOAuthServer{challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.mu.Lock()
+		challenge, ok := s.challenges[r.PostForm.Get("code")]
+		verifier := r.PostForm.Get("code_verifier")
+		s.verifiers = append(s.verifiers, verifier)
+		s.mu.Unlock()
+		sum := sha256.Sum256([]byte(verifier))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"fake-token","token_type":"Bearer","expires_in":3600}`))
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// authorize 模拟用户在授权页面同意授权，返回授权码
+func (s *fakeOAuthServer) authorize(t *testing.T, authURL *url.URL) string {
+	t.Helper()
+	q := authURL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("授权请求缺少 PKCE 参数: %s", authURL)
+	}
+	code := "code-" + q.Get("state")[:8]
+	s.mu.Lock()
+	s.challenges[code] = q.Get("code_challenge")
+	s.mu.Unlock()
+	return code
+}
+
+// fakeProvider 测试用的登录平台，FetchProfile 只记录参数后返回错误，不会进入写数据库的流程
+type fakeProvider struct {
+	name   string
+	config *oauth2.Config
+
+	mu     sync.Mutex
+	tokens []string
+	nonces []string
+}
+
+var errFakeProfile = errors.New("fake profile")
+
+func (p *fakeProvider) Name() string  { return p.name }
+func (p *fakeProvider) Label() string { return p.name }
+func (p *fakeProvider) Config(ctx context.Context) (*oauth2.Config, error) {
+	return p.config, nil
+}
+func (p *fakeProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (*OAuthProfile, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokens = append(p.tokens, token.AccessToken)
+	p.nonces = append(p.nonces, nonce)
+	return nil, errFakeProfile
+}
+
+func (p *fakeProvider) fetched() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.tokens)
+}
+
+// oauthTestClient 保存 Cookie 的测试客户端
+type oauthTestClient struct {
+	router  *gin.Engine
+	cookies map[string]*http.Cookie
+}
+
+func (c *oauthTestClient) get(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, ck := range c.cookies {
+		req.AddCookie(ck)
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+	for _, ck := range w.Result().Cookies() {
+		c.cookies[ck.Name] = ck
+	}
+	return w
+}
+
+// setupOAuthTest 注册两个测试平台，返回测试客户端
+func setupOAuthTest(t *testing.T) (*oauthTestClient, *fakeOAuthServer, *fakeProvider, *fakeProvider) {
+	gin.SetMode(gin.TestMode)
+	server := newFakeOAuthServer(t)
+	newProvider := func(name string) *fakeProvider {
+		return &fakeProvider{name: name, config: &oauth2.Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			RedirectURL:  "http://localhost/auth/" + name + "/callback",
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   server.URL + "/authorize",
+				TokenURL:  server.URL + "/token",
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		}}
+	}
+	first, second := newProvider("fake-a"), newProvider("fake-b")
+	for _, p := range []*fakeProvider{first, second} {
+		oauthProviders[p.name] = p
+		name := p.name
+		t.Cleanup(func() { delete(oauthProviders, name) })
+	}
+
+	r := gin.New()
+	r.SetHTMLTemplate(template.Must(template.New("403.tmpl").Parse(`{{.Message}}`)))
+	r.Use(sessions.Sessions("test", cookie.NewStore([]byte("test-secret"))))
+	r.GET("/auth/:provider", OAuthLogin)
+	r.GET("/auth/:provider/callback", OAuthCallback)
+	// 写入一条已过期的登录，模拟超过10分钟后才回调
+	r.GET("/seed-expired", func(c *gin.Context) {
+		saveOAuthPending(sessions.Default(c), "expired-state", oauthPending{
+			Provider:  "fake-a",
+			Verifier:  oauth2.GenerateVerifier(),
+			ExpiresAt: time.Now().Add(-time.Second).Unix(),
+		})
+	})
+	// 输出会话中保存的登录完成后的返回路径
+	r.GET("/pending-redirects", func(c *gin.Context) {
+		var paths []string
+		for _, pending := range loadOAuthPending(sessions.Default(c)) {
+			paths = append(paths, pending.RedirectTo)
+		}
+		c.String(http.StatusOK, strings.Join(paths, ","))
+	})
+	return &oauthTestClient{router: r, cookies: map[string]*http.Cookie{}}, server, first, second
+}
+
+// startLogin 发起登录，返回跳转到授权服务的地址
+func startLogin(t *testing.T, client *oauthTestClient, provider string) *url.URL {
+	t.Helper()
+	w := client.get(t, "/auth/"+provider)
+	if w.Code != http.StatusFound {
+		t.Fatalf("GET /auth/%s = %d", provider, w.Code)
+	}
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func callbackURL(provider, state, code string) string {
+	return "/auth/" + provider + "/callback?" + url.Values{"state": {state}, "code": {code}}.Encode()
+}
+
+const (
+	msgStateInvalid = "登录请求无效或已过期"
+	msgExchangeFail = "第三方授权失败"
+	msgProfileFail  = "获取第三方账号信息失败"
+)
+
+func TestOAuthCallbackForwardsPKCEVerifierAndNonce(t *testing.T) {
+	client, server, provider, _ := setupOAuthTest(t)
+	authURL := startLogin(t, client, "fake-a")
+	code := server.authorize(t, authURL)
+
+	w := client.get(t, callbackURL("fake-a", authURL.Query().Get("state"), code))
+	// 换取令牌成功后才会获取用户信息，测试平台获取用户信息时返回错误
+	if !strings.Contains(w.Body.String(), msgProfileFail) {
+		t.Fatalf("回调结果 %d %q，期望换取令牌成功", w.Code, w.Body.String())
+	}
+	if len(server.verifiers) != 1 || server.verifiers[0] == "" {
+		t.Fatalf("授权服务收到的 code_verifier = %q", server.verifiers)
+	}
+	if provider.tokens[0] != "fake-token" {
+		t.Errorf("FetchProfile 收到的令牌 = %q", provider.tokens[0])
+	}
+	if nonce := authURL.Query().Get("nonce"); nonce == "" || provider.nonces[0] != nonce {
+		t.Errorf("FetchProfile 收到的 nonce = %q，授权请求中为 %q", provider.nonces[0], nonce)
+	}
+}
+
+func TestOAuthCallbackVerifierPerLogin(t *testing.T) {
+	client, server, provider, _ := setupOAuthTest(t)
+	// 同一会话先后发起两次登录，回调时各自使用自己的 verifier
+	first := startLogin(t, client, "fake-a")
+	second := startLogin(t, client, "fake-a")
+	server.authorize(t, first)
+	secondCode := server.authorize(t, second)
+
+	// 用第一次的 state 搭配第二次的授权码，verifier 与 challenge 不匹配
+	w := client.get(t, callbackURL("fake-a", first.Query().Get("state"), secondCode))
+	if !strings.Contains(w.Body.String(), msgExchangeFail) {
+		t.Fatalf("verifier 不匹配时 = %q，期望换取令牌失败", w.Body.String())
+	}
+	w = client.get(t, callbackURL("fake-a", second.Query().Get("state"), secondCode))
+	if !strings.Contains(w.Body.String(), msgProfileFail) {
+		t.Fatalf("第二次登录回调 = %q，期望换取令牌成功", w.Body.String())
+	}
+	if n := provider.fetched(); n != 1 {
+		t.Errorf("获取用户信息 %d 次，期望 1 次", n)
+	}
+}
+
+func TestOAuthCallbackRejectsInvalidState(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		state    func(authURL *url.URL) string
+	}{
+		{"missing", "fake-a", func(*url.URL) string { return "" }},
+		{"unknown", "fake-a", func(*url.URL) string { return "forged-state" }},
+		{"other provider", "fake-b", func(u *url.URL) string { return u.Query().Get("state") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server, first, second := setupOAuthTest(t)
+			authURL := startLogin(t, client, "fake-a")
+			code := server.authorize(t, authURL)
+
+			w := client.get(t, callbackURL(tt.provider, tt.state(authURL), code))
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), msgStateInvalid) {
+				t.Fatalf("回调结果 %d %q，期望 400", w.Code, w.Body.String())
+			}
+			if len(server.verifiers) != 0 || first.fetched()+second.fetched() != 0 {
+				t.Error("state 无效时不应换取令牌")
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackStateSingleUse(t *testing.T) {
+	client, server, provider, _ := setupOAuthTest(t)
+	authURL := startLogin(t, client, "fake-a")
+	code := server.authorize(t, authURL)
+	target := callbackURL("fake-a", authURL.Query().Get("state"), code)
+
+	if w := client.get(t, target); !strings.Contains(w.Body.String(), msgProfileFail) {
+		t.Fatalf("第一次回调 = %q", w.Body.String())
+	}
+	w := client.get(t, target)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), msgStateInvalid) {
+		t.Fatalf("重复回调 = %d %q，期望 400", w.Code, w.Body.String())
+	}
+	if n := provider.fetched(); n != 1 {
+		t.Errorf("获取用户信息 %d 次，期望 1 次", n)
+	}
+}
+
+func TestOAuthCallbackStateConsumedOnError(t *testing.T) {
+	client, server, _, _ := setupOAuthTest(t)
+	authURL := startLogin(t, client, "fake-a")
+	code := server.authorize(t, authURL)
+	state := authURL.Query().Get("state")
+
+	// 用户取消授权后 state 同样失效
+	w := client.get(t, "/auth/fake-a/callback?"+url.Values{"state": {state}, "error": {"access_denied"}}.Encode())
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("取消授权 = %d", w.Code)
+	}
+	w = client.get(t, callbackURL("fake-a", state, code))
+	if !strings.Contains(w.Body.String(), msgStateInvalid) {
+		t.Fatalf("state 使用后再次回调 = %q，期望 state 无效", w.Body.String())
+	}
+}
+
+func TestOAuthCallbackStateExpired(t *testing.T) {
+	client, server, _, _ := setupOAuthTest(t)
+	client.get(t, "/seed-expired")
+
+	w := client.get(t, callbackURL("fake-a", "expired-state", "code"))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), msgStateInvalid) {
+		t.Fatalf("过期的 state = %d %q，期望 400", w.Code, w.Body.String())
+	}
+	if len(server.verifiers) != 0 {
+		t.Error("state 过期时不应换取令牌")
+	}
+}
+
+func TestOAuthLoginRejectsUnsafeRedirect(t *testing.T) {
+	tests := []struct {
+		redirectTo string
+		want       string
+	}{
+		{"/post-1-1", "/post-1-1"},
+		{"//evil.com", "/"},
+		{"/\\evil.com", "/"},
+		{"https://evil.com/", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.redirectTo, func(t *testing.T) {
+			client, _, _, _ := setupOAuthTest(t)
+			client.get(t, "/auth/fake-a?"+url.Values{"redirect_to": {tt.redirectTo}}.Encode())
+			if got := client.get(t, "/pending-redirects").Body.String(); got != tt.want {
+				t.Errorf("redirect_to=%q 保存为 %q，期望 %q", tt.redirectTo, got, tt.want)
+			}
+		})
+	}
+}
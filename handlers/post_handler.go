@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"gin-doniai/database"
 	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+	"gin-doniai/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,38 +19,41 @@ func CreatePost(c *gin.Context) {
     // 从上下文获取用户信息
     userObj, exists := c.Get("user")
     if !exists || userObj == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
+        responses.Unauthorized(c, "用户未登录")
         return
     }
     user := userObj.(*models.User)
 
     // 解析请求数据
-    var requestData struct {
-        Title      string `json:"title" binding:"required"`
-        CategoryId int    `json:"category_id" binding:"required"`
-        Content    string `json:"content" binding:"required"`
-        Tags       string `json:"tags"`
-        ReadLimit  int    `json:"read_limit"`
-    }
+    var requestData CreatePostRequest
 
     if err := c.ShouldBindJSON(&requestData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求参数错误: " + err.Error(),
-        })
+        responses.BadRequest(c, "请求参数错误: " + err.Error())
+        return
+    }
+
+    // 过滤敏感词，屏蔽的词语替换为 *；是否需要审核按屏蔽前的原文判断
+    reviewText := requestData.Title + "\n" + requestData.Content
+    if !filterWords(c, &requestData.Title, &requestData.Content, &requestData.Tags) {
+        return
+    }
+
+    // 未指定阅读限制时默认公开
+    if requestData.ReadLimit == 0 {
+        requestData.ReadLimit = models.ReadLimitPublic
+    }
+    if requestData.ReadLimit < models.ReadLimitPublic || requestData.ReadLimit > models.ReadLimitPrivate {
+        responses.BadRequest(c, "无效的阅读限制")
         return
     }
 
     // 根据 category_id 查询分类名称
     var category models.Category
     if err := database.DB.First(&category, requestData.CategoryId).Error; err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "无效的分类ID",
-        })
+        responses.BadRequest(c, "无效的分类ID")
+        return
+    }
+    if !checkPostCategory(c, user, &category) {
         return
     }
 
@@ -54,27 +62,28 @@ func CreatePost(c *gin.Context) {
         Title:     requestData.Title,
         Category:  category.Name, // 使用查询到的分类名称
         CategoryId: requestData.CategoryId,
-        Content:   requestData.Content,
+        Content:   utils.RenderMarkdown(requestData.Content, utils.MarkdownOptions{}),
+        Markdown:  requestData.Content,
         Tags:      requestData.Tags,
         UserId:    int(user.ID),
         Author:    user.Name,
         ReadLimit: requestData.ReadLimit,
     }
+    // 低等级用户或可疑内容需要审核后才会公开
+    post.StatusCode, post.ModerationReason = policies.InitialStatus(user, reviewText)
 
     result := database.DB.Create(&post)
     if result.Error != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "文章创建失败: " + result.Error.Error(),
-        })
+        responses.Internal(c, "文章创建失败: " + result.Error.Error())
         return
     }
+    reindexPosts(post.ID)
 
-    c.JSON(http.StatusCreated, gin.H{
-        "success": true,
-        "message": "文章创建成功",
-        "post":    post,
-    })
+    message := "文章创建成功"
+    if post.StatusCode == models.StatusPending {
+        message = "文章已提交，审核通过后公开显示"
+    }
+    responses.Created(c, message, serializers.NewPost(&post))
 }
 
 
@@ -83,10 +92,7 @@ func LikePost(c *gin.Context) {
 	// 从上下文获取用户信息
 	userObj, exists := c.Get("user")
 	if !exists || userObj == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户未登录",
-		})
+		responses.Unauthorized(c, "用户未登录")
 		return
 	}
 	user := userObj.(*models.User)
@@ -95,25 +101,17 @@ func LikePost(c *gin.Context) {
 	id := c.Param("id")
 	var post models.Post
 
-	// 查找文章
-	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "文章不存在",
-		})
+	// 查找文章，只能操作自己能看到的文章
+	if err := database.DB.First(&post, id).Error; err != nil || !policies.CanViewPost(user, &post) {
+		responses.NotFound(c, "文章不存在")
 		return
 	}
 
 	// 解析请求数据
-	var requestData struct {
-		Action string `json:"action" binding:"required,oneof=like unlike"` // 点赞或取消点赞
-	}
+	var requestData LikePostRequest
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "请求参数错误: " + err.Error(),
-		})
+		responses.BadRequest(c, "请求参数错误: " + err.Error())
 		return
 	}
 
@@ -131,10 +129,7 @@ func LikePost(c *gin.Context) {
 			}
 
 			if err := database.DB.Create(&postLike).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "点赞失败: " + err.Error(),
-				})
+				responses.Internal(c, "点赞失败: " + err.Error())
 				return
 			}
 
@@ -146,10 +141,7 @@ func LikePost(c *gin.Context) {
 		if err == nil {
 			// 用户已点赞，删除点赞记录
 			if err := database.DB.Delete(&postLike).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "取消点赞失败: " + err.Error(),
-				})
+				responses.Internal(c, "取消点赞失败: " + err.Error())
 				return
 			}
 
@@ -164,10 +156,8 @@ func LikePost(c *gin.Context) {
 	var updatedPost models.Post
 	database.DB.First(&updatedPost, id)
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "操作成功",
-		"likes":   updatedPost.Likes,
+	responses.OK(c, "操作成功", gin.H{
+		"likes": updatedPost.Likes,
 	})
 }
 
@@ -176,10 +166,7 @@ func FavoritePost(c *gin.Context) {
 	// 从上下文获取用户信息
 	userObj, exists := c.Get("user")
 	if !exists || userObj == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户未登录",
-		})
+		responses.Unauthorized(c, "用户未登录")
 		return
 	}
 	user := userObj.(*models.User)
@@ -188,25 +175,17 @@ func FavoritePost(c *gin.Context) {
 	id := c.Param("id")
 	var post models.Post
 
-	// 查找文章
-	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "文章不存在",
-		})
+	// 查找文章，只能操作自己能看到的文章
+	if err := database.DB.First(&post, id).Error; err != nil || !policies.CanViewPost(user, &post) {
+		responses.NotFound(c, "文章不存在")
 		return
 	}
 
 	// 解析请求数据
-	var requestData struct {
-		Action string `json:"action" binding:"required,oneof=favorite unfavorite"`
-	}
+	var requestData FavoritePostRequest
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "请求参数错误: " + err.Error(),
-		})
+		responses.BadRequest(c, "请求参数错误: " + err.Error())
 		return
 	}
 
@@ -224,10 +203,7 @@ func FavoritePost(c *gin.Context) {
 			}
 
 			if err := database.DB.Create(&postFavorite).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "收藏失败: " + err.Error(),
-				})
+				responses.Internal(c, "收藏失败: " + err.Error())
 				return
 			}
 
@@ -239,10 +215,7 @@ func FavoritePost(c *gin.Context) {
 		if err == nil {
 			// 用户已收藏，删除收藏记录
 			if err := database.DB.Delete(&postFavorite).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "取消收藏失败: " + err.Error(),
-				})
+				responses.Internal(c, "取消收藏失败: " + err.Error())
 				return
 			}
 
@@ -257,27 +230,27 @@ func FavoritePost(c *gin.Context) {
 	var updatedPost models.Post
 	database.DB.First(&updatedPost, id)
 
-	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"message":   "操作成功",
+	responses.OK(c, "操作成功", gin.H{
 		"favorites": updatedPost.Favorites,
 	})
 }
 
-// GetPosts 获取所有用户
+// GetPosts 获取文章列表（分页）
 func GetPosts(c *gin.Context) {
 	var posts []models.Post
 
-	result := database.DB.Find(&posts)
+	page, perPage := responses.PageParams(c, 20)
+	query := database.DB.Model(&models.Post{}).Scopes(policies.VisiblePosts(UserFromContext(c)))
+	var total int64
+	query.Count(&total)
+
+	result := query.Preload("User").Order("created_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&posts)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"posts": posts,
-		"count": len(posts),
-	})
+	responses.List(c, serializers.NewPosts(posts), responses.NewMeta(page, perPage, total))
 }
 
 // GetPost 获取单个文章
@@ -285,13 +258,25 @@ func GetPost(c *gin.Context) {
 	id := c.Param("id")
 	var post models.Post
 
-	result := database.DB.First(&post, id)
+	result := database.DB.Preload("User").First(&post, id)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		responses.NotFound(c, "文章不存在")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"post": post})
+	// 检查阅读权限：待审核、未通过和私有文章对无权查看的人表现为不存在，等级不足时提示
+	user := UserFromContext(c)
+	if !policies.CanViewPost(user, &post) {
+		if policies.IsHiddenPost(user, &post) {
+			responses.NotFound(c, "文章不存在")
+			return
+		}
+		responses.Error(c, http.StatusForbidden, responses.CodeLevelTooLow,
+			fmt.Sprintf("该文章需要 Lv%d 及以上等级才能阅读", policies.RequiredLevel(post.ReadLimit)))
+		return
+	}
+
+	responses.OK(c, "", serializers.NewPost(&post))
 }
 
 // UpdatePost 更新文章
@@ -301,28 +286,85 @@ func UpdatePost(c *gin.Context) {
 
 	// 先查找文章是否存在
 	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		responses.NotFound(c, "文章不存在")
+		return
+	}
+
+	// 只有作者或版主可以修改文章
+	user, _ := c.MustGet("user").(*models.User)
+	if !policies.CanModifyPost(user, &post) {
+		responses.Forbidden(c, "无权限修改此文章")
 		return
 	}
 
-	// 绑定更新数据
-	var updateData models.Post
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// 绑定更新数据（仅允许修改内容相关字段，作者等信息不可变更）
+	var requestData UpdatePostRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+	if requestData.ReadLimit < 0 || requestData.ReadLimit > models.ReadLimitPrivate {
+		responses.BadRequest(c, "无效的阅读限制")
 		return
 	}
+	reviewText := requestData.Title + "\n" + requestData.Content
+	if !filterWords(c, &requestData.Title, &requestData.Content, &requestData.Tags) {
+		return
+	}
+	updateData := models.Post{
+		Title:      requestData.Title,
+		CategoryId: requestData.CategoryId,
+		Tags:       requestData.Tags,
+		ReadLimit:  requestData.ReadLimit,
+	}
+	// 内容为Markdown原文，由服务端重新渲染
+	if requestData.Content != "" {
+		updateData.Markdown = requestData.Content
+		updateData.Content = utils.RenderMarkdown(requestData.Content, utils.MarkdownOptions{})
+	}
+	// 修改标题或内容后重新判断是否需要审核，审核人员修改他人文章时不改变状态
+	if (requestData.Title != "" || requestData.Content != "") && uint(post.UserId) == user.ID {
+		if reason := policies.ReviewReason(user, reviewText); reason != "" {
+			updateData.StatusCode = models.StatusPending
+			updateData.ModerationReason = reason
+		} else if post.StatusCode == models.StatusDisabled {
+			updateData.StatusCode = models.StatusPending
+			updateData.ModerationReason = policies.ResubmitReason
+		}
+	}
+	if requestData.CategoryId > 0 && requestData.CategoryId != post.CategoryId {
+		var category models.Category
+		if err := database.DB.First(&category, requestData.CategoryId).Error; err != nil {
+			responses.BadRequest(c, "无效的分类ID")
+			return
+		}
+		if !checkPostCategory(c, user, &category) {
+			return
+		}
+		updateData.Category = category.Name
+	}
 
 	// 更新文章
+	before := postAuditValues(&post)
 	result := database.DB.Model(&post).Updates(updateData)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
+	reindexPosts(post.ID)
+	// 版主修改他人文章时记录审计日志
+	if uint(post.UserId) != user.ID {
+		recordAudit(c, auditEntry{
+			Action:      models.AuditPostUpdate,
+			TargetType:  models.AuditTargetPost,
+			TargetID:    post.ID,
+			TargetLabel: post.Title,
+			Before:      before,
+			After:       postAuditValues(&post),
+		})
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "文章更新成功",
-		"post":    post,
-	})
+	responses.OK(c, "文章更新成功", serializers.NewPost(&post))
 }
 
 // DeletePost 删除文章（软删除）
@@ -330,20 +372,37 @@ func DeletePost(c *gin.Context) {
 	id := c.Param("id")
 	var post models.Post
 
-	// 先查找用户是否存在
+	// 先查找文章是否存在
 	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		responses.NotFound(c, "文章不存在")
 		return
 	}
 
-	// 软删除
-	result := database.DB.Delete(&post)
+	// 只有作者或版主可以删除文章
+	user, _ := c.MustGet("user").(*models.User)
+	if !policies.CanModifyPost(user, &post) {
+		responses.Forbidden(c, "无权限删除此文章")
+		return
+	}
+
+	// 软删除，文章进入回收站
+	before := postAuditValues(&post)
+	result := trashContent(database.DB, &post, []uint{post.ID}, user)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
+	reindexPosts(post.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditPostDelete,
+		TargetType:  models.AuditTargetPost,
+		TargetID:    post.ID,
+		TargetLabel: post.Title,
+		Before:      before,
+		After:       postAuditValues(&post),
+	})
 
-	c.JSON(http.StatusOK, gin.H{"message": "文章删除成功"})
+	responses.OK(c, "文章删除成功", nil)
 }
 
 // 硬删除（永久删除）
@@ -351,11 +410,39 @@ func ForceDeletePost(c *gin.Context) {
 	id := c.Param("id")
 	var post models.Post
 
-	result := database.DB.Unscoped().Delete(&post, id)
+	// 先查出文章（包括已软删除的），审计日志需要记录删除前的信息
+	if err := database.DB.Unscoped().First(&post, id).Error; err != nil {
+		responses.NotFound(c, "文章不存在")
+		return
+	}
+
+	result := database.DB.Unscoped().Delete(&post)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
+	reindexPosts(post.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditPostForceDelete,
+		TargetType:  models.AuditTargetPost,
+		TargetID:    post.ID,
+		TargetLabel: post.Title,
+		Before:      postAuditValues(&post),
+	})
 
-	c.JSON(http.StatusOK, gin.H{"message": "文章永久删除成功"})
+	responses.OK(c, "文章永久删除成功", nil)
+}
+
+// checkPostCategory 检查用户能否在分类下发帖，分类禁用时返回 400，等级不足时返回 403
+func checkPostCategory(c *gin.Context, user *models.User, category *models.Category) bool {
+	if category.StatusCode != models.StatusNormal {
+		responses.BadRequest(c, "该分类暂不可发帖")
+		return false
+	}
+	if !policies.CanPostInCategory(user, category) {
+		responses.Error(c, http.StatusForbidden, responses.CodeLevelTooLow,
+			fmt.Sprintf("在「%s」发帖需要 Lv%d 及以上等级", category.Name, category.MinPostLevel))
+		return false
+	}
+	return true
 }
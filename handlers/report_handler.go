@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReportPost 举报文章，来自可信用户的举报达到阈值后文章自动隐藏并进入审核
+func ReportPost(c *gin.Context) {
+	user := UserFromContext(c)
+	var post models.Post
+	if err := database.DB.First(&post, c.Param("id")).Error; err != nil || !policies.CanViewPost(user, &post) {
+		responses.NotFound(c, "文章不存在")
+		return
+	}
+	if uint(post.UserId) == user.ID {
+		responses.BadRequest(c, "不能举报自己发布的内容")
+		return
+	}
+
+	report, ok := createReport(c, user, models.ReportTargetPost, post.ID)
+	if !ok {
+		return
+	}
+	if report.Trusted && reachedHideThreshold(models.ReportTargetPost, post.ID) {
+		database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(&post, post.ID).Error; err != nil || post.StatusCode != models.StatusNormal {
+				return err
+			}
+			return updatePostStatus(tx, &post, models.StatusPending, policies.ReportHiddenReason, nil)
+		})
+		reindexPosts(post.ID)
+	}
+	responses.Created(c, "举报已提交，感谢您的反馈", serializers.NewReport(report))
+}
+
+// ReportComment 举报评论，来自可信用户的举报达到阈值后评论自动隐藏并进入审核
+func ReportComment(c *gin.Context) {
+	user := UserFromContext(c)
+	comment, ok := findVisibleComment(user, c.Param("id"), database.DB)
+	if !ok {
+		responses.NotFound(c, "评论不存在")
+		return
+	}
+	if comment.UserID == user.ID {
+		responses.BadRequest(c, "不能举报自己发布的内容")
+		return
+	}
+
+	report, ok := createReport(c, user, models.ReportTargetComment, comment.ID)
+	if !ok {
+		return
+	}
+	if report.Trusted && reachedHideThreshold(models.ReportTargetComment, comment.ID) {
+		database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(comment, comment.ID).Error; err != nil || comment.StatusCode != models.StatusNormal {
+				return err
+			}
+			return updateCommentStatus(tx, comment, models.StatusPending, policies.ReportHiddenReason, nil)
+		})
+		reindexPosts(comment.PostID)
+	}
+	responses.Created(c, "举报已提交，感谢您的反馈", serializers.NewReport(report))
+}
+
+// createReport 保存举报，同一用户重复举报同一内容时返回 409
+func createReport(c *gin.Context, user *models.User, targetType string, targetID uint) (*models.Report, bool) {
+	var requestData ReportRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return nil, false
+	}
+
+	var reported int64
+	database.DB.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND user_id = ?", targetType, targetID, user.ID).
+		Count(&reported)
+	if reported > 0 {
+		responses.Error(c, http.StatusConflict, responses.CodeConflict, "您已经举报过该内容")
+		return nil, false
+	}
+
+	// 并发的重复举报由唯一索引拦截，同样返回 409
+	report := models.Report{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     user.ID,
+		Reason:     requestData.Reason,
+		Note:       strings.TrimSpace(requestData.Note),
+		Trusted:    policies.IsTrustedReporter(user),
+		Status:     models.ReportOpen,
+	}
+	if err := database.DB.Create(&report).Error; err != nil {
+		if database.IsDuplicateKey(err) {
+			responses.Error(c, http.StatusConflict, responses.CodeConflict, "您已经举报过该内容")
+			return nil, false
+		}
+		responses.Internal(c, "举报失败")
+		return nil, false
+	}
+	return &report, true
+}
+
+// reachedHideThreshold 来自可信用户的待处理举报是否达到自动隐藏的数量
+func reachedHideThreshold(targetType string, targetID uint) bool {
+	threshold := policies.ReportHideThreshold()
+	if threshold == 0 {
+		return false
+	}
+	var count int64
+	database.DB.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ? AND trusted = ?", targetType, targetID, models.ReportOpen, true).
+		Count(&count)
+	return count >= int64(threshold)
+}
+
+// ListReports 举报列表，status 为 open（默认）、resolved 或 dismissed
+func ListReports(c *gin.Context) {
+	page, perPage := responses.PageParams(c, 20)
+	status := c.DefaultQuery("status", models.ReportOpen)
+	if status != models.ReportResolved && status != models.ReportDismissed {
+		status = models.ReportOpen
+	}
+	query := database.DB.Model(&models.Report{}).Where("status = ?", status)
+	var total int64
+	query.Count(&total)
+
+	var reports []models.Report
+	if err := query.Preload("User").Order("created_at ASC").Offset((page - 1) * perPage).Limit(perPage).Find(&reports).Error; err != nil {
+		responses.Internal(c, "获取举报列表失败")
+		return
+	}
+	responses.List(c, serializers.NewReports(reports), responses.NewMeta(page, perPage, total))
+}
+
+// HandleReport 处理举报，对同一内容的所有待处理举报一并生效：
+// remove 下架内容，dismiss 驳回举报，因举报自动隐藏的内容恢复显示
+func HandleReport(c *gin.Context) {
+	var requestData ReportActionRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	var report models.Report
+	if err := database.DB.First(&report, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "举报不存在")
+		return
+	}
+	if report.Status != models.ReportOpen {
+		responses.BadRequest(c, "该举报已经处理过了")
+		return
+	}
+
+	remove := requestData.Action == "remove"
+	reason := strings.TrimSpace(requestData.Reason)
+	if reason == "" {
+		reason = "因举报下架：" + report.ReasonLabel()
+	}
+	outcome := models.ReportDismissed
+	if remove {
+		outcome = models.ReportResolved
+	}
+	reviewer := UserFromContext(c)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyReportAction(tx, &report, remove, reason, reviewer); err != nil {
+			return err
+		}
+		return closeReports(tx, report.TargetType, report.TargetID, outcome, reviewer)
+	})
+	if err != nil {
+		responses.Internal(c, "处理举报失败")
+		return
+	}
+	reindexReportTarget(report.TargetType, report.TargetID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditReportHandle,
+		TargetType:  models.AuditTargetReport,
+		TargetID:    report.ID,
+		TargetLabel: fmt.Sprintf("%s #%d", report.TargetType, report.TargetID),
+		Before:      auditValues{"status": report.Status},
+		After:       auditValues{"status": outcome, "reason": reason},
+	})
+	if remove {
+		responses.OK(c, "已下架内容", nil)
+	} else {
+		responses.OK(c, "已驳回举报", nil)
+	}
+}
+
+// applyReportAction 按处理结果修改被举报内容的状态，内容已被删除时不做处理
+func applyReportAction(tx *gorm.DB, report *models.Report, remove bool, reason string, reviewer *models.User) error {
+	switch report.TargetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := tx.First(&post, report.TargetID).Error; err != nil {
+			return ignoreNotFound(err)
+		}
+		if remove {
+			return updatePostStatus(tx, &post, models.StatusDisabled, reason, reviewer)
+		}
+		if post.StatusCode == models.StatusPending && post.ModerationReason == policies.ReportHiddenReason {
+			return updatePostStatus(tx, &post, models.StatusNormal, "", reviewer)
+		}
+	case models.ReportTargetComment:
+		var comment models.Comment
+		if err := tx.First(&comment, report.TargetID).Error; err != nil {
+			return ignoreNotFound(err)
+		}
+		if remove {
+			return updateCommentStatus(tx, &comment, models.StatusDisabled, reason, reviewer)
+		}
+		if comment.StatusCode == models.StatusPending && comment.ModerationReason == policies.ReportHiddenReason {
+			return updateCommentStatus(tx, &comment, models.StatusNormal, "", reviewer)
+		}
+	}
+	return nil
+}
+
+// closeReports 把内容的待处理举报标记为已处理或已驳回
+func closeReports(tx *gorm.DB, targetType string, targetID uint, outcome string, reviewer *models.User) error {
+	return tx.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportOpen).
+		Updates(map[string]interface{}{
+			"status":     outcome,
+			"handled_by": reviewer.ID,
+			"handled_at": time.Now(),
+		}).Error
+}
+
+// reportOutcome 审核结果对应的举报处理状态：通过即驳回举报，拒绝即举报成立
+func reportOutcome(status int) string {
+	if status == models.StatusNormal {
+		return models.ReportDismissed
+	}
+	return models.ReportResolved
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+type reportTarget struct {
+	Type string
+	ID   uint
+}
+
+// reportGroup 审核页面中同一内容的待处理举报
+type reportGroup struct {
+	ReportID   uint // 处理时提交的举报，对同一内容的所有举报生效
+	TargetType string
+	Title      string
+	URL        string
+	StatusCode int
+	Count      int
+	Reasons    []string
+	Notes      []string
+}
+
+// openReportGroups 按被举报内容汇总最早的一批待处理举报
+func openReportGroups(limit int) ([]*reportGroup, int64) {
+	var total int64
+	open := database.DB.Model(&models.Report{}).Where("status = ?", models.ReportOpen)
+	open.Count(&total)
+
+	var reports []models.Report
+	open.Order("created_at ASC").Limit(limit).Find(&reports)
+
+	var groups []*reportGroup
+	byTarget := map[reportTarget]*reportGroup{}
+	var postIDs, commentIDs []uint
+	for _, report := range reports {
+		key := reportTarget{report.TargetType, report.TargetID}
+		group, ok := byTarget[key]
+		if !ok {
+			group = &reportGroup{ReportID: report.ID, TargetType: report.TargetType}
+			byTarget[key] = group
+			groups = append(groups, group)
+			if report.TargetType == models.ReportTargetPost {
+				postIDs = append(postIDs, report.TargetID)
+			} else {
+				commentIDs = append(commentIDs, report.TargetID)
+			}
+		}
+		group.Count++
+		if label := report.ReasonLabel(); !slices.Contains(group.Reasons, label) {
+			group.Reasons = append(group.Reasons, label)
+		}
+		if report.Note != "" {
+			group.Notes = append(group.Notes, report.Note)
+		}
+	}
+
+	if len(postIDs) > 0 {
+		var posts []models.Post
+		database.DB.Select("id", "title", "status_code").Where("id IN ?", postIDs).Find(&posts)
+		for _, post := range posts {
+			if group := byTarget[reportTarget{models.ReportTargetPost, post.ID}]; group != nil {
+				group.Title = post.Title
+				group.URL = fmt.Sprintf("/post-%d-1", post.ID)
+				group.StatusCode = post.StatusCode
+			}
+		}
+	}
+	if len(commentIDs) > 0 {
+		var comments []models.Comment
+		database.DB.Select("id", "post_id", "markdown", "status_code").Where("id IN ?", commentIDs).Find(&comments)
+		for _, comment := range comments {
+			if group := byTarget[reportTarget{models.ReportTargetComment, comment.ID}]; group != nil {
+				group.Title = excerpt(comment.Markdown, 80)
+				group.URL = fmt.Sprintf("/post-%d-1", comment.PostID)
+				group.StatusCode = comment.StatusCode
+			}
+		}
+	}
+	return groups, total
+}
+
+// excerpt 截取前 n 个字符作为摘要
+func excerpt(s string, n int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,179 @@
+package handlers
+
+// 以下为 JSON API 的请求体定义，处理函数绑定这些类型，
+// OpenAPI 文档也通过反射这些类型生成，修改字段时文档会自动同步
+
+// CreatePostRequest 创建文章
+type CreatePostRequest struct {
+	Title      string `json:"title" binding:"required"`
+	CategoryId int    `json:"category_id" binding:"required"`
+	Content    string `json:"content" binding:"required"` // Markdown 原文
+	Tags       string `json:"tags"`                       // 逗号分隔
+	ReadLimit  int    `json:"read_limit"`                 // 1 公开 2 Lv1 3 Lv2 4 私密，默认 1
+}
+
+// UpdatePostRequest 更新文章，未传的字段保持不变
+type UpdatePostRequest struct {
+	Title      string `json:"title"`
+	CategoryId int    `json:"category_id"`
+	Content    string `json:"content"`
+	Tags       string `json:"tags"`
+	ReadLimit  int    `json:"read_limit"`
+}
+
+// LikePostRequest 文章点赞
+type LikePostRequest struct {
+	Action string `json:"action" binding:"required,oneof=like unlike"`
+}
+
+// FavoritePostRequest 文章收藏
+type FavoritePostRequest struct {
+	Action string `json:"action" binding:"required,oneof=favorite unfavorite"`
+}
+
+// CreateCommentRequest 发表评论
+type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	PostID   uint   `json:"post_id" binding:"required"`
+	ParentID uint   `json:"parent_id" binding:"omitempty"`
+}
+
+// UpdateCommentRequest 修改评论
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// LikeCommentRequest 评论点赞
+type LikeCommentRequest struct {
+	Action string `json:"action"` // like 或 unlike
+}
+
+// CreateUserRequest 管理员创建用户
+type CreateUserRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Avatar   string `json:"avatar"`
+	Level    int    `json:"level"`
+	Role     string `json:"role"`
+}
+
+// UpdateUserRequest 管理员更新用户
+type UpdateUserRequest struct {
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Avatar string `json:"avatar"`
+	Age    int    `json:"age"`
+	Level  int    `json:"level"`
+	Role   string `json:"role"`
+	Motto  string `json:"motto"`
+}
+
+// UpdateProfileRequest 更新个人资料，GitHub 和 Google 账户通过关联第三方账号设置
+type UpdateProfileRequest struct {
+	Motto string `json:"motto"`
+}
+
+// UpdatePasswordRequest 修改密码
+type UpdatePasswordRequest struct {
+	CurrentPassword string `json:"current_password"` // 第三方登录创建、尚未设置密码的账户可以不填
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ForgotPasswordRequest 申请重置密码
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 通过重置令牌设置新密码
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// CreateAccessTokenRequest 创建个人访问令牌
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=365"` // 0 表示永不过期
+}
+
+// TwoFactorCodeRequest 两步验证码，也可以填写恢复码
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ChangeEmailRequest 修改邮箱，新邮箱需要点击确认邮件后才会生效
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ModerationRequest 审核文章或评论，拒绝时需要填写原因
+type ModerationRequest struct {
+	Action string `json:"action" binding:"required,oneof=approve reject"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// ReportRequest 举报文章或评论
+type ReportRequest struct {
+	Reason string `json:"reason" binding:"required,oneof=spam abuse illegal other"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+// ReportActionRequest 处理举报：remove 下架内容，dismiss 驳回举报
+type ReportActionRequest struct {
+	Action string `json:"action" binding:"required,oneof=remove dismiss"`
+	Reason string `json:"reason" binding:"max=255"` // 下架原因，展示给作者，不填时使用举报原因
+}
+
+// CreateSensitiveWordsRequest 批量添加敏感词，已存在的词语更新处理方式
+type CreateSensitiveWordsRequest struct {
+	Words  []string `json:"words" binding:"required,min=1,max=1000,dive,max=100"`
+	Action string   `json:"action" binding:"required,oneof=reject mask review"` // reject 禁止发布，mask 替换为 *，review 人工审核
+}
+
+// UpdateSensitiveWordRequest 修改敏感词的处理方式
+type UpdateSensitiveWordRequest struct {
+	Action string `json:"action" binding:"required,oneof=reject mask review"`
+}
+
+// CreateSanctionRequest 处罚用户或 IP，禁言和封禁账号时用 user_id 或 username 指定用户
+type CreateSanctionRequest struct {
+	Type          string `json:"type" binding:"required,oneof=mute ban ip_ban"`
+	UserID        uint   `json:"user_id"`
+	Username      string `json:"username" binding:"max=100"`
+	IPAddress     string `json:"ip_address" binding:"max=45"` // 仅 ip_ban 使用
+	Reason        string `json:"reason" binding:"required,max=255"`
+	DurationHours int    `json:"duration_hours" binding:"min=0,max=87600"` // 0 表示永久
+}
+
+// AdminBulkRequest 管理后台的批量操作，可用的 action 因对象而不同：
+// delete 软删除，restore 恢复，move 修改文章分类，set_status 修改状态
+type AdminBulkRequest struct {
+	IDs        []uint `json:"ids" binding:"required,min=1,max=100"`
+	Action     string `json:"action" binding:"required,oneof=delete restore move set_status"`
+	CategoryID uint   `json:"category_id"`                                 // move 时使用
+	StatusCode int    `json:"status_code" binding:"omitempty,min=1,max=3"` // set_status 时使用：1 正常 2 禁用 3 待审核
+	Reason     string `json:"reason" binding:"max=255"`                    // set_status 时展示给作者的原因
+}
+
+// CategoryRequest 创建或修改分类，修改时整体替换所有可编辑的字段
+type CategoryRequest struct {
+	Name          string `json:"name" binding:"required,max=40"`
+	Alias         string `json:"alias" binding:"required,max=40"` // 用于 /categories/:alias，只能包含小写字母、数字和连字符
+	ParentID      uint   `json:"parent_id"`                       // 0 表示顶级分类，上级分类必须是顶级分类
+	Description   string `json:"description" binding:"max=255"`
+	Icon          string `json:"icon" binding:"max=32"`
+	Color         string `json:"color" binding:"omitempty,hexcolor"`
+	SortOrder     int    `json:"sort_order"`
+	MinPostLevel  int    `json:"min_post_level" binding:"min=0,max=100"` // 0 表示不限
+	IsRecommended bool   `json:"is_recommended"`
+	RecommendRank int    `json:"recommend_rank"`
+	StatusCode    int    `json:"status_code" binding:"omitempty,oneof=1 2"` // 默认 1 正常
+}
+
+// SortCategoriesRequest 按 ids 的顺序重新设置分类的排序
+type SortCategoriesRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=500"`
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginSanction 返回阻止登录的处罚：账号被封禁或当前 IP 被封禁，没有时返回 nil。
+// 密码登录和第三方登录在建立会话之前调用，把处罚说明展示给用户。
+func LoginSanction(c *gin.Context, user *models.User) *models.UserSanction {
+	var sanction models.UserSanction
+	err := database.DB.Scopes(policies.ActiveSanctions()).
+		Where("(type = ? AND user_id = ?) OR (type = ? AND ip_address = ?)",
+			models.SanctionBan, user.ID, models.SanctionIPBan, c.ClientIP()).
+		Order("expires_at IS NULL DESC, expires_at DESC").
+		First(&sanction).Error
+	if err != nil {
+		return nil
+	}
+	return &sanction
+}
+
+// ListSanctions 处罚列表，默认只列出生效中的处罚，status=all 时包含已到期和已撤销的记录
+func ListSanctions(c *gin.Context) {
+	page, perPage := responses.PageParams(c, 20)
+	query := database.DB.Model(&models.UserSanction{})
+	if c.Query("status") != "all" {
+		query = query.Scopes(policies.ActiveSanctions())
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var total int64
+	query.Count(&total)
+
+	var sanctions []models.UserSanction
+	if err := query.Preload("User").Preload("Moderator").Order("created_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&sanctions).Error; err != nil {
+		responses.Internal(c, "获取处罚列表失败")
+		return
+	}
+	responses.List(c, serializers.NewSanctions(sanctions), responses.NewMeta(page, perPage, total))
+}
+
+// CreateSanction 禁言、封禁用户或封禁 IP，duration_hours 为0表示永久
+func CreateSanction(c *gin.Context) {
+	var requestData CreateSanctionRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	reason := strings.TrimSpace(requestData.Reason)
+	if reason == "" {
+		responses.BadRequest(c, "请填写处罚原因")
+		return
+	}
+
+	actor := UserFromContext(c)
+	now := time.Now()
+	sanction := models.UserSanction{
+		Type:      requestData.Type,
+		Reason:    reason,
+		StartsAt:  now,
+		CreatedBy: actor.ID,
+	}
+	if requestData.DurationHours > 0 {
+		expiresAt := now.Add(time.Duration(requestData.DurationHours) * time.Hour)
+		sanction.ExpiresAt = &expiresAt
+	}
+
+	if requestData.Type == models.SanctionIPBan {
+		ip := net.ParseIP(strings.TrimSpace(requestData.IPAddress))
+		if ip == nil {
+			responses.BadRequest(c, "请填写有效的 IP 地址")
+			return
+		}
+		if ip.String() == c.ClientIP() {
+			responses.BadRequest(c, "不能封禁自己当前使用的 IP")
+			return
+		}
+		sanction.IPAddress = ip.String()
+	} else {
+		var target models.User
+		query := database.DB
+		if requestData.UserID > 0 {
+			query = query.Where("id = ?", requestData.UserID)
+		} else if name := strings.TrimSpace(requestData.Username); name != "" {
+			query = query.Where("name = ?", name)
+		} else {
+			responses.BadRequest(c, "请指定要处罚的用户")
+			return
+		}
+		if err := query.First(&target).Error; err != nil {
+			responses.NotFound(c, "用户不存在")
+			return
+		}
+		if !policies.CanSanction(actor, &target) {
+			responses.Forbidden(c, "无权处罚该用户")
+			return
+		}
+		sanction.UserID = target.ID
+		sanction.User = target
+	}
+
+	if err := database.DB.Omit("User", "Moderator").Create(&sanction).Error; err != nil {
+		responses.Internal(c, "处罚失败")
+		return
+	}
+	sanction.Moderator = *actor
+	recordAudit(c, auditEntry{
+		Action:      models.AuditSanctionCreate,
+		TargetType:  models.AuditTargetSanction,
+		TargetID:    sanction.ID,
+		TargetLabel: sanctionAuditLabel(&sanction),
+		After:       sanctionAuditValues(&sanction),
+	})
+	responses.Created(c, "已"+sanction.TypeLabel(), serializers.NewSanction(&sanction))
+}
+
+// RevokeSanction 提前解除处罚
+func RevokeSanction(c *gin.Context) {
+	var sanction models.UserSanction
+	if err := database.DB.First(&sanction, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "处罚记录不存在")
+		return
+	}
+	if !sanction.IsActive(time.Now()) {
+		responses.BadRequest(c, "该处罚已经失效")
+		return
+	}
+
+	before := sanctionAuditValues(&sanction)
+	now := time.Now()
+	err := database.DB.Model(&sanction).Updates(map[string]interface{}{
+		"revoked_at": now,
+		"revoked_by": UserFromContext(c).ID,
+	}).Error
+	if err != nil {
+		responses.Internal(c, "解除处罚失败")
+		return
+	}
+	database.DB.Preload("User").First(&sanction, sanction.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditSanctionRevoke,
+		TargetType:  models.AuditTargetSanction,
+		TargetID:    sanction.ID,
+		TargetLabel: sanctionAuditLabel(&sanction),
+		Before:      before,
+		After:       sanctionAuditValues(&sanction),
+	})
+	responses.OK(c, "已解除"+sanction.TypeLabel(), nil)
+}
+
+// activeSanctionsForPage 审核页面展示的生效中的处罚
+func activeSanctionsForPage(limit int) []models.UserSanction {
+	var sanctions []models.UserSanction
+	database.DB.Scopes(policies.ActiveSanctions()).
+		Preload("User").Preload("Moderator").
+		Order("created_at DESC").Limit(limit).
+		Find(&sanctions)
+	return sanctions
+}
+
+// sanctionAuditLabel 审计日志中处罚的说明：处罚类型和被处罚的用户或 IP
+func sanctionAuditLabel(sanction *models.UserSanction) string {
+	if sanction.Type == models.SanctionIPBan {
+		return sanction.TypeLabel() + " " + sanction.IPAddress
+	}
+	return sanction.TypeLabel() + " " + sanction.User.Name
+}
+
+// SanctionFailed 登录时遇到处罚，返回 403 和处罚说明
+func SanctionFailed(c *gin.Context, sanction *models.UserSanction) {
+	code := responses.CodeAccountBanned
+	if sanction.Type == models.SanctionIPBan {
+		code = responses.CodeIPBanned
+	}
+	responses.Error(c, http.StatusForbidden, code, sanction.Notice())
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/search"
+	"gin-doniai/serializers"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 搜索：公开的文章连同公开的评论写入搜索索引，文章或评论修改后调用 reindexPosts 更新对应文章的索引，
+// 服务启动时由 RebuildSearchIndex 建立全部索引。
+
+// 搜索结果每页显示的数量
+const searchPageSize = 10
+
+// 搜索词的最大字数
+const searchMaxQuery = 100
+
+// 重建索引时每批处理的文章数
+const searchIndexBatch = 200
+
+// postSearchHit 一条搜索结果：文章和带高亮的标题、摘要
+type postSearchHit struct {
+	models.Post
+	Hit     search.Hit
+	TimeAgo string
+}
+
+// SearchPage 文章搜索页面
+func SearchPage(c *gin.Context) {
+	user := UserFromContext(c)
+	hits, meta, err := searchPosts(c, user)
+	if err != nil {
+		fmt.Printf("搜索失败: %v\n", err)
+	}
+	for i := range hits {
+		hits[i].TimeAgo = utils.GetTimeAgo(hits[i].CreatedAt)
+	}
+
+	// 翻页链接保留搜索条件
+	query := c.Request.URL.Query()
+	query.Del("page")
+	pageQuery := ""
+	if len(query) > 0 {
+		pageQuery = query.Encode() + "&"
+	}
+
+	var categories []models.Category
+	database.DB.Scopes(policies.OrderedCategories()).Where("status_code = ?", models.StatusNormal).Find(&categories)
+
+	stats := CachedSiteStats()
+	responses.HTML(c, http.StatusOK, "search.tmpl", gin.H{
+		"user":         user,
+		"hits":         hits,
+		"total":        meta.Total,
+		"keyword":      strings.TrimSpace(c.Query("q")),
+		"filter":       searchFilter(c),
+		"categoryTree": CategoryTree(),
+		"categories":   categories,
+		"currentPage":  meta.Page,
+		"totalPages":   meta.TotalPages,
+		"hasPrev":      meta.Page > 1,
+		"hasNext":      meta.Page < meta.TotalPages,
+		"prevPage":     meta.Page - 1,
+		"nextPage":     meta.Page + 1,
+		"pageQuery":    template.URL(pageQuery),
+		"userCount":    stats.Users,
+		"postCount":    stats.Posts,
+		"commentCount": stats.Comments,
+		"onlineCount":  stats.Online,
+	})
+}
+
+// SearchPosts 搜索文章，返回带高亮标题和摘要的文章列表
+func SearchPosts(c *gin.Context) {
+	hits, meta, err := searchPosts(c, UserFromContext(c))
+	if err != nil {
+		responses.Internal(c, "搜索失败")
+		return
+	}
+	results := make([]serializers.SearchResult, 0, len(hits))
+	for i := range hits {
+		results = append(results, serializers.NewSearchResult(&hits[i].Post, hits[i].Hit))
+	}
+	responses.List(c, results, meta)
+}
+
+// searchFilter 页面上回显的筛选条件
+func searchFilter(c *gin.Context) map[string]string {
+	filter := map[string]string{}
+	for _, key := range []string{"q", "category_id", "tag", "author", "from", "to", "sort"} {
+		filter[key] = strings.TrimSpace(c.Query(key))
+	}
+	return filter
+}
+
+// searchPosts 按请求参数搜索文章：q 搜索词，category_id 分类（包含子分类），tag 标签，author 作者用户名，
+// from、to 发布日期范围（包含当天），sort 为 newest 时按发布时间排序，默认按相关度
+func searchPosts(c *gin.Context, user *models.User) ([]postSearchHit, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, searchPageSize)
+	empty := responses.NewMeta(page, perPage, 0)
+
+	query := search.Query{
+		Text:   excerpt(c.Query("q"), searchMaxQuery),
+		Tag:    strings.TrimSpace(c.Query("tag")),
+		Sort:   c.Query("sort"),
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	}
+	query.ReadLimit, query.OwnerID = policies.SearchVisibility(user)
+	if id, err := strconv.Atoi(c.Query("category_id")); err == nil && id > 0 {
+		var category models.Category
+		if err := database.DB.First(&category, id).Error; err != nil {
+			return nil, empty, nil
+		}
+		query.CategoryIDs = CategoryAndChildren(&category)
+	}
+	if name := strings.TrimSpace(c.Query("author")); name != "" {
+		var author models.User
+		if err := database.DB.Where("name = ?", name).First(&author).Error; err != nil {
+			return nil, empty, nil
+		}
+		query.UserID = author.ID
+	}
+	if from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local); err == nil {
+		query.From = from
+	}
+	if to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local); err == nil {
+		query.To = to.AddDate(0, 0, 1)
+	}
+
+	result, err := search.Search(query)
+	if err != nil {
+		return nil, empty, err
+	}
+
+	// 按搜索结果的顺序取出文章，索引中的文章已不可见时跳过
+	ids := make([]uint, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	var posts []models.Post
+	if len(ids) > 0 {
+		database.DB.Scopes(policies.VisiblePosts(user)).Where("id IN ?", ids).Find(&posts)
+	}
+	hits := make([]postSearchHit, 0, len(posts))
+	var stale []uint
+	for _, hit := range result.Hits {
+		if i := slices.IndexFunc(posts, func(p models.Post) bool { return p.ID == hit.ID }); i >= 0 {
+			hits = append(hits, postSearchHit{Post: posts[i], Hit: hit})
+		} else {
+			stale = append(stale, hit.ID)
+		}
+	}
+	// 索引与数据库不一致时（如更新索引失败），总数扣除跳过的文章并重新索引这些文章，
+	// 避免分页显示的总数与实际结果不符
+	if len(stale) > 0 {
+		reindexPosts(stale...)
+	}
+	return hits, responses.NewMeta(page, perPage, int64(result.Total-len(stale))), nil
+}
+
+// searchDocument 文章的索引内容，comments 为文章的公开评论
+func searchDocument(post *models.Post, comments []models.Comment) search.Document {
+	doc := search.Document{
+		ID:         post.ID,
+		Title:      post.Title,
+		Content:    utils.PlainText(post.Content),
+		Tags:       utils.ParseTags(post.Tags),
+		CategoryID: uint(post.CategoryId),
+		UserID:     uint(post.UserId),
+		ReadLimit:  post.ReadLimit,
+		CreatedAt:  post.CreatedAt,
+	}
+	for _, comment := range comments {
+		doc.Comments = append(doc.Comments, utils.PlainText(comment.Content))
+	}
+	return doc
+}
+
+// indexPosts 写入一批公开文章的索引，返回已写入的文章ID
+func indexPosts(posts []models.Post) map[uint]bool {
+	indexed := make(map[uint]bool, len(posts))
+	if len(posts) == 0 {
+		return indexed
+	}
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	var comments []models.Comment
+	database.DB.Scopes(policies.VisibleComments()).Where("post_id IN ?", ids).Order("id ASC").Find(&comments)
+	byPost := make(map[uint][]models.Comment, len(posts))
+	for _, comment := range comments {
+		byPost[comment.PostID] = append(byPost[comment.PostID], comment)
+	}
+
+	for i := range posts {
+		if err := search.Index(searchDocument(&posts[i], byPost[posts[i].ID])); err != nil {
+			fmt.Printf("更新搜索索引失败: %v\n", err)
+			continue
+		}
+		indexed[posts[i].ID] = true
+	}
+	return indexed
+}
+
+// reindexPosts 重新索引文章：公开的文章连同公开的评论写入索引，已删除或未公开的文章从索引中移除。
+// 修改文章或评论的事务提交后调用，评论修改时传入所属文章的ID
+func reindexPosts(ids ...uint) {
+	if len(ids) == 0 {
+		return
+	}
+	var posts []models.Post
+	database.DB.Where("id IN ? AND status_code = ?", ids, models.StatusNormal).Find(&posts)
+	indexed := indexPosts(posts)
+	for _, id := range ids {
+		if indexed[id] {
+			continue
+		}
+		if err := search.Delete(id); err != nil {
+			fmt.Printf("更新搜索索引失败: %v\n", err)
+		}
+	}
+}
+
+// reindexCommentPosts 重新索引评论所属的文章
+func reindexCommentPosts(comments ...models.Comment) {
+	ids := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		if !slices.Contains(ids, comment.PostID) {
+			ids = append(ids, comment.PostID)
+		}
+	}
+	reindexPosts(ids...)
+}
+
+// reindexReportTarget 重新索引被举报的文章或评论所属的文章
+func reindexReportTarget(targetType string, targetID uint) {
+	switch targetType {
+	case models.ReportTargetPost:
+		reindexPosts(targetID)
+	case models.ReportTargetComment:
+		var comment models.Comment
+		if err := database.DB.Unscoped().First(&comment, targetID).Error; err == nil {
+			reindexPosts(comment.PostID)
+		}
+	}
+}
+
+// RebuildSearchIndex 为全部公开的文章建立搜索索引，服务启动时调用
+func RebuildSearchIndex() error {
+	var posts []models.Post
+	return database.DB.Where("status_code = ?", models.StatusNormal).FindInBatches(&posts, searchIndexBatch, func(tx *gorm.DB, batch int) error {
+		indexPosts(posts)
+		return nil
+	}).Error
+}
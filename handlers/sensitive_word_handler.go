@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+	"gin-doniai/wordfilter"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// ReloadSensitiveWords 从数据库重新加载敏感词，启动时、规则修改后和定时任务中调用
+func ReloadSensitiveWords() error {
+	var words []models.SensitiveWord
+	if err := database.DB.Find(&words).Error; err != nil {
+		return err
+	}
+	rules := make([]wordfilter.Rule, 0, len(words))
+	for _, word := range words {
+		rules = append(rules, word.Rule())
+	}
+	wordfilter.Load(rules)
+	return nil
+}
+
+// filterWords 按敏感词规则处理用户提交的文本：命中禁止发布的词语时返回 400，
+// 需要屏蔽的词语原地替换为 *。需要审核的词语由 policies.ReviewReason 按屏蔽前的原文判断。
+func filterWords(c *gin.Context, texts ...*string) bool {
+	for _, text := range texts {
+		result := wordfilter.Check(*text)
+		if len(result.Reject) > 0 {
+			responses.Error(c, http.StatusBadRequest, responses.CodeContentRejected,
+				"内容包含不允许发布的词语："+strings.Join(result.Reject, "、"))
+			return false
+		}
+		*text = result.Text
+	}
+	return true
+}
+
+// SensitiveWordsPage 敏感词管理页面
+func SensitiveWordsPage(c *gin.Context) {
+	user := UserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login?redirect_to=/admin/sensitive-words")
+		return
+	}
+	if !user.Can(models.PermManageWords) {
+		responses.HTML(c, http.StatusForbidden, "403.tmpl", gin.H{"Message": "没有管理敏感词的权限", "user": user})
+		return
+	}
+
+	page, perPage := responses.PageParams(c, 100)
+	keyword := strings.TrimSpace(c.Query("q"))
+	words, total, _ := querySensitiveWords(keyword, page, perPage)
+	meta := responses.NewMeta(page, perPage, total)
+	responses.HTML(c, http.StatusOK, "sensitive-words.tmpl", gin.H{
+		"user":     user,
+		"section":  "sensitive-words",
+		"words":    words,
+		"keyword":  keyword,
+		"meta":     meta,
+		"prevPage": page - 1,
+		"nextPage": page + 1,
+		"hasNext":  page < meta.TotalPages,
+	})
+}
+
+// GetSensitiveWords 敏感词列表，q 按词语模糊搜索
+func GetSensitiveWords(c *gin.Context) {
+	page, perPage := responses.PageParams(c, 100)
+	words, total, err := querySensitiveWords(strings.TrimSpace(c.Query("q")), page, perPage)
+	if err != nil {
+		responses.Internal(c, "获取敏感词失败")
+		return
+	}
+	responses.List(c, serializers.NewSensitiveWords(words), responses.NewMeta(page, perPage, total))
+}
+
+func querySensitiveWords(keyword string, page, perPage int) ([]models.SensitiveWord, int64, error) {
+	query := database.DB.Model(&models.SensitiveWord{})
+	if keyword != "" {
+		query = query.Where("word LIKE ?", "%"+keyword+"%")
+	}
+	var total int64
+	query.Count(&total)
+	var words []models.SensitiveWord
+	err := query.Order("id DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&words).Error
+	return words, total, err
+}
+
+// CreateSensitiveWords 批量添加敏感词，已存在的词语更新处理方式
+func CreateSensitiveWords(c *gin.Context) {
+	var requestData CreateSensitiveWordsRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	user := UserFromContext(c)
+	seen := map[string]bool{}
+	var words []models.SensitiveWord
+	for _, word := range requestData.Words {
+		word = strings.TrimSpace(word)
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, models.SensitiveWord{Word: word, Action: requestData.Action, CreatedBy: user.ID})
+	}
+	if len(words) == 0 {
+		responses.BadRequest(c, "请至少填写一个词语")
+		return
+	}
+
+	texts := make([]string, 0, len(words))
+	for _, word := range words {
+		texts = append(texts, word.Word)
+	}
+	before := wordAuditSnapshots(texts)
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "word"}},
+		DoUpdates: clause.AssignmentColumns([]string{"action", "updated_at"}),
+	}).Create(&words).Error
+	if err != nil {
+		responses.Internal(c, "添加敏感词失败")
+		return
+	}
+	auditBulk(c, models.AuditWordCreate, models.AuditTargetSensitiveWord, before, wordAuditSnapshots(texts))
+	if !reloadSensitiveWords(c) {
+		return
+	}
+	// 批量写入时已存在的词语拿不到准确的ID，只返回数量
+	responses.Created(c, fmt.Sprintf("已保存 %d 个敏感词", len(words)), gin.H{"saved": len(words)})
+}
+
+// UpdateSensitiveWord 修改敏感词的处理方式
+func UpdateSensitiveWord(c *gin.Context) {
+	var requestData UpdateSensitiveWordRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	var word models.SensitiveWord
+	if err := database.DB.First(&word, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "敏感词不存在")
+		return
+	}
+	before := wordAuditValues(&word)
+	if err := database.DB.Model(&word).Update("action", requestData.Action).Error; err != nil {
+		responses.Internal(c, "修改敏感词失败")
+		return
+	}
+	recordAudit(c, auditEntry{
+		Action:      models.AuditWordUpdate,
+		TargetType:  models.AuditTargetSensitiveWord,
+		TargetID:    word.ID,
+		TargetLabel: word.Word,
+		Before:      before,
+		After:       wordAuditValues(&word),
+	})
+	if !reloadSensitiveWords(c) {
+		return
+	}
+	responses.OK(c, "敏感词已更新", serializers.NewSensitiveWord(&word))
+}
+
+// DeleteSensitiveWord 删除敏感词
+func DeleteSensitiveWord(c *gin.Context) {
+	var word models.SensitiveWord
+	if err := database.DB.First(&word, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "敏感词不存在")
+		return
+	}
+	if err := database.DB.Delete(&word).Error; err != nil {
+		responses.Internal(c, "删除敏感词失败")
+		return
+	}
+	recordAudit(c, auditEntry{
+		Action:      models.AuditWordDelete,
+		TargetType:  models.AuditTargetSensitiveWord,
+		TargetID:    word.ID,
+		TargetLabel: word.Word,
+		Before:      wordAuditValues(&word),
+	})
+	if !reloadSensitiveWords(c) {
+		return
+	}
+	responses.OK(c, "敏感词已删除", nil)
+}
+
+// reloadSensitiveWords 规则修改后立即生效，其他实例由定时任务重新加载
+func reloadSensitiveWords(c *gin.Context) bool {
+	if err := ReloadSensitiveWords(); err != nil {
+		responses.Internal(c, "敏感词已保存，但重新加载失败")
+		return false
+	}
+	return true
+}
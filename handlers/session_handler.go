@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSessions 当前用户已登录的设备列表
+func GetSessions(c *gin.Context) {
+	user := UserFromContext(c)
+
+	list, err := ListUserSessions(user.ID)
+	if err != nil {
+		responses.Internal(c, "获取登录设备失败")
+		return
+	}
+	responses.OK(c, "", serializers.NewSessions(list, sessions.Default(c).ID()))
+}
+
+// DeleteSession 让指定设备下线，撤销当前会话等同于退出登录
+func DeleteSession(c *gin.Context) {
+	user := UserFromContext(c)
+
+	result := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).Delete(&models.UserSession{})
+	if result.Error != nil {
+		responses.Internal(c, "操作失败")
+		return
+	}
+	if result.RowsAffected == 0 {
+		responses.NotFound(c, "会话不存在")
+		return
+	}
+	responses.OK(c, "设备已下线", nil)
+}
+
+// DeleteOtherSessions 让当前设备以外的所有设备下线
+func DeleteOtherSessions(c *gin.Context) {
+	user := UserFromContext(c)
+
+	if err := RevokeUserSessions(user.ID, sessions.Default(c).ID()); err != nil {
+		responses.Internal(c, "操作失败")
+		return
+	}
+	responses.OK(c, "其他设备已全部下线", nil)
+}
+
+// ListUserSessions 查询用户未过期的会话，最近活跃的在前
+func ListUserSessions(userID uint) ([]models.UserSession, error) {
+	var list []models.UserSession
+	err := database.DB.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_active_at DESC").Find(&list).Error
+	return list, err
+}
+
+// RevokeUserSessions 删除用户的全部会话，exceptKey 不为空时保留该会话
+func RevokeUserSessions(userID uint, exceptKey string) error {
+	query := database.DB.Where("user_id = ?", userID)
+	if exceptKey != "" {
+		query = query.Where("session_key <> ?", exceptKey)
+	}
+	return query.Delete(&models.UserSession{}).Error
+}
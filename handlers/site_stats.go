@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+)
+
+// SiteStats 站点统计，首页、搜索页和管理后台共用
+type SiteStats struct {
+	Users           int64     `json:"users"`
+	Posts           int64     `json:"posts"`    // 公开的文章
+	Comments        int64     `json:"comments"` // 公开的评论
+	Online          int64     `json:"online"`   // 30分钟内活跃的用户
+	Categories      int64     `json:"categories"`
+	NewUsersToday   int64     `json:"new_users_today"`
+	NewPostsToday   int64     `json:"new_posts_today"`
+	PendingPosts    int64     `json:"pending_posts"`
+	PendingComments int64     `json:"pending_comments"`
+	OpenReports     int64     `json:"open_reports"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// 统计结果缓存1分钟，避免每次打开首页都执行一遍 COUNT
+// （放在 handlers 而不是 caches 中，因为管理后台的接口也要用到，caches 依赖 handlers）
+var (
+	siteStats         SiteStats
+	siteStatsMutex    sync.RWMutex
+	siteStatsExpiry   time.Time
+	siteStatsDuration = time.Minute
+)
+
+// CachedSiteStats 获取缓存的站点统计，过期后重新统计
+func CachedSiteStats() SiteStats {
+	siteStatsMutex.RLock()
+	if time.Now().Before(siteStatsExpiry) {
+		defer siteStatsMutex.RUnlock()
+		return siteStats
+	}
+	siteStatsMutex.RUnlock()
+
+	siteStatsMutex.Lock()
+	defer siteStatsMutex.Unlock()
+
+	// 双重检查，防止并发情况下重复统计
+	if time.Now().Before(siteStatsExpiry) {
+		return siteStats
+	}
+	siteStats = countSiteStats()
+	siteStatsExpiry = time.Now().Add(siteStatsDuration)
+	return siteStats
+}
+
+// InvalidateSiteStats 批量修改内容后清除缓存，下次访问时重新统计
+func InvalidateSiteStats() {
+	siteStatsMutex.Lock()
+	siteStatsExpiry = time.Time{}
+	siteStatsMutex.Unlock()
+}
+
+func countSiteStats() SiteStats {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var stats SiteStats
+	database.DB.Model(&models.User{}).Count(&stats.Users)
+	database.DB.Model(&models.Post{}).Where("status_code = ?", models.StatusNormal).Count(&stats.Posts)
+	database.DB.Model(&models.Comment{}).Scopes(policies.VisibleComments()).Count(&stats.Comments)
+	database.DB.Model(&models.UserOnlineStatus{}).
+		Where("last_active_time > ?", now.Add(-30*time.Minute)).
+		Count(&stats.Online)
+	database.DB.Model(&models.Category{}).Where("status_code = ?", models.StatusNormal).Count(&stats.Categories)
+	database.DB.Model(&models.User{}).Where("created_at >= ?", today).Count(&stats.NewUsersToday)
+	database.DB.Model(&models.Post{}).Where("created_at >= ?", today).Count(&stats.NewPostsToday)
+	database.DB.Model(&models.Post{}).Where("status_code = ?", models.StatusPending).Count(&stats.PendingPosts)
+	database.DB.Model(&models.Comment{}).Where("status_code = ?", models.StatusPending).Count(&stats.PendingComments)
+	database.DB.Model(&models.Report{}).Where("status = ?", models.ReportOpen).Count(&stats.OpenReports)
+	stats.UpdatedAt = now
+	return stats
+}
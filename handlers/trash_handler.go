@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 回收站：软删除的文章、评论和用户。作者可以查看和恢复自己删除的文章、评论，
+// 版主可以查看和恢复全部内容，管理员还可以恢复用户。超过保留天数的内容由定时任务永久删除。
+
+// 回收站每页显示的数量
+const trashPageSize = 20
+
+// 定时清理时每批处理的记录数
+const trashPurgeBatch = 200
+
+// TrashPage 回收站页面，type 为 posts（默认）、comments 或 users
+func TrashPage(c *gin.Context) {
+	user := UserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login?redirect_to=/trash")
+		return
+	}
+
+	var data gin.H
+	switch c.Query("type") {
+	case "comments":
+		comments, meta, _ := queryTrashedComments(c, user)
+		data = adminPageData(c, user, "comments", meta, "q")
+		deletedBy := make([]uint, 0, len(comments))
+		for _, comment := range comments {
+			deletedBy = append(deletedBy, comment.DeletedBy)
+		}
+		data["comments"] = comments
+		data["postTitles"] = postTitles(comments)
+		data["deleters"] = userNames(deletedBy)
+		data["canManage"] = user.Can(models.PermManageComments)
+	case "users":
+		if !user.Can(models.PermManageUsers) {
+			responses.HTML(c, http.StatusForbidden, "403.tmpl", gin.H{"Message": "没有管理用户的权限", "user": user})
+			return
+		}
+		users, meta, _ := queryTrashedUsers(c)
+		data = adminPageData(c, user, "users", meta, "q")
+		data["users"] = users
+	default:
+		posts, meta, _ := queryTrashedPosts(c, user)
+		data = adminPageData(c, user, "posts", meta, "q")
+		deletedBy := make([]uint, 0, len(posts))
+		for _, post := range posts {
+			deletedBy = append(deletedBy, post.DeletedBy)
+		}
+		data["posts"] = posts
+		data["deleters"] = userNames(deletedBy)
+		data["canManage"] = user.Can(models.PermManagePosts)
+	}
+	data["retentionDays"] = policies.TrashRetentionDays()
+	responses.HTML(c, http.StatusOK, "trash.tmpl", data)
+}
+
+// userNames 按 ID 查询用户名，包括已删除的用户，用于显示删除人
+func userNames(ids []uint) map[uint]string {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names
+	}
+	var users []models.User
+	database.DB.Unscoped().Select("id", "name").Where("id IN ?", ids).Find(&users)
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+	return names
+}
+
+// ListTrashedPosts 回收站中的文章，没有文章管理权限时只列出自己的文章，q 按标题搜索
+func ListTrashedPosts(c *gin.Context) {
+	posts, meta, err := queryTrashedPosts(c, UserFromContext(c))
+	if err != nil {
+		responses.Internal(c, "获取回收站失败")
+		return
+	}
+	responses.List(c, serializers.NewPosts(posts), meta)
+}
+
+// ListTrashedComments 回收站中的评论，没有评论管理权限时只列出自己的评论，q 按内容搜索
+func ListTrashedComments(c *gin.Context) {
+	comments, meta, err := queryTrashedComments(c, UserFromContext(c))
+	if err != nil {
+		responses.Internal(c, "获取回收站失败")
+		return
+	}
+	responses.List(c, serializers.NewComments(comments), meta)
+}
+
+// ListTrashedUsers 已删除的用户，q 按用户名或邮箱搜索
+func ListTrashedUsers(c *gin.Context) {
+	users, meta, err := queryTrashedUsers(c)
+	if err != nil {
+		responses.Internal(c, "获取回收站失败")
+		return
+	}
+	responses.List(c, serializers.UsersFor(UserFromContext(c), AccessTokenFromContext(c), users), meta)
+}
+
+func queryTrashedPosts(c *gin.Context, user *models.User) ([]models.Post, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, trashPageSize)
+	query := database.DB.Unscoped().Model(&models.Post{}).Where("deleted_at IS NOT NULL")
+	if !user.Can(models.PermManagePosts) {
+		query = query.Where("user_id = ?", user.ID)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("title LIKE ?", "%"+q+"%")
+	}
+	var total int64
+	query.Count(&total)
+
+	var posts []models.Post
+	err := query.Preload("User").Order("deleted_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&posts).Error
+	return posts, responses.NewMeta(page, perPage, total), err
+}
+
+func queryTrashedComments(c *gin.Context, user *models.User) ([]models.Comment, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, trashPageSize)
+	query := database.DB.Unscoped().Model(&models.Comment{}).Where("deleted_at IS NOT NULL")
+	if !user.Can(models.PermManageComments) {
+		query = query.Where("user_id = ?", user.ID)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("markdown LIKE ?", "%"+q+"%")
+	}
+	var total int64
+	query.Count(&total)
+
+	var comments []models.Comment
+	err := query.Preload("User").Order("deleted_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&comments).Error
+	return comments, responses.NewMeta(page, perPage, total), err
+}
+
+func queryTrashedUsers(c *gin.Context) ([]models.User, *responses.Meta, error) {
+	page, perPage := responses.PageParams(c, trashPageSize)
+	query := database.DB.Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL")
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("name LIKE ? OR email LIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+	var total int64
+	query.Count(&total)
+
+	var users []models.User
+	err := query.Order("deleted_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&users).Error
+	return users, responses.NewMeta(page, perPage, total), err
+}
+
+// RestorePost 从回收站恢复文章，并按现有的公开评论重新计算回复数
+func RestorePost(c *gin.Context) {
+	var post models.Post
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&post, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "回收站中没有这篇文章")
+		return
+	}
+
+	user := UserFromContext(c)
+	if !policies.CanRestorePost(user, &post) {
+		responses.Forbidden(c, "无权限恢复此文章")
+		return
+	}
+
+	before := postAuditValues(&post)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := restoreDeleted(tx, &models.Post{}, []uint{post.ID}, true).Error; err != nil {
+			return err
+		}
+		return recountReplies(tx, []uint{post.ID})
+	})
+	if err != nil {
+		responses.Internal(c, "恢复文章失败")
+		return
+	}
+	database.DB.First(&post, post.ID)
+	reindexPosts(post.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditPostRestore,
+		TargetType:  models.AuditTargetPost,
+		TargetID:    post.ID,
+		TargetLabel: post.Title,
+		Before:      before,
+		After:       postAuditValues(&post),
+	})
+
+	responses.OK(c, "文章已恢复", serializers.NewPost(&post))
+}
+
+// RestoreComment 从回收站恢复评论，公开的评论重新计入文章的回复数
+func RestoreComment(c *gin.Context) {
+	var comment models.Comment
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&comment, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "回收站中没有这条评论")
+		return
+	}
+
+	user := UserFromContext(c)
+	if !policies.CanRestoreComment(user, &comment) {
+		responses.Forbidden(c, "无权限恢复此评论")
+		return
+	}
+
+	before := commentAuditValues(&comment)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := restoreDeleted(tx, &models.Comment{}, []uint{comment.ID}, true).Error; err != nil {
+			return err
+		}
+		return adjustReplies(tx, []models.Comment{comment}, 1)
+	})
+	if err != nil {
+		responses.Internal(c, "恢复评论失败")
+		return
+	}
+	database.DB.First(&comment, comment.ID)
+	reindexPosts(comment.PostID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditCommentRestore,
+		TargetType:  models.AuditTargetComment,
+		TargetID:    comment.ID,
+		TargetLabel: excerpt(comment.Markdown, 100),
+		Before:      before,
+		After:       commentAuditValues(&comment),
+	})
+
+	responses.OK(c, "评论已恢复", serializers.NewComment(&comment))
+}
+
+// RestoreUser 恢复已删除的用户
+func RestoreUser(c *gin.Context) {
+	var user models.User
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&user, c.Param("id")).Error; err != nil {
+		responses.NotFound(c, "回收站中没有这个用户")
+		return
+	}
+
+	before := userAuditValues(&user)
+	if err := restoreDeleted(database.DB, &models.User{}, []uint{user.ID}, false).Error; err != nil {
+		responses.Internal(c, "恢复用户失败")
+		return
+	}
+	database.DB.First(&user, user.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditUserRestore,
+		TargetType:  models.AuditTargetUser,
+		TargetID:    user.ID,
+		TargetLabel: user.Name,
+		Before:      before,
+		After:       userAuditValues(&user),
+	})
+
+	responses.OK(c, "用户已恢复", serializers.UserFor(UserFromContext(c), AccessTokenFromContext(c), &user))
+}
+
+// trashContent 软删除文章或评论并记下删除人，作者只能从回收站恢复自己删除的内容
+func trashContent(tx *gorm.DB, model interface{}, ids []uint, user *models.User) *gorm.DB {
+	if result := tx.Model(model).Where("id IN ?", ids).UpdateColumn("deleted_by", user.ID); result.Error != nil {
+		return result
+	}
+	return tx.Where("id IN ?", ids).Delete(model)
+}
+
+// restoreDeleted 从回收站恢复软删除的记录，clearDeletedBy 为真时同时清除删除人（文章和评论）
+func restoreDeleted(tx *gorm.DB, model interface{}, ids []uint, clearDeletedBy bool) *gorm.DB {
+	updates := map[string]interface{}{"deleted_at": nil}
+	if clearDeletedBy {
+		updates["deleted_by"] = 0
+	}
+	return tx.Unscoped().Model(model).Where("id IN ? AND deleted_at IS NOT NULL", ids).Updates(updates)
+}
+
+// recountReplies 按公开的评论重新计算文章的回复数。文章在回收站期间删除或恢复评论不会更新回复数，恢复文章时需要重新计算
+func recountReplies(tx *gorm.DB, postIDs []uint) error {
+	replies := tx.Model(&models.Comment{}).Select("COUNT(*)").
+		Where("comments.post_id = posts.id AND comments.status_code = ?", models.StatusNormal)
+	return tx.Model(&models.Post{}).Where("id IN ?", postIDs).UpdateColumn("replies", replies).Error
+}
+
+// PurgeTrash 永久删除在回收站中超过保留天数的评论、文章和用户，由定时任务调用。
+// 文章的评论、点赞和收藏随文章一起删除；仍有文章或评论的用户暂不删除，等内容清理完后再删除
+func PurgeTrash() {
+	days := policies.TrashRetentionDays()
+	if days == 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	var comments []models.Comment
+	err := database.DB.Unscoped().Where("deleted_at < ?", cutoff).FindInBatches(&comments, trashPurgeBatch, func(tx *gorm.DB, batch int) error {
+		ids := make([]uint, 0, len(comments))
+		entries := make([]auditEntry, 0, len(comments))
+		for i := range comments {
+			ids = append(ids, comments[i].ID)
+			entries = append(entries, trashPurgeEntry(models.AuditTargetComment, comments[i].ID, excerpt(comments[i].Markdown, 100), commentAuditValues(&comments[i])))
+		}
+		if err := database.DB.Unscoped().Where("id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		recordSystemAudit(entries...)
+		return nil
+	}).Error
+	if err != nil {
+		fmt.Printf("清理回收站中的评论失败: %v\n", err)
+	}
+
+	var posts []models.Post
+	err = database.DB.Unscoped().Where("deleted_at < ?", cutoff).FindInBatches(&posts, trashPurgeBatch, func(tx *gorm.DB, batch int) error {
+		ids := make([]uint, 0, len(posts))
+		entries := make([]auditEntry, 0, len(posts))
+		for i := range posts {
+			ids = append(ids, posts[i].ID)
+			entries = append(entries, trashPurgeEntry(models.AuditTargetPost, posts[i].ID, posts[i].Title, postAuditValues(&posts[i])))
+		}
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&models.Comment{}, &models.PostLike{}, &models.PostFavorite{}} {
+				if err := tx.Unscoped().Where("post_id IN ?", ids).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Post{}).Error
+		})
+		if err != nil {
+			return err
+		}
+		recordSystemAudit(entries...)
+		return nil
+	}).Error
+	if err != nil {
+		fmt.Printf("清理回收站中的文章失败: %v\n", err)
+	}
+
+	var users []models.User
+	err = database.DB.Unscoped().Where("deleted_at < ?", cutoff).FindInBatches(&users, trashPurgeBatch, func(tx *gorm.DB, batch int) error {
+		entries := make([]auditEntry, 0, len(users))
+		for i := range users {
+			var content int64
+			database.DB.Unscoped().Model(&models.Post{}).Where("user_id = ?", users[i].ID).Count(&content)
+			if content == 0 {
+				database.DB.Unscoped().Model(&models.Comment{}).Where("user_id = ?", users[i].ID).Count(&content)
+			}
+			if content > 0 {
+				continue
+			}
+			if err := database.DB.Unscoped().Delete(&users[i]).Error; err != nil {
+				fmt.Printf("清理回收站中的用户 %d 失败: %v\n", users[i].ID, err)
+				continue
+			}
+			entries = append(entries, trashPurgeEntry(models.AuditTargetUser, users[i].ID, users[i].Name, userAuditValues(&users[i])))
+		}
+		recordSystemAudit(entries...)
+		return nil
+	}).Error
+	if err != nil {
+		fmt.Printf("清理回收站中的用户失败: %v\n", err)
+	}
+}
+
+func trashPurgeEntry(targetType string, id uint, label string, before auditValues) auditEntry {
+	return auditEntry{
+		Action:      models.AuditTrashPurge,
+		TargetType:  targetType,
+		TargetID:    id,
+		TargetLabel: label,
+		Before:      before,
+	}
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
+	"gin-doniai/utils"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	twoFactorIssuer       = "Doniai"
+	recoveryCodeCount     = 10
+	sessionTwoFactorSetup = "2fa_setup_secret" // 启用过程中尚未确认的密钥
+)
+
+// TwoFactorPage 登录第二步：输入身份验证器中的验证码
+func TwoFactorPage(c *gin.Context) {
+	if userID, _ := pendingLogin(sessions.Default(c)); userID == 0 {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+	responses.HTML(c, http.StatusOK, "two-factor.tmpl", gin.H{})
+}
+
+// TwoFactorSubmit 校验登录第二步的验证码或恢复码，通过后建立登录会话
+func TwoFactorSubmit(c *gin.Context) {
+	session := sessions.Default(c)
+	userID, remember := pendingLogin(session)
+	if userID == 0 {
+		responses.Unauthorized(c, "登录已过期，请重新登录")
+		return
+	}
+
+	var requestData TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请输入验证码")
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		clearPendingLogin(session)
+		session.Save()
+		responses.Unauthorized(c, "登录已过期，请重新登录")
+		return
+	}
+
+	// 第二步同样计入账号和IP的失败次数，防止反复重新登录来绕过单次5次的限制
+	accountKey := userAccountKey(user.ID)
+	if wait, ok := checkLoginAllowed(c, accountKey); !ok {
+		responses.TooManyRequests(c, wait, (&LoginThrottledError{Wait: wait}).Error())
+		return
+	}
+
+	if !VerifySecondFactor(&user, requestData.Code) {
+		recordLoginFailure(c, accountKey, user.Email, user.ID, models.LoginFailureTwoFactor)
+		attempts, _ := session.Get(sessionPendingAttempts).(int)
+		attempts++
+		if attempts >= maxTwoFactorAttempts {
+			clearPendingLogin(session)
+			session.Save()
+			responses.Unauthorized(c, "验证码错误次数过多，请重新登录")
+			return
+		}
+		session.Set(sessionPendingAttempts, attempts)
+		session.Save()
+		responses.Error(c, http.StatusUnauthorized, responses.CodeUnauthorized,
+			fmt.Sprintf("验证码错误，还可以尝试 %d 次", maxTwoFactorAttempts-attempts))
+		return
+	}
+
+	loginAccountLimiter.Reset(accountKey)
+	redirectTo := pendingRedirect(session)
+	if err := finishLogin(c, &user, remember); err != nil {
+		responses.Internal(c, "登录失败，请稍后重试")
+		return
+	}
+	responses.OK(c, "登录成功", gin.H{"redirect": redirectTo})
+}
+
+// SetupTwoFactor 生成新的两步验证密钥，需要再提交一次验证码确认后才会启用
+func SetupTwoFactor(c *gin.Context) {
+	user := UserFromContext(c)
+	if user.TwoFactorEnabled {
+		responses.BadRequest(c, "已启用两步验证")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		responses.Internal(c, "生成密钥失败")
+		return
+	}
+	uri := utils.TOTPProvisioningURI(twoFactorIssuer, user.Email, secret)
+	qrCode, err := utils.QRCodeDataURI(uri)
+	if err != nil {
+		responses.Internal(c, "生成二维码失败")
+		return
+	}
+
+	session := sessions.Default(c)
+	session.Set(sessionTwoFactorSetup, secret)
+	if err := session.Save(); err != nil {
+		responses.Internal(c, "保存会话失败")
+		return
+	}
+
+	responses.OK(c, "", serializers.TwoFactorSetup{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qrCode,
+	})
+}
+
+// EnableTwoFactor 用验证码确认密钥并启用两步验证，返回一次性恢复码
+func EnableTwoFactor(c *gin.Context) {
+	user := UserFromContext(c)
+	if user.TwoFactorEnabled {
+		responses.BadRequest(c, "已启用两步验证")
+		return
+	}
+
+	var requestData TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请输入验证码")
+		return
+	}
+
+	session := sessions.Default(c)
+	secret, _ := session.Get(sessionTwoFactorSetup).(string)
+	if secret == "" {
+		responses.BadRequest(c, "请先生成两步验证密钥")
+		return
+	}
+	counter, ok := utils.ValidateTOTP(secret, requestData.Code, time.Now())
+	if !ok {
+		responses.BadRequest(c, "验证码错误，请确认手机时间准确")
+		return
+	}
+
+	err := database.DB.Model(user).Updates(map[string]interface{}{
+		"two_factor_enabled":      true,
+		"two_factor_secret":       secret,
+		"two_factor_last_counter": counter,
+	}).Error
+	if err != nil {
+		responses.Internal(c, "启用两步验证失败")
+		return
+	}
+	session.Delete(sessionTwoFactorSetup)
+	session.Save()
+	recordAudit(c, accountAuditEntry(models.AuditTwoFactorEnable, user, nil))
+
+	codes, err := replaceRecoveryCodes(user.ID)
+	if err != nil {
+		responses.Internal(c, "生成恢复码失败")
+		return
+	}
+	responses.OK(c, "两步验证已启用，请妥善保存恢复码", serializers.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor 关闭两步验证，需要提供验证码或恢复码；版主和管理员必须保持启用
+func DisableTwoFactor(c *gin.Context) {
+	user := UserFromContext(c)
+	if !user.TwoFactorEnabled {
+		responses.BadRequest(c, "未启用两步验证")
+		return
+	}
+	if user.Can(models.PermModerate) {
+		responses.Forbidden(c, "版主和管理员必须启用两步验证，不能关闭")
+		return
+	}
+
+	var requestData TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请输入验证码")
+		return
+	}
+	if !checkSecondFactor(c, user, requestData.Code) {
+		return
+	}
+
+	err := database.DB.Model(user).Updates(map[string]interface{}{
+		"two_factor_enabled":      false,
+		"two_factor_secret":       "",
+		"two_factor_last_counter": 0,
+	}).Error
+	if err != nil {
+		responses.Internal(c, "关闭两步验证失败")
+		return
+	}
+	database.DB.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{})
+	recordAudit(c, accountAuditEntry(models.AuditTwoFactorDisable, user, nil))
+	responses.OK(c, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user := UserFromContext(c)
+	if !user.TwoFactorEnabled {
+		responses.BadRequest(c, "未启用两步验证")
+		return
+	}
+
+	var requestData TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, "请输入验证码")
+		return
+	}
+	if !checkSecondFactor(c, user, requestData.Code) {
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(user.ID)
+	if err != nil {
+		responses.Internal(c, "生成恢复码失败")
+		return
+	}
+	responses.OK(c, "恢复码已重新生成，旧的恢复码已失效", serializers.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// checkSecondFactor 校验已登录用户在账户设置中提交的验证码，与登录第二步共用账号和IP的失败次数限制，
+// 未通过时已写入响应
+func checkSecondFactor(c *gin.Context, user *models.User, code string) bool {
+	accountKey := userAccountKey(user.ID)
+	if wait, ok := checkLoginAllowed(c, accountKey); !ok {
+		responses.TooManyRequests(c, wait, (&LoginThrottledError{Wait: wait}).Error())
+		return false
+	}
+	if !VerifySecondFactor(user, code) {
+		recordLoginFailure(c, accountKey, user.Email, user.ID, models.LoginFailureTwoFactor)
+		responses.BadRequest(c, "验证码错误")
+		return false
+	}
+	loginAccountLimiter.Reset(accountKey)
+	return true
+}
+
+// VerifySecondFactor 校验身份验证器验证码或一次性恢复码，使用过的验证码和恢复码不能再次使用
+func VerifySecondFactor(user *models.User, code string) bool {
+	if !user.TwoFactorEnabled || user.TwoFactorSecret == "" {
+		return false
+	}
+
+	if counter, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, time.Now()); ok {
+		// 只有窗口序号大于上次使用的才有效，条件更新保证并发请求中只有一个成功
+		result := database.DB.Model(&models.User{}).
+			Where("id = ? AND two_factor_last_counter < ?", user.ID, counter).
+			Update("two_factor_last_counter", counter)
+		if result.Error == nil && result.RowsAffected == 1 {
+			user.TwoFactorLastCounter = counter
+			return true
+		}
+		return false
+	}
+
+	hash := utils.HashToken(utils.NormalizeRecoveryCode(code))
+	result := database.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hash).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// CountRecoveryCodes 剩余可用的恢复码数量
+func CountRecoveryCodes(userID uint) int64 {
+	var count int64
+	database.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count)
+	return count
+}
+
+// replaceRecoveryCodes 删除旧恢复码并生成新的一组，返回明文
+func replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(code)})
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return codes, tx.Commit().Error
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// twoFactorStore 模拟 users 和 recovery_codes 两张表中两步验证用到的列，
+// 只执行 VerifySecondFactor 发出的两条条件更新，其他语句返回错误让测试失败
+type twoFactorStore struct {
+	mu           sync.Mutex
+	lastCounter  map[int64]int64           // 用户ID -> two_factor_last_counter
+	recoveryUsed map[int64]map[string]bool // 用户ID -> 恢复码摘要 -> 是否已使用
+}
+
+var (
+	registerTwoFactorDriver sync.Once
+	twoFactorStores         sync.Map // DSN -> *twoFactorStore
+)
+
+// useTwoFactorStore 将 database.DB 替换为连接到内存数据的 GORM 实例
+func useTwoFactorStore(t *testing.T) *twoFactorStore {
+	t.Helper()
+	store := &twoFactorStore{lastCounter: map[int64]int64{}, recoveryUsed: map[int64]map[string]bool{}}
+	name := fmt.Sprintf("two-factor-%p", store)
+	registerTwoFactorDriver.Do(func() { sql.Register("two-factor-store", twoFactorDriver{}) })
+	twoFactorStores.Store(name, store)
+	t.Cleanup(func() { twoFactorStores.Delete(name) })
+
+	sqlDB, err := sql.Open("two-factor-store", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = old
+		sqlDB.Close()
+	})
+	return store
+}
+
+type twoFactorDriver struct{}
+
+func (twoFactorDriver) Open(name string) (driver.Conn, error) {
+	store, ok := twoFactorStores.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown store %s", name)
+	}
+	return &twoFactorConn{store: store.(*twoFactorStore)}, nil
+}
+
+type twoFactorConn struct{ store *twoFactorStore }
+
+func (c *twoFactorConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("unexpected prepare: %s", query)
+}
+func (c *twoFactorConn) Close() error              { return nil }
+func (c *twoFactorConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("unexpected transaction") }
+
+func (c *twoFactorConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "UPDATE `users`") && strings.Contains(query, "two_factor_last_counter < ?"):
+		id := placeholderArg(query, args, "id = ?").(int64)
+		counter := placeholderArg(query, args, "two_factor_last_counter < ?").(int64)
+		if s.lastCounter[id] >= counter {
+			return driver.RowsAffected(0), nil
+		}
+		s.lastCounter[id] = counter
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "UPDATE `recovery_codes`") && strings.Contains(query, "used_at IS NULL"):
+		userID := placeholderArg(query, args, "user_id = ?").(int64)
+		hash := placeholderArg(query, args, "code_hash = ?").(string)
+		used, ok := s.recoveryUsed[userID][hash]
+		if !ok || used {
+			return driver.RowsAffected(0), nil
+		}
+		s.recoveryUsed[userID][hash] = true
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+// placeholderArg 返回语句中 cond 里的占位符对应的参数
+func placeholderArg(query string, args []driver.NamedValue, cond string) driver.Value {
+	i := strings.Index(query, cond)
+	if i < 0 {
+		panic("condition not found: " + cond)
+	}
+	return args[strings.Count(query[:i+len(cond)], "?")-1].Value
+}
+
+func (s *twoFactorStore) addRecoveryCodes(userID uint, codes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recoveryUsed[int64(userID)] == nil {
+		s.recoveryUsed[int64(userID)] = map[string]bool{}
+	}
+	for _, code := range codes {
+		s.recoveryUsed[int64(userID)][utils.HashToken(code)] = false
+	}
+}
+
+func newTwoFactorUser(t *testing.T, id uint) *models.User {
+	t.Helper()
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{TwoFactorEnabled: true, TwoFactorSecret: secret}
+	user.ID = id
+	return user
+}
+
+func totpCodeAt(t *testing.T, secret string, counter int64) string {
+	t.Helper()
+	code, err := utils.TOTPCode(secret, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	store := useTwoFactorStore(t)
+	user := newTwoFactorUser(t, 1)
+	current := utils.TOTPCounter(time.Now())
+	code := totpCodeAt(t, user.TwoFactorSecret, current)
+
+	if !VerifySecondFactor(user, code) {
+		t.Fatal("首次使用验证码应当通过")
+	}
+	if user.TwoFactorLastCounter != current {
+		t.Errorf("TwoFactorLastCounter = %d，期望 %d", user.TwoFactorLastCounter, current)
+	}
+	if VerifySecondFactor(user, code) {
+		t.Error("同一验证码不能再次使用")
+	}
+	// 使用过的窗口之前的验证码虽然仍在允许的时钟误差内，也不能再使用
+	if VerifySecondFactor(user, totpCodeAt(t, user.TwoFactorSecret, current-1)) {
+		t.Error("早于已使用窗口的验证码不能使用")
+	}
+	if got := store.lastCounter[1]; got != current {
+		t.Errorf("two_factor_last_counter = %d，期望 %d", got, current)
+	}
+	// 下一个窗口的验证码可以使用
+	if !VerifySecondFactor(user, totpCodeAt(t, user.TwoFactorSecret, current+1)) {
+		t.Error("下一个窗口的验证码应当通过")
+	}
+}
+
+func TestVerifySecondFactorConcurrentReplay(t *testing.T) {
+	useTwoFactorStore(t)
+	user := newTwoFactorUser(t, 1)
+	code := totpCodeAt(t, user.TwoFactorSecret, utils.TOTPCounter(time.Now()))
+
+	// 同一验证码的并发请求只有一个通过
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	passed := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := *user
+			if VerifySecondFactor(&u, code) {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != 1 {
+		t.Errorf("通过次数 = %d，期望 1", passed)
+	}
+}
+
+func TestVerifySecondFactorRecoveryCodeSingleUse(t *testing.T) {
+	store := useTwoFactorStore(t)
+	user := newTwoFactorUser(t, 1)
+	other := newTwoFactorUser(t, 2)
+	store.addRecoveryCodes(1, "a1b2c-3d4e5", "f6a7b-8c9d0")
+	store.addRecoveryCodes(2, "11111-22222")
+
+	// 输入时可以省略连字符或使用大写
+	if !VerifySecondFactor(user, "A1B2C3D4E5") {
+		t.Fatal("恢复码首次使用应当通过")
+	}
+	if VerifySecondFactor(user, "a1b2c-3d4e5") {
+		t.Error("恢复码不能再次使用")
+	}
+	if !VerifySecondFactor(user, "f6a7b-8c9d0") {
+		t.Error("其他恢复码应当仍然可用")
+	}
+	// 不能使用其他用户的恢复码
+	if VerifySecondFactor(user, "11111-22222") {
+		t.Error("其他用户的恢复码不能使用")
+	}
+	if !VerifySecondFactor(other, "11111-22222") {
+		t.Error("其他用户的恢复码应当仍然可用")
+	}
+	if VerifySecondFactor(user, "00000-00000") {
+		t.Error("不存在的恢复码不能通过")
+	}
+}
+
+func TestVerifySecondFactorDisabled(t *testing.T) {
+	useTwoFactorStore(t)
+	user := newTwoFactorUser(t, 1)
+	user.TwoFactorEnabled = false
+	if VerifySecondFactor(user, totpCodeAt(t, user.TwoFactorSecret, utils.TOTPCounter(time.Now()))) {
+		t.Error("未启用两步验证时不能通过")
+	}
+}
+
+// postTwoFactorCode 以 user 的身份调用两步验证设置接口
+func postTwoFactorCode(handler gin.HandlerFunc, user *models.User, code string) int {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/two-factor/disable", strings.NewReader(`{"code":"`+code+`"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", user)
+	handler(c)
+	return w.Code
+}
+
+func TestTwoFactorSettingsAttemptLimit(t *testing.T) {
+	useTwoFactorStore(t)
+	for _, handler := range []gin.HandlerFunc{DisableTwoFactor, RegenerateRecoveryCodes} {
+		user := newTwoFactorUser(t, 9001)
+		user.Role = models.RoleMember
+		t.Cleanup(func() {
+			loginAccountLimiter.Reset(userAccountKey(user.ID))
+			loginIPLimiter.Reset("192.0.2.1")
+		})
+		loginAccountLimiter.Reset(userAccountKey(user.ID))
+		loginIPLimiter.Reset("192.0.2.1")
+
+		// 与登录相同：前3次错误不限制，第4次错误后需要等待
+		for i := 0; i < 4; i++ {
+			if got := postTwoFactorCode(handler, user, "000000"); got != http.StatusBadRequest {
+				t.Fatalf("第 %d 次错误验证码的状态码 = %d，期望 %d", i+1, got, http.StatusBadRequest)
+			}
+		}
+		if got := postTwoFactorCode(handler, user, "000000"); got != http.StatusTooManyRequests {
+			t.Errorf("多次错误后的状态码 = %d，期望 %d", got, http.StatusTooManyRequests)
+		}
+	}
+}
+
+func TestDisableTwoFactorModerator(t *testing.T) {
+	useTwoFactorStore(t)
+	for _, role := range []string{models.RoleModerator, models.RoleAdmin} {
+		user := newTwoFactorUser(t, 9002)
+		user.Role = role
+		code := totpCodeAt(t, user.TwoFactorSecret, utils.TOTPCounter(time.Now()))
+		if got := postTwoFactorCode(DisableTwoFactor, user, code); got != http.StatusForbidden {
+			t.Errorf("%s 关闭两步验证的状态码 = %d，期望 %d", role, got, http.StatusForbidden)
+		}
+	}
+}
@@ -1,48 +1,79 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"gin-doniai/database"
 	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/serializers"
     "gin-doniai/utils"
+	"gin-doniai/wordfilter"
+	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateUser 创建用户
 func CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var requestData CreateUserRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+	if requestData.Role != "" && !models.IsValidRole(requestData.Role) {
+		responses.BadRequest(c, "无效的角色")
 		return
 	}
 
+	hashedPassword, err := utils.HashPassword(requestData.Password)
+	if err != nil {
+		responses.Internal(c, "密码加密失败")
+		return
+	}
+	user := models.User{
+		Name:        requestData.Name,
+		Email:       requestData.Email,
+		Password:    hashedPassword,
+		PasswordSet: true,
+		Avatar:      requestData.Avatar,
+		Level:       requestData.Level,
+		Role:        requestData.Role,
+	}
+
 	result := database.DB.Create(&user)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "用户创建成功",
-		"user":    user,
+	recordAudit(c, auditEntry{
+		Action:      models.AuditUserCreate,
+		TargetType:  models.AuditTargetUser,
+		TargetID:    user.ID,
+		TargetLabel: user.Name,
+		After:       userAuditValues(&user),
 	})
+
+	responses.Created(c, "用户创建成功", serializers.UserFor(UserFromContext(c), AccessTokenFromContext(c), &user))
 }
 
 // GetUsers 获取所有用户
 func GetUsers(c *gin.Context) {
 	var users []models.User
 
-	result := database.DB.Find(&users)
+	page, perPage := responses.PageParams(c, 20)
+	var total int64
+	database.DB.Model(&models.User{}).Count(&total)
+
+	result := database.DB.Order("id ASC").Offset((page - 1) * perPage).Limit(perPage).Find(&users)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"users": users,
-		"count": len(users),
-	})
+	responses.List(c, serializers.UsersFor(UserFromContext(c), AccessTokenFromContext(c), users), responses.NewMeta(page, perPage, total))
 }
 
 // GetUser 获取单个用户
@@ -52,11 +83,11 @@ func GetUser(c *gin.Context) {
 
 	result := database.DB.First(&user, id)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		responses.NotFound(c, "用户不存在")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	responses.OK(c, "", serializers.UserFor(UserFromContext(c), AccessTokenFromContext(c), &user))
 }
 
 // UpdateUser 更新用户
@@ -66,28 +97,68 @@ func UpdateUser(c *gin.Context) {
 
 	// 先查找用户是否存在
 	if err := database.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		responses.NotFound(c, "用户不存在")
 		return
 	}
 
-	// 绑定更新数据
-	var updateData models.User
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// 绑定更新数据（密码需通过修改密码或重置密码流程变更）
+	var requestData UpdateUserRequest
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+	if requestData.Role != "" && !models.IsValidRole(requestData.Role) {
+		responses.BadRequest(c, "无效的角色")
 		return
 	}
+	// 版主和管理员拥有管理权限，必须先启用两步验证
+	if (requestData.Role == models.RoleAdmin || requestData.Role == models.RoleModerator) &&
+		requestData.Role != user.Role && !user.TwoFactorEnabled {
+		responses.BadRequest(c, "该用户尚未启用两步验证，无法设为版主或管理员")
+		return
+	}
+	updateData := models.User{
+		Name:   requestData.Name,
+		Email:  requestData.Email,
+		Avatar: requestData.Avatar,
+		Age:    requestData.Age,
+		Level:  requestData.Level,
+		Role:   requestData.Role,
+		Motto:  requestData.Motto,
+	}
+
+	// 修改邮箱后需要重新验证，发往原邮箱的验证链接也随之失效
+	emailChanged := requestData.Email != "" && !strings.EqualFold(requestData.Email, user.Email)
 
 	// 更新用户
-	result := database.DB.Model(&user).Updates(updateData)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	before := userAuditValues(&user)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if !emailChanged {
+			return nil
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"email_verified":    false,
+			"email_verified_at": nil,
+		}).Error
+	})
+	if err != nil {
+		responses.Internal(c, err.Error())
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "用户更新成功",
-		"user":    user,
+	database.DB.First(&user, user.ID)
+	recordAudit(c, auditEntry{
+		Action:      models.AuditUserUpdate,
+		TargetType:  models.AuditTargetUser,
+		TargetID:    user.ID,
+		TargetLabel: user.Name,
+		Before:      before,
+		After:       userAuditValues(&user),
 	})
+
+	responses.OK(c, "用户更新成功", serializers.UserFor(UserFromContext(c), AccessTokenFromContext(c), &user))
 }
 
 // DeleteUser 删除用户（软删除）
@@ -97,18 +168,27 @@ func DeleteUser(c *gin.Context) {
 
 	// 先查找用户是否存在
 	if err := database.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		responses.NotFound(c, "用户不存在")
 		return
 	}
 
 	// 软删除
+	before := userAuditValues(&user)
 	result := database.DB.Delete(&user)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
+	recordAudit(c, auditEntry{
+		Action:      models.AuditUserDelete,
+		TargetType:  models.AuditTargetUser,
+		TargetID:    user.ID,
+		TargetLabel: user.Name,
+		Before:      before,
+		After:       userAuditValues(&user),
+	})
 
-	c.JSON(http.StatusOK, gin.H{"message": "用户删除成功"})
+	responses.OK(c, "用户删除成功", nil)
 }
 
 // 硬删除（永久删除）
@@ -116,13 +196,26 @@ func ForceDeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	var user models.User
 
-	result := database.DB.Unscoped().Delete(&user, id)
+	// 先查出用户（包括已软删除的），审计日志需要记录删除前的资料
+	if err := database.DB.Unscoped().First(&user, id).Error; err != nil {
+		responses.NotFound(c, "用户不存在")
+		return
+	}
+
+	result := database.DB.Unscoped().Delete(&user)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		responses.Internal(c, result.Error.Error())
 		return
 	}
+	recordAudit(c, auditEntry{
+		Action:      models.AuditUserForceDelete,
+		TargetType:  models.AuditTargetUser,
+		TargetID:    user.ID,
+		TargetLabel: user.Name,
+		Before:      userAuditValues(&user),
+	})
 
-	c.JSON(http.StatusOK, gin.H{"message": "用户永久删除成功"})
+	responses.OK(c, "用户永久删除成功", nil)
 }
 
 // UpdateUserProfile 更新用户资料
@@ -130,54 +223,46 @@ func UpdateUserProfile(c *gin.Context) {
     // 从上下文获取当前用户
     userObj, exists := c.Get("user")
     if !exists || userObj == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
+        responses.Unauthorized(c, "用户未登录")
         return
     }
 
     currentUser := userObj.(*models.User)
 
     // 绑定请求数据
-    var updateData struct {
-        Motto         string `json:"motto"`
-        Github        string `json:"github"`
-        GoogleAccount string `json:"google_account"`
-    }
+    var updateData UpdateProfileRequest
 
     if err := c.ShouldBindJSON(&updateData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求数据格式错误",
-        })
+        responses.BadRequest(c, "请求数据格式错误")
         return
     }
 
+    // 个人格言没有审核流程，需要审核的词语同样不允许使用
+    if updateData.Motto != "" {
+        result := wordfilter.Check(updateData.Motto)
+        if blocked := append(result.Reject, result.Review...); len(blocked) > 0 {
+            responses.Error(c, http.StatusBadRequest, responses.CodeContentRejected, "个人格言包含不允许使用的词语："+strings.Join(blocked, "、"))
+            return
+        }
+        updateData.Motto = result.Text
+    }
+
     // 更新用户信息
     updates := make(map[string]interface{})
     if updateData.Motto != "" {
         updates["motto"] = updateData.Motto
     }
-    if updateData.Github != "" {
-        updates["github"] = updateData.Github
-    }
-    if updateData.GoogleAccount != "" {
-        updates["google_account"] = updateData.GoogleAccount
+    if len(updates) == 0 {
+        responses.OK(c, "个人信息更新成功", nil)
+        return
     }
 
     if err := database.DB.Model(&models.User{}).Where("id = ?", currentUser.ID).Updates(updates).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "更新失败: " + err.Error(),
-        })
+        responses.Internal(c, "更新失败: " + err.Error())
         return
     }
 
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "message": "个人信息更新成功",
-    })
+    responses.OK(c, "个人信息更新成功", nil)
 }
 
 // UpdateUserPassword 修改用户密码
@@ -185,70 +270,55 @@ func UpdateUserPassword(c *gin.Context) {
     // 从上下文获取当前用户
     userObj, exists := c.Get("user")
     if !exists || userObj == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
+        responses.Unauthorized(c, "用户未登录")
         return
     }
 
     currentUser := userObj.(*models.User)
 
     // 绑定请求数据
-    var passwordData struct {
-        CurrentPassword string `json:"current_password" binding:"required"`
-        NewPassword     string `json:"new_password" binding:"required"`
-    }
+    var passwordData UpdatePasswordRequest
 
     if err := c.ShouldBindJSON(&passwordData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求数据格式错误",
-        })
+        responses.BadRequest(c, "请求数据格式错误")
         return
     }
 
-    // 验证当前密码是否正确
-    if !utils.CheckPassword(passwordData.CurrentPassword, currentUser.Password) {
-        c.JSON(http.StatusForbidden, gin.H{
-            "success": false,
-            "message": "当前密码错误",
-        })
+    // 验证当前密码是否正确，第三方登录创建的账户首次设置密码时不需要
+    if currentUser.PasswordSet && !utils.CheckPassword(passwordData.CurrentPassword, currentUser.Password) {
+        responses.Forbidden(c, "当前密码错误")
         return
     }
 
     // 验证新密码长度
     if len(passwordData.NewPassword) < 6 {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "新密码长度至少6位",
-        })
+        responses.BadRequest(c, "新密码长度至少6位")
         return
     }
 
     // 加密新密码
     hashedPassword, err := utils.HashPassword(passwordData.NewPassword)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "密码加密失败",
-        })
+        responses.Internal(c, "密码加密失败")
         return
     }
 
     // 更新密码
-    if err := database.DB.Model(&models.User{}).Where("id = ?", currentUser.ID).Update("password", hashedPassword).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "密码更新失败: " + err.Error(),
-        })
+    if err := database.DB.Model(&models.User{}).Where("id = ?", currentUser.ID).Updates(map[string]interface{}{
+        "password":     hashedPassword,
+        "password_set": true,
+    }).Error; err != nil {
+        responses.Internal(c, "密码更新失败: " + err.Error())
         return
     }
 
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "message": "密码修改成功",
-    })
+    // 密码修改后其他设备全部下线，只保留当前会话
+    if err := RevokeUserSessions(currentUser.ID, sessions.Default(c).ID()); err != nil {
+        fmt.Printf("撤销用户会话失败: %v\n", err)
+    }
+    recordAudit(c, accountAuditEntry(models.AuditPasswordChange, currentUser, nil))
+
+    responses.OK(c, "密码修改成功，其他设备已退出登录", nil)
 }
 
 // UserIDFromContext 从上下文中获取用户ID
@@ -261,3 +331,24 @@ func UserIDFromContext(c *gin.Context) *uint {
     }
     return nil
 }
+
+// UserFromContext 从上下文中获取当前登录用户，未登录时返回nil
+func UserFromContext(c *gin.Context) *models.User {
+    userObj, exists := c.Get("user")
+    if exists && userObj != nil {
+        if user, ok := userObj.(*models.User); ok {
+            return user
+        }
+    }
+    return nil
+}
+
+// AccessTokenFromContext 获取当前请求使用的个人访问令牌，会话登录时返回nil
+func AccessTokenFromContext(c *gin.Context) *models.AccessToken {
+    if tokenObj, exists := c.Get("access_token"); exists {
+        if token, ok := tokenObj.(*models.AccessToken); ok {
+            return token
+        }
+    }
+    return nil
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"gin-doniai/database"
 	"gin-doniai/models"
-	"net/http"
+	"gin-doniai/responses"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -70,7 +70,7 @@ func GetOnlineUserCount(c *gin.Context) {
 		Where("last_active_time > ?", cutoffTime).
 		Count(&count)
 
-	c.JSON(http.StatusOK, gin.H{
+	responses.OK(c, "", gin.H{
 		"online_count": count,
 	})
 }
@@ -82,11 +82,8 @@ func CleanupExpiredOnlineStatus() {
 		Delete(&models.UserOnlineStatus{})
 }
 
-// UpdateUserOnlineStatusWithInfo 更新用户在线状态（用于消息队列处理）
-func UpdateUserOnlineStatusWithInfo(userID uint, clientIP string, userAgent string) {
-    // 获取session（注意：在批量处理中可能需要不同的session处理方式）
-    // 这里我们简化处理，只使用必要的信息
-
+// UpdateUserOnlineStatusWithInfo 更新用户在线状态（用于消息队列处理），同时记录会话的最近活跃时间和设备信息
+func UpdateUserOnlineStatusWithInfo(userID uint, sessionID string, clientIP string, userAgent string) {
     // 获取当前时间
     currentTime := time.Now()
 
@@ -95,11 +92,20 @@ func UpdateUserOnlineStatusWithInfo(userID uint, clientIP string, userAgent stri
         UserID:         userID,
         LastActiveTime: currentTime,
         IPAddress:      clientIP,
-        SessionID:      fmt.Sprintf("batch-%d-%s", userID, currentTime.Format("20060102150405")),
+        SessionID:      sessionID,
         UserAgent:      userAgent,
     }
 
     // 使用Upsert操作更新在线状态
     database.DB.Where("user_id = ?", userID).Assign(onlineStatus).FirstOrCreate(&onlineStatus)
-}
 
+    if sessionID != "" {
+        database.DB.Model(&models.UserSession{}).
+            Where("session_key = ? AND user_id = ?", sessionID, userID).
+            UpdateColumns(map[string]interface{}{
+                "last_active_at": currentTime,
+                "ip_address":     clientIP,
+                "user_agent":     userAgent,
+            })
+    }
+}
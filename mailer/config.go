@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 默认发送队列，由 Setup 根据环境变量创建
+var defaultQueue *Queue
+
+// NewSenderFromEnv 根据环境变量创建发送方式：
+//
+//	MAIL_DRIVER    smtp 或 log（默认 log）
+//	MAIL_FROM      发件人，如 "Doniai <noreply@example.com>"
+//	MAIL_LOG_DIR   log 方式下保存 .eml 文件的目录，为空时打印到标准输出
+//	SMTP_HOST、SMTP_PORT（默认587）、SMTP_USERNAME、SMTP_PASSWORD
+//	SMTP_ENCRYPTION starttls（默认）、tls 或 none
+func NewSenderFromEnv() (Sender, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Doniai <noreply@localhost>"
+	}
+
+	switch driver := strings.ToLower(os.Getenv("MAIL_DRIVER")); driver {
+	case "", "log":
+		return &FileSender{Dir: os.Getenv("MAIL_LOG_DIR"), From: from}, nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("mailer: 未设置 SMTP_HOST")
+		}
+		port := 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("mailer: SMTP_PORT 无效: %v", err)
+			}
+			port = p
+		}
+		encryption := strings.ToLower(os.Getenv("SMTP_ENCRYPTION"))
+		switch encryption {
+		case "":
+			encryption = EncryptionSTARTTLS
+		case EncryptionSTARTTLS, EncryptionTLS, EncryptionNone:
+		default:
+			return nil, fmt.Errorf("mailer: SMTP_ENCRYPTION 无效: %s", encryption)
+		}
+		return &SMTPSender{
+			Host:       host,
+			Port:       port,
+			Username:   os.Getenv("SMTP_USERNAME"),
+			Password:   os.Getenv("SMTP_PASSWORD"),
+			From:       from,
+			Encryption: encryption,
+		}, nil
+	default:
+		return nil, fmt.Errorf("mailer: 不支持的 MAIL_DRIVER: %s", driver)
+	}
+}
+
+// Setup 根据环境变量创建默认发送队列并启动，配置错误时退回到打印到标准输出
+func Setup() {
+	sender, err := NewSenderFromEnv()
+	if err != nil {
+		fmt.Printf("警告: 邮件配置错误，邮件将只打印到标准输出: %v\n", err)
+		sender = &FileSender{From: "Doniai <noreply@localhost>"}
+	}
+	defaultQueue = NewQueue(sender, 1000)
+	defaultQueue.Start(2)
+}
+
+// Send 把邮件加入默认发送队列
+func Send(msg *Message) error {
+	if defaultQueue == nil {
+		return ErrQueueClosed
+	}
+	return defaultQueue.Enqueue(msg)
+}
+
+// SendTemplate 用内置模板生成邮件并加入默认发送队列
+func SendTemplate(to, name string, data interface{}) error {
+	msg, err := Render(name, data)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{to}
+	return Send(msg)
+}
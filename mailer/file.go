@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSender 开发环境使用：不真正发送，而是把邮件写入目录下的 .eml 文件，Dir 为空时打印到标准输出
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+// Send 保存一封邮件
+func (s *FileSender) Send(msg *Message) error {
+	now := time.Now()
+	_, recipients, data, err := msg.Build(s.From, now)
+	if err != nil {
+		return err
+	}
+
+	if s.Dir == "" {
+		body := msg.Text
+		if body == "" {
+			body = msg.HTML
+		}
+		fmt.Printf("[mailer] 收件人: %s\n主题: %s\n%s\n", strings.Join(recipients, ", "), msg.Subject, body)
+		return nil
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405"), hex.EncodeToString(b))
+	return os.WriteFile(filepath.Join(s.Dir, name), data, 0o644)
+}
@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message 一封待发送的邮件，Text 和 HTML 至少填写一个
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender 邮件发送方式，SMTP 用于生产环境，FileSender 用于开发环境
+type Sender interface {
+	Send(msg *Message) error
+}
+
+var (
+	// ErrNoRecipient 邮件没有收件人
+	ErrNoRecipient = errors.New("mailer: 邮件没有收件人")
+	// ErrInvalidAddress 发件人或收件人地址格式错误
+	ErrInvalidAddress = errors.New("mailer: 邮件地址无效")
+)
+
+// Build 生成完整的 MIME 邮件内容，同时返回信封使用的发件人和收件人地址
+func (m *Message) Build(from string, now time.Time) (envelopeFrom string, recipients []string, data []byte, err error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%w: 发件人 %q: %v", ErrInvalidAddress, from, err)
+	}
+	if len(m.To) == 0 {
+		return "", nil, nil, ErrNoRecipient
+	}
+
+	var toHeader []string
+	for _, to := range m.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("%w: 收件人 %q: %v", ErrInvalidAddress, to, err)
+		}
+		recipients = append(recipients, addr.Address)
+		toHeader = append(toHeader, addr.String())
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", fromAddr.String())
+	writeHeader(&buf, "To", strings.Join(toHeader, ", "))
+	writeHeader(&buf, "Subject", mime.BEncoding.Encode("UTF-8", stripNewlines(m.Subject)))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID(fromAddr.Address))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		mw := multipart.NewWriter(&buf)
+		writeHeader(&buf, "Content-Type", `multipart/alternative; boundary="`+mw.Boundary()+`"`)
+		buf.WriteString("\r\n")
+		if err := writePart(mw, "text/plain", m.Text); err != nil {
+			return "", nil, nil, err
+		}
+		if err := writePart(mw, "text/html", m.HTML); err != nil {
+			return "", nil, nil, err
+		}
+		if err := mw.Close(); err != nil {
+			return "", nil, nil, err
+		}
+	case m.HTML != "":
+		writeBody(&buf, "text/html", m.HTML)
+	default:
+		writeBody(&buf, "text/plain", m.Text)
+	}
+	return fromAddr.Address, recipients, buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+// writeBody 单一正文，使用 base64 编码避免中文和长行的问题
+func writeBody(buf *bytes.Buffer, contentType, body string) {
+	writeHeader(buf, "Content-Type", contentType+"; charset=UTF-8")
+	writeHeader(buf, "Content-Transfer-Encoding", "base64")
+	buf.WriteString("\r\n")
+	buf.WriteString(encodeBase64Lines(body))
+}
+
+func writePart(mw *multipart.Writer, contentType, body string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "base64")
+	w, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(encodeBase64Lines(body)))
+	return err
+}
+
+// encodeBase64Lines 按每行76个字符折行的 base64 编码
+func encodeBase64Lines(s string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(s))
+	var b strings.Builder
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.String()
+}
+
+// stripNewlines 去除换行，防止邮件头注入
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
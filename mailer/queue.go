@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull 发送队列已满
+var ErrQueueFull = errors.New("mailer: 发送队列已满")
+
+// ErrQueueClosed 发送队列已停止
+var ErrQueueClosed = errors.New("mailer: 发送队列已停止")
+
+type job struct {
+	msg     *Message
+	attempt int
+}
+
+// Queue 在后台发送邮件，失败后按递增的间隔重试
+type Queue struct {
+	sender      Sender
+	jobs        chan job
+	MaxAttempts int             // 最多尝试次数
+	Backoff     []time.Duration // 第 n 次失败后等待的时间，超出长度时使用最后一个
+
+	mu      sync.Mutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// NewQueue 创建发送队列，size 为缓冲的邮件数量
+func NewQueue(sender Sender, size int) *Queue {
+	return &Queue{
+		sender:      sender,
+		jobs:        make(chan job, size),
+		MaxAttempts: 5,
+		Backoff:     []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute, 30 * time.Minute},
+	}
+}
+
+// Start 启动 n 个发送协程
+func (q *Queue) Start(n int) {
+	for i := 0; i < n; i++ {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			for j := range q.jobs {
+				q.process(j)
+			}
+		}()
+	}
+}
+
+// Enqueue 把邮件加入队列，不会阻塞
+func (q *Queue) Enqueue(msg *Message) error {
+	return q.push(job{msg: msg, attempt: 1})
+}
+
+// Stop 停止接收新邮件，等待队列中已有的邮件发送完毕；尚在等待重试的邮件会被丢弃
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+	q.workers.Wait()
+}
+
+func (q *Queue) push(j job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.jobs <- j:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) process(j job) {
+	err := q.sender.Send(j.msg)
+	if err == nil {
+		return
+	}
+	if j.attempt >= q.MaxAttempts || permanent(err) {
+		fmt.Printf("发送邮件失败，已放弃（第%d次）: %s: %v\n", j.attempt, j.msg.Subject, err)
+		return
+	}
+
+	delay := time.Minute
+	if len(q.Backoff) > 0 {
+		delay = q.Backoff[min(j.attempt, len(q.Backoff))-1]
+	}
+	fmt.Printf("发送邮件失败，%s后重试（第%d次）: %s: %v\n", delay, j.attempt, j.msg.Subject, err)
+	time.AfterFunc(delay, func() {
+		if err := q.push(job{msg: j.msg, attempt: j.attempt + 1}); err != nil {
+			fmt.Printf("重试邮件失败: %s: %v\n", j.msg.Subject, err)
+		}
+	})
+}
+
+// permanent 判断是否为重试也无法成功的错误：地址无效或 SMTP 服务器返回 5xx
+func permanent(err error) bool {
+	if errors.Is(err, ErrNoRecipient) || errors.Is(err, ErrInvalidAddress) {
+		return true
+	}
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500
+}
@@ -0,0 +1,170 @@
+package mailer
+
+import (
+	"errors"
+	"net/textproto"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender 按顺序返回预设的错误，之后全部成功
+type fakeSender struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+	sent  chan *Message
+}
+
+func newFakeSender(errs ...error) *fakeSender {
+	return &fakeSender{errs: errs, sent: make(chan *Message, 10)}
+}
+
+func (s *fakeSender) Send(msg *Message) error {
+	s.mu.Lock()
+	s.calls++
+	var err error
+	if len(s.errs) > 0 {
+		err, s.errs = s.errs[0], s.errs[1:]
+	}
+	s.mu.Unlock()
+	if err == nil {
+		s.sent <- msg
+	}
+	return err
+}
+
+func (s *fakeSender) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// newTestQueue 重试间隔很短的队列
+func newTestQueue(t *testing.T, sender Sender) *Queue {
+	q := NewQueue(sender, 10)
+	q.Backoff = []time.Duration{time.Millisecond, 2 * time.Millisecond}
+	q.Start(1)
+	t.Cleanup(q.Stop)
+	return q
+}
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+var errTemporary = &textproto.Error{Code: 451, Msg: "try again later"}
+
+func TestQueueRetriesTemporaryFailures(t *testing.T) {
+	sender := newFakeSender(errTemporary, errors.New("connection reset"))
+	q := newTestQueue(t, sender)
+	if err := q.Enqueue(&Message{Subject: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-sender.sent:
+		if msg.Subject != "hello" {
+			t.Errorf("Subject = %q", msg.Subject)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("邮件没有在重试后发送成功")
+	}
+	if calls := sender.Calls(); calls != 3 {
+		t.Errorf("发送了 %d 次，期望 3 次", calls)
+	}
+}
+
+func TestQueueGivesUpOnPermanentFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"smtp 5xx", &textproto.Error{Code: 550, Msg: "no such user"}},
+		{"invalid address", ErrInvalidAddress},
+		{"no recipient", ErrNoRecipient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := newFakeSender(tt.err, nil)
+			q := newTestQueue(t, sender)
+			q.Enqueue(&Message{Subject: "hello"})
+			waitFor(t, "第一次发送", func() bool { return sender.Calls() >= 1 })
+
+			time.Sleep(20 * time.Millisecond) // 超过全部重试间隔
+			if calls := sender.Calls(); calls != 1 {
+				t.Errorf("永久错误后又重试了，共发送 %d 次", calls)
+			}
+		})
+	}
+}
+
+func TestQueueStopsAfterMaxAttempts(t *testing.T) {
+	sender := newFakeSender(errTemporary, errTemporary, errTemporary, errTemporary, errTemporary)
+	q := newTestQueue(t, sender)
+	q.MaxAttempts = 3
+	q.Enqueue(&Message{Subject: "hello"})
+	waitFor(t, "三次发送", func() bool { return sender.Calls() >= 3 })
+
+	time.Sleep(20 * time.Millisecond)
+	if calls := sender.Calls(); calls != 3 {
+		t.Errorf("发送了 %d 次，期望最多 3 次", calls)
+	}
+}
+
+func TestQueueFullAndClosed(t *testing.T) {
+	q := NewQueue(newFakeSender(), 1) // 未启动，邮件留在队列中
+	if err := q.Enqueue(&Message{}); err != nil {
+		t.Fatalf("第一封: %v", err)
+	}
+	if err := q.Enqueue(&Message{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("队列已满时 err = %v，期望 ErrQueueFull", err)
+	}
+	q.Stop()
+	if err := q.Enqueue(&Message{}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("停止后 err = %v，期望 ErrQueueClosed", err)
+	}
+}
+
+// 队列和 SMTP 一起测试：第一次连接被临时拒绝，重试后送达；收件人不存在时不再重试
+func TestQueueWithSMTPSink(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.Rcpt = func(rcpt string, conn int) string {
+		switch {
+		case rcpt == "nobody@example.com":
+			return "550 5.1.1 No such user"
+		case conn == 1:
+			return "421 4.3.2 Service not available"
+		}
+		return "250 OK"
+	}
+	q := newTestQueue(t, sink.sender())
+
+	q.Enqueue(&Message{To: []string{"alice@example.com"}, Subject: "hello", Text: "hello"})
+	waitFor(t, "重试后送达", func() bool { return len(sink.Messages()) == 1 })
+
+	q.Enqueue(&Message{To: []string{"nobody@example.com"}, Subject: "bounce", Text: "bounce"})
+	waitFor(t, "第三次连接", func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return sink.conns >= 3
+	})
+	time.Sleep(20 * time.Millisecond)
+	sink.mu.Lock()
+	conns := sink.conns
+	sink.mu.Unlock()
+	if conns != 3 {
+		t.Errorf("收件人不存在时又重试了，共连接 %d 次", conns)
+	}
+	if n := len(sink.Messages()); n != 1 {
+		t.Errorf("收到 %d 封邮件，期望 1 封", n)
+	}
+}
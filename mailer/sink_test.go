@@ -0,0 +1,164 @@
+package mailer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sinkMessage SMTP 测试服务收到的一封邮件
+type sinkMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// smtpSink 进程内的 SMTP 测试服务，只实现发送邮件需要的命令，不支持 STARTTLS
+type smtpSink struct {
+	Addr     string
+	Username string // 不为空时要求 AUTH PLAIN 认证
+	Password string
+	// Rcpt 返回 RCPT TO 的响应，为 nil 时全部接受；参数为收件人和这是第几次连接（从1开始）
+	Rcpt func(rcpt string, conn int) string
+
+	listener net.Listener
+	once     sync.Once
+	mu       sync.Mutex
+	conns    int
+	messages []sinkMessage
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpSink{Addr: l.Addr().String(), listener: l}
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+// start 开始接受连接，测试在此之前设置好 Username、Rcpt 等字段
+func (s *smtpSink) start() {
+	s.once.Do(func() {
+		go func() {
+			for {
+				conn, err := s.listener.Accept()
+				if err != nil {
+					return
+				}
+				s.mu.Lock()
+				s.conns++
+				n := s.conns
+				s.mu.Unlock()
+				go s.serve(conn, n)
+			}
+		}()
+	})
+}
+
+// sender 连接到测试服务的 SMTPSender，同时开始接受连接
+func (s *smtpSink) sender() *SMTPSender {
+	s.start()
+	host, port, _ := net.SplitHostPort(s.Addr)
+	p, _ := strconv.Atoi(port)
+	return &SMTPSender{
+		Host:       host,
+		Port:       p,
+		From:       "Doniai <noreply@example.com>",
+		Encryption: EncryptionNone,
+	}
+}
+
+func (s *smtpSink) Messages() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+func (s *smtpSink) serve(conn net.Conn, n int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 sink ESMTP")
+	var msg sinkMessage
+	authed := s.Username == ""
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			if s.Username != "" {
+				reply("250-sink")
+				reply("250 AUTH PLAIN")
+			} else {
+				reply("250 sink")
+			}
+		case strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "AUTH PLAIN"):
+			raw, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			if string(raw) == "\x00"+s.Username+"\x00"+s.Password {
+				authed = true
+				reply("235 2.7.0 Authentication successful")
+			} else {
+				reply("535 5.7.8 Authentication credentials invalid")
+			}
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			if !authed {
+				reply("530 5.7.0 Authentication required")
+				continue
+			}
+			msg = sinkMessage{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			resp := "250 OK"
+			if s.Rcpt != nil {
+				resp = s.Rcpt(rcpt, n)
+			}
+			if strings.HasPrefix(resp, "250") {
+				msg.To = append(msg.To, rcpt)
+			}
+			reply(resp)
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK queued")
+		case cmd == "RSET":
+			msg = sinkMessage{}
+			reply("250 OK")
+		case cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP 连接的加密方式
+const (
+	EncryptionSTARTTLS = "starttls" // 明文连接后升级为 TLS（587端口）
+	EncryptionTLS      = "tls"      // 直接建立 TLS 连接（465端口）
+	EncryptionNone     = "none"     // 不加密，只用于本地测试的 SMTP 服务
+)
+
+// SMTPSender 通过 SMTP 服务器发送邮件
+type SMTPSender struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       string
+	Encryption string
+	Timeout    time.Duration
+}
+
+// Send 发送一封邮件，每封邮件使用单独的连接
+func (s *SMTPSender) Send(msg *Message) error {
+	envelopeFrom, recipients, data, err := msg.Build(s.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	if s.Encryption == EncryptionTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: s.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("mailer: 连接 SMTP 服务器失败: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mailer: SMTP 握手失败: %w", err)
+	}
+	defer client.Close()
+
+	if s.Encryption == "" || s.Encryption == EncryptionSTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("mailer: SMTP 服务器 %s 不支持 STARTTLS", addr)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return fmt.Errorf("mailer: STARTTLS 失败: %w", err)
+		}
+	}
+
+	if s.Username != "" {
+		// PlainAuth 只允许在加密连接或本机连接上发送密码
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("mailer: SMTP 认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(envelopeFrom); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,183 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPSenderDelivers(t *testing.T) {
+	sink := newSMTPSink(t)
+	msg := &Message{
+		To:      []string{"张三 <alice@example.com>", "bob@example.com"},
+		Subject: "验证邮箱\r\nBcc: evil@example.com",
+		Text:    "你好，请点击链接验证邮箱。",
+		HTML:    "<p>你好，请点击链接验证邮箱。</p>",
+	}
+	if err := sink.sender().Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	messages := sink.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("收到 %d 封邮件，期望 1 封", len(messages))
+	}
+	got := messages[0]
+	if got.From != "noreply@example.com" {
+		t.Errorf("MAIL FROM = %q", got.From)
+	}
+	if strings.Join(got.To, ",") != "alice@example.com,bob@example.com" {
+		t.Errorf("RCPT TO = %v", got.To)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.Data))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	if parsed.Header.Get("Bcc") != "" {
+		t.Errorf("主题中的换行被当作邮件头: Bcc = %q", parsed.Header.Get("Bcc"))
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "验证邮箱 Bcc: evil@example.com" {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc := part.Header.Get("Content-Transfer-Encoding"); enc != "base64" {
+			t.Errorf("Content-Transfer-Encoding = %q", enc)
+		}
+		body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	want := []string{
+		"text/plain; charset=UTF-8: " + msg.Text,
+		"text/html; charset=UTF-8: " + msg.HTML,
+	}
+	if strings.Join(parts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("正文 = %q，期望 %q", parts, want)
+	}
+}
+
+func TestSMTPSenderAuth(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.Username, sink.Password = "mailer", "secret"
+	msg := &Message{To: []string{"alice@example.com"}, Subject: "hi", Text: "hi"}
+
+	sender := sink.sender()
+	sender.Username, sender.Password = "mailer", "wrong"
+	err := sender.Send(msg)
+	if err == nil {
+		t.Fatal("密码错误时应当发送失败")
+	}
+	if !permanent(err) {
+		t.Errorf("认证失败（535）应当不再重试: %v", err)
+	}
+
+	sender.Password = "secret"
+	if err := sender.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if n := len(sink.Messages()); n != 1 {
+		t.Errorf("收到 %d 封邮件，期望 1 封", n)
+	}
+}
+
+func TestSMTPSenderRequiresSTARTTLS(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender := sink.sender()
+	sender.Encryption = EncryptionSTARTTLS
+	err := sender.Send(&Message{To: []string{"alice@example.com"}, Subject: "hi", Text: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("服务器不支持 STARTTLS 时应当拒绝发送，err = %v", err)
+	}
+	if n := len(sink.Messages()); n != 0 {
+		t.Errorf("不应发送邮件，收到 %d 封", n)
+	}
+}
+
+func TestSMTPSenderRejectedRecipient(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		permanent bool
+	}{
+		{"mailbox unavailable", "550 5.1.1 No such user", true},
+		{"greylisted", "451 4.7.1 Try again later", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newSMTPSink(t)
+			sink.Rcpt = func(string, int) string { return tt.response }
+			err := sink.sender().Send(&Message{To: []string{"alice@example.com"}, Subject: "hi", Text: "hi"})
+			var protoErr *textproto.Error
+			if !errors.As(err, &protoErr) {
+				t.Fatalf("err = %v，期望 SMTP 错误", err)
+			}
+			if permanent(err) != tt.permanent {
+				t.Errorf("permanent(%v) = %v，期望 %v", err, !tt.permanent, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestSMTPSenderConnectionRefused(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender := sink.sender()
+	sink.listener.Close()
+	sender.Timeout = time.Second
+	err := sender.Send(&Message{To: []string{"alice@example.com"}, Subject: "hi", Text: "hi"})
+	if err == nil {
+		t.Fatal("连接失败时应当返回错误")
+	}
+	if permanent(err) {
+		t.Errorf("连接失败应当重试: %v", err)
+	}
+}
+
+func TestBuildInvalidAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   []string
+		want error
+	}{
+		{"no recipient", "noreply@example.com", nil, ErrNoRecipient},
+		{"bad recipient", "noreply@example.com", []string{"not an address"}, ErrInvalidAddress},
+		{"bad sender", "noreply", []string{"alice@example.com"}, ErrInvalidAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &Message{To: tt.to, Subject: "hi", Text: "hi"}
+			_, _, _, err := msg.Build(tt.from, time.Now())
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v，期望 %v", err, tt.want)
+			}
+			if !permanent(err) {
+				t.Errorf("地址错误应当不再重试: %v", err)
+			}
+		})
+	}
+}
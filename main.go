@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -12,13 +15,20 @@ import (
 	"gin-doniai/caches"
 	"gin-doniai/database"
 	"gin-doniai/handlers"
+	"gin-doniai/mailer"
 	"gin-doniai/models"
+	"gin-doniai/openapi"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+	"gin-doniai/search"
+	"gin-doniai/serializers"
+	"gin-doniai/stores"
 	"gin-doniai/utils"
 	"gin-doniai/workers"
 
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
 )
 
 // 在 main.go 顶部添加全局变量
@@ -26,6 +36,7 @@ var (
 	onlineStatusChan      chan workers.OnlineStatusUpdate
     viewEventChan chan workers.ViewEvent
 	globalConfig          GlobalConfig
+	sessionStore          *stores.SessionStore
 	recommendedCategories []models.Category
 )
 
@@ -44,6 +55,8 @@ type OnlineStatusUpdate struct {
 
 func main() {
 	database.InitDB()
+	// 启动邮件发送队列
+	mailer.Setup()
 
 	// 初始化全局配置
 	globalConfig = GlobalConfig{
@@ -60,6 +73,21 @@ func main() {
 		fmt.Printf("获取推荐分类失败: %v\n", err)
 	}
 
+	// 加载敏感词
+	if err := handlers.ReloadSensitiveWords(); err != nil {
+		fmt.Printf("加载敏感词失败: %v\n", err)
+	}
+
+	// 启用搜索后端，后台建立文章的搜索索引
+	if err := search.Setup(os.Getenv("SEARCH_BACKEND")); err != nil {
+		fmt.Printf("启用搜索后端失败: %v\n", err)
+	}
+	go func() {
+		if err := handlers.RebuildSearchIndex(); err != nil {
+			fmt.Printf("建立搜索索引失败: %v\n", err)
+		}
+	}()
+
 	gin.SetMode(gin.DebugMode)
     // gin.SetMode(gin.ReleaseMode)
 	// 初始化在线状态更新通道
@@ -72,6 +100,9 @@ func main() {
     // 启动浏览事件处理器
     go workers.HandleViewNumUpdates(viewEventChan)
 
+    // 启动回收站定时清理
+    go workers.HandleTrashPurge()
+
 	router := gin.Default()
 	router.SetFuncMap(template.FuncMap{
 		"add": func(a, b int) int {
@@ -93,15 +124,20 @@ func main() {
         "timeAgo": func(t time.Time) string {
             return utils.GetTimeAgo(t)
         },
+		"statusLabel": models.StatusLabel,
 		"global": func() GlobalConfig {
 			return globalConfig
 		},
 	})
-	// 设置session存储
-	store := cookie.NewStore([]byte("secret"))
-	router.Use(sessions.Sessions("mysession", store))
+	// 设置session存储：会话保存在数据库中，Cookie 只保存签名后的会话标识
+	secret := sessionSecret()
+	sessionStore = stores.NewSessionStore(database.DB, secret)
+	router.Use(middlewares.ClientIP())
+	router.Use(sessions.Sessions("mysession", sessionStore))
 	// 在路由定义之前应用用户中间件
 	router.Use(middlewares.UserAndOnlineStatusMiddleware(onlineStatusChan))
+	// 会话登录的修改请求需要携带 CSRF 令牌
+	router.Use(middlewares.CSRF(secret))
 
 	// 加载模板文件
 	router.LoadHTMLGlob("templates/**/*")
@@ -118,6 +154,8 @@ func main() {
 	router.POST("/register", registerSubmit)
 	router.GET("/login", loginHandler)
 	router.POST("/login", loginSubmit)
+	router.GET("/login/two-factor", handlers.TwoFactorPage)
+	router.POST("/login/two-factor", handlers.TwoFactorSubmit)
 	router.GET("/logout", logoutHandler)
 	router.GET("/profile", profileHandler)
 	router.GET("/posts", articleHandler)
@@ -125,61 +163,39 @@ func main() {
 	router.GET("/settings", settingsHandler)
 	router.GET("/rss", rssHandler)
 	// 添加搜索路由
-	router.GET("/search", searchPostsHandler)
+	router.GET("/search", handlers.SearchPage)
 	router.GET("/member", searchUsersHandler)
 
 	// 在 main.go 的路由定义部分添加
-    router.GET("/auth/github", handlers.GitHubLogin)
-    router.GET("/auth/github/callback", handlers.GitHubCallback)
-    router.GET("/auth/google", handlers.GoogleLogin)
-    router.GET("/auth/google/callback", handlers.GoogleCallback)
+    router.GET("/auth/:provider", handlers.OAuthLogin)
+    router.GET("/auth/:provider/callback", handlers.OAuthCallback)
 
 
-	// 在 main.go 的路由部分添加
-	router.GET("/api/online/count", handlers.GetOnlineUserCount)
-	// 在路由定义部分添加
-    router.POST("/api/auth/forgot-password", handlers.ForgotPassword)
     router.GET("/reset-password", handlers.ResetPassword)
-    router.POST("/api/auth/reset-password", handlers.ProcessResetPassword)
-
-
-	// 在 main.go 的路由定义部分添加评论路由
-	commentRoutes := router.Group("/api/comments")
-	{
-		commentRoutes.POST("/", handlers.CreateComment)
-		commentRoutes.GET("/", handlers.GetComments)
-		commentRoutes.GET("/:id", handlers.GetComment)
-		commentRoutes.PUT("/:id", handlers.UpdateComment)
-		commentRoutes.DELETE("/:id", handlers.DeleteComment)
-		commentRoutes.POST("/:id/like", handlers.LikeComment)
-	}
-
-	userRoutes := router.Group("/api/users")
-	{
-		userRoutes.POST("/", handlers.CreateUser)                 // 创建用户
-		userRoutes.GET("/", handlers.GetUsers)                    // 获取所有用户
-		userRoutes.GET("/:id", handlers.GetUser)                  // 获取单个用户
-		userRoutes.PUT("/:id", handlers.UpdateUser)               // 更新用户
-		userRoutes.DELETE("/:id", handlers.DeleteUser)            // 删除用户（软删除）
-		userRoutes.DELETE("/:id/force", handlers.ForceDeleteUser) // 强制删除
-		userRoutes.PUT("/profile", handlers.UpdateUserProfile)    // 更新用户资料
-        userRoutes.PUT("/password", handlers.UpdateUserPassword) // 修改用户密码
-	}
-
-	postRoutes := router.Group("/api/posts")
-	{
-		postRoutes.POST("/", handlers.CreatePost)                 // 创建文章
-		postRoutes.GET("/", handlers.GetPosts)                    // 获取所有文章
-		postRoutes.GET("/:id", handlers.GetPost)                  // 获取单个文章
-		postRoutes.PUT("/:id", handlers.UpdatePost)               // 更新文章
-		postRoutes.DELETE("/:id", handlers.DeletePost)            // 删除文章（软删除）
-		postRoutes.POST("/:id/like", handlers.LikePost)           // 文章点赞
-		postRoutes.DELETE("/:id/force", handlers.ForceDeletePost) // 强制删除
-		postRoutes.POST("/:id/favorite", handlers.FavoritePost)   // 文章收藏
-	}
+    router.GET("/verify-email", handlers.VerifyEmail)
+	router.GET("/moderation", handlers.ModerationPage)
+	router.GET("/trash", handlers.TrashPage)
+	router.GET("/admin", handlers.AdminDashboardPage)
+	router.GET("/admin/users", handlers.AdminUsersPage)
+	router.GET("/admin/posts", handlers.AdminPostsPage)
+	router.GET("/admin/comments", handlers.AdminCommentsPage)
+	router.GET("/admin/categories", handlers.AdminCategoriesPage)
+	router.GET("/admin/sensitive-words", handlers.SensitiveWordsPage)
+	router.GET("/admin/audit", handlers.AdminAuditPage)
+
+	// JSON API，旧的 /api 前缀只保留原有接口，响应头中提示已废弃，响应保持旧版格式
+	registerAPIRoutes(router.Group("/api/v1"))
+	registerLegacyAPIRoutes(router.Group("/api", middlewares.Deprecated("/api", "/api/v1"), middlewares.LegacyResponse("/api")))
+
+	// OpenAPI 文档，根据实际注册的路由生成
+	describeAPIRoutes()
+	router.GET("/api/openapi.json", openapi.Handler(router, openapi.Info{
+		Title:   "Doniai技术社区 API",
+		Version: "v1",
+	}, "/api/v1"))
 
     router.NoRoute(func(c *gin.Context) {
-        c.HTML(http.StatusNotFound, "404.tmpl", gin.H{
+        responses.HTML(c, http.StatusNotFound, "404.tmpl", gin.H{
             "Message": "页面未找到",
         })
     })
@@ -193,12 +209,30 @@ func main() {
 			select {
 			case <-ticker.C:
 				handlers.CleanupExpiredOnlineStatus()
+				handlers.CleanupLoginAttempts()
+				sessionStore.Cleanup()
+				// 其他实例修改的敏感词在这里同步
+				if err := handlers.ReloadSensitiveWords(); err != nil {
+					fmt.Printf("重新加载敏感词失败: %v\n", err)
+				}
 			}
 		}
 	}()
 	router.Run(":8080")
 }
 
+// sessionSecret 读取会话签名密钥，未配置时使用随机密钥（重启后所有用户需要重新登录）
+func sessionSecret() []byte {
+	secret := os.Getenv("SESSION_SECRET")
+	if secret == "" {
+		fmt.Println("警告: 未设置 SESSION_SECRET，使用随机密钥，重启后所有会话将失效")
+		return securecookie.GenerateRandomKey(32)
+	}
+	if len(secret) < 32 {
+		fmt.Println("警告: SESSION_SECRET 长度建议不少于32个字符")
+	}
+	return []byte(secret)
+}
 
 func homeHandler(c *gin.Context) {
 	// 从上下文获取用户信息
@@ -206,8 +240,6 @@ func homeHandler(c *gin.Context) {
 	var user *models.User
 	if exists && userObj != nil {
 		user = userObj.(*models.User)
-	} else {
-		fmt.Println("未获取到用户信息")
 	}
 	// 获取页码参数，默认为第1页
 	pageStr := c.Query("page")
@@ -226,30 +258,33 @@ func homeHandler(c *gin.Context) {
 
 	// 查询总记录数
 	var total int64
-	dbQuery := database.DB.Model(&models.Post{})
+	dbQuery := database.DB.Model(&models.Post{}).Scopes(policies.VisiblePosts(user))
 
-	var categoryId uint
+	// 分类页面同时列出子分类的文章
+	var currentCategory *models.Category
+	var categoryIDs []uint
 	if categoryType != "" {
 		var category models.Category
 	    if err := database.DB.Where("alias = ?", categoryType).First(&category).Error; err != nil {
             // 当找不到分类时，返回404页面而不是继续执行
-            c.HTML(http.StatusNotFound, "404.tmpl", gin.H{
+            responses.HTML(c, http.StatusNotFound, "404.tmpl", gin.H{
                 "Message": "分类未找到",
             })
             return
         } else {
-            categoryId = category.ID
-            dbQuery = dbQuery.Where("category_id = ?", categoryId)
+            currentCategory = &category
+            categoryIDs = handlers.CategoryAndChildren(&category)
+            dbQuery = dbQuery.Where("category_id IN ?", categoryIDs)
         }
 	}
 	dbQuery.Count(&total)
 
 	// 查询当前页的帖子
 	var posts []models.Post
-	postQuery := database.DB.Where("category_id > ?", 0).Order("created_at DESC").Offset(offset).Limit(limit)
+	postQuery := database.DB.Scopes(policies.VisiblePosts(user)).Where("category_id > ?", 0).Order("created_at DESC").Offset(offset).Limit(limit)
 
-	if categoryId > 0 {
-		postQuery = postQuery.Where("category_id = ?", categoryId)
+	if currentCategory != nil {
+		postQuery = postQuery.Where("category_id IN ?", categoryIDs)
 	}
 	postQuery.Find(&posts)
 
@@ -271,22 +306,12 @@ func homeHandler(c *gin.Context) {
 		})
 	}
 
-	// 获取在线用户数
-	var onlineCount int64
-	cutoffTime := time.Now().Add(-30 * time.Minute)
-	database.DB.Model(&models.UserOnlineStatus{}).
-		Where("last_active_time > ?", cutoffTime).
-		Count(&onlineCount)
-
-	// 获取站点统计信息
-	var userCount, postCount, commentCount int64
-	database.DB.Model(&models.User{}).Count(&userCount)
-	database.DB.Model(&models.Post{}).Count(&postCount)
-	database.DB.Model(&models.Comment{}).Count(&commentCount)
+	// 站点统计（用户数、文章数、评论数、在线人数），缓存1分钟
+	stats := handlers.CachedSiteStats()
 
 	// 获取所有分类
 	var categories []models.Category
-	database.DB.Where("status_code = ?", 1).Find(&categories)
+	database.DB.Scopes(policies.OrderedCategories()).Where("status_code = ?", 1).Find(&categories)
 
 	data := gin.H{
 		"CurrentTime":  time.Now().Format("2006-01-02 15:04:05"),
@@ -298,14 +323,18 @@ func homeHandler(c *gin.Context) {
 		"prevPage":     page - 1,
 		"nextPage":     page + 1,
 		"user":         user,
-		"userCount":    userCount,
-		"postCount":    postCount,
-		"commentCount": commentCount,
-		"onlineCount":  onlineCount,
+		"userCount":    stats.Users,
+		"postCount":    stats.Posts,
+		"commentCount": stats.Comments,
+		"onlineCount":  stats.Online,
 		"categories":   categories,
+		"category":     currentCategory,
+	}
+	if currentCategory != nil {
+		data["subcategories"] = handlers.Subcategories(currentCategory)
 	}
 
-	c.HTML(http.StatusOK, "home.tmpl", data)
+	responses.HTML(c, http.StatusOK, "home.tmpl", data)
 }
 
 func aboutHandler(c *gin.Context) {
@@ -319,7 +348,7 @@ func aboutHandler(c *gin.Context) {
 			{"王五", "产品经理"},
 		},
 	}
-	c.HTML(http.StatusOK, "about.tmpl", data)
+	responses.HTML(c, http.StatusOK, "about.tmpl", data)
 }
 
 func detailHandler(c *gin.Context) {
@@ -338,14 +367,39 @@ func detailHandler(c *gin.Context) {
 		id = idParts[0] // 获取 "29"
 	}
 
+	// 查询数据库获取文章详情，并预加载用户信息
+	var post models.Post
+	if err := database.DB.Preload("User").First(&post, id).Error; err != nil {
+		responses.HTML(c, http.StatusNotFound, "404.tmpl", gin.H{
+			"Message": "文章未找到",
+		})
+		return
+	}
+
+	// 检查阅读权限：待审核、未通过和私有文章对无权查看的人表现为不存在，等级不足时提示
+	if !policies.CanViewPost(user, &post) {
+		if policies.IsHiddenPost(user, &post) {
+			responses.HTML(c, http.StatusNotFound, "404.tmpl", gin.H{
+				"Message": "文章未找到",
+				"user":    user,
+			})
+			return
+		}
+		message := fmt.Sprintf("该文章需要 Lv%d 及以上等级才能阅读", policies.RequiredLevel(post.ReadLimit))
+		if user == nil {
+			message += "，请先登录"
+		}
+		responses.HTML(c, http.StatusForbidden, "403.tmpl", gin.H{
+			"Message": message,
+			"user":    user,
+		})
+		return
+	}
+
     UserId := handlers.UserIDFromContext(c)
-    postId, err := strconv.ParseUint(id, 10, 32)
-    if err != nil {
-        postId = 0
-    }
     // 发送浏览事件
     viewEvent := workers.ViewEvent{
-        PostID:     uint(postId),
+        PostID:    post.ID,
         UserID:    UserId,
         IP:        c.ClientIP(),
         UserAgent: c.Request.UserAgent(),
@@ -358,15 +412,6 @@ func detailHandler(c *gin.Context) {
         fmt.Println("浏览事件通道已满")
     }
 
-	// 查询数据库获取文章详情，并预加载用户信息
-	var post models.Post
-	if err := database.DB.Preload("User").First(&post, id).Error; err != nil {
-		c.HTML(http.StatusNotFound, "404.tmpl", gin.H{
-			"Message": "文章未找到",
-		})
-		return
-	}
-
 	// 创建带有友好时间和回复评论的评论结构
 	type CommentWithReplies struct {
 		models.Comment
@@ -390,14 +435,14 @@ func detailHandler(c *gin.Context) {
 
 	// 查询总评论数
 	var totalComments int64
-	database.DB.Model(&models.Comment{}).Where("post_id = ? AND parent_id = 0", id).Count(&totalComments)
+	database.DB.Model(&models.Comment{}).Scopes(policies.VisibleComments()).Where("post_id = ? AND parent_id = 0", id).Count(&totalComments)
 
 	// 计算总页数
 	totalCommentPages := int((totalComments + int64(commentLimit) - 1) / int64(commentLimit))
 
 	// 查询该文章的评论（仅顶级评论），并预加载用户信息
 	var comments []models.Comment
-	database.DB.Where("post_id = ? AND parent_id = 0", id).Preload("User").
+	database.DB.Scopes(policies.VisibleComments()).Where("post_id = ? AND parent_id = 0", id).Preload("User").
 		Offset(commentOffset).Limit(commentLimit).Order("created_at DESC").Find(&comments)
 
 	// 创建带有回复和友好时间的评论列表
@@ -408,7 +453,7 @@ func detailHandler(c *gin.Context) {
 
 		// 查询该评论的回复
 		var replies []models.Comment
-		database.DB.Where("parent_id = ?", comment.ID).Preload("User").Find(&replies)
+		database.DB.Scopes(policies.VisibleComments()).Where("parent_id = ?", comment.ID).Preload("User").Find(&replies)
 
 		// 处理回复评论的友好时间
 		var repliesWithTime []CommentWithReplies
@@ -417,7 +462,7 @@ func detailHandler(c *gin.Context) {
 			repliesWithTime = append(repliesWithTime, CommentWithReplies{
 				Comment: reply,
 				TimeAgo: replyTimeAgo,
-				Content: template.HTML(reply.Content),
+				Content: template.HTML(utils.SanitizeHTML(reply.Content)),
 			})
 		}
 
@@ -425,7 +470,7 @@ func detailHandler(c *gin.Context) {
 			Comment: comment,
 			TimeAgo: timeAgo,
 			Replies: repliesWithTime,
-			Content: template.HTML(comment.Content),
+			Content: template.HTML(utils.SanitizeHTML(comment.Content)),
 		})
 	}
 
@@ -438,17 +483,16 @@ func detailHandler(c *gin.Context) {
 	database.DB.Model(&models.Post{}).Where("user_id = ?", post.User.ID).Select("SUM(replies)").Row().Scan(&replyCount)
 	database.DB.Model(&models.Post{}).Where("user_id = ?", post.User.ID).Select("SUM(likes)").Row().Scan(&likeCount)
 	// 将评论分页信息添加到模板数据
-    fmt.Printf("当前文章ID: %s, 分类ID: %d\n", id, post.CategoryId)
 	// 搜索3条相关的文章数据
 	var relatedPosts []models.Post
-    database.DB.Where("id != ? AND category_id = ?", id, post.CategoryId).
+    database.DB.Scopes(policies.VisiblePosts(user)).Where("id != ? AND category_id = ?", id, post.CategoryId).
         Order("RAND()").
         Limit(3).
         Find(&relatedPosts)
 	data := gin.H{
 		"Post":               post,
 		"User":               post.User,
-		"Content":            template.HTML(post.Content),
+		"Content":            template.HTML(serializers.SafeContent(post.Content, post.Markdown)),
 		"Tags":               tags,
 		"user":               user,
 		"Comments":           commentsWithReplies,
@@ -465,7 +509,7 @@ func detailHandler(c *gin.Context) {
 		"RelatedPosts":       relatedPosts,
 	}
 
-	c.HTML(http.StatusOK, "detail.tmpl", data)
+	responses.HTML(c, http.StatusOK, "detail.tmpl", data)
 }
 
 func profileHandler(c *gin.Context) {
@@ -476,7 +520,7 @@ func profileHandler(c *gin.Context) {
 		user = userObj.(*models.User)
 	} else {
 		// 用户未登录，重定向到登录页面
-		c.Redirect(http.StatusFound, "/login")
+		redirectToLogin(c)
 		return
 	}
 
@@ -488,7 +532,7 @@ func profileHandler(c *gin.Context) {
 		"profileUser": user,
 		"postCount":   postCount,
 	}
-	c.HTML(http.StatusOK, "profile.tmpl", data)
+	responses.HTML(c, http.StatusOK, "profile.tmpl", data)
 }
 
 func articleHandler(c *gin.Context) {
@@ -499,7 +543,7 @@ func articleHandler(c *gin.Context) {
         user = userObj.(*models.User)
     } else {
         // 用户未登录，重定向到登录页面
-        c.Redirect(http.StatusFound, "/login")
+        redirectToLogin(c)
         return
     }
 
@@ -613,7 +657,7 @@ func articleHandler(c *gin.Context) {
         "prevPage":          page - 1,
         "nextPage":          page + 1,
     }
-    c.HTML(http.StatusOK, "article-list.tmpl", data)
+    responses.HTML(c, http.StatusOK, "article-list.tmpl", data)
 }
 
 // 辅助函数：根据tab获取对应的总页数
@@ -640,14 +684,44 @@ func settingsHandler(c *gin.Context) {
 		user = userObj.(*models.User)
 	} else {
 		// 用户未登录，重定向到登录页面
-		c.Redirect(http.StatusFound, "/login")
+		redirectToLogin(c)
 		return
 	}
 
+	tokens, _ := handlers.ListAccessTokens(user.ID)
+	userSessions, _ := handlers.ListUserSessions(user.ID)
+	loginFailures, _ := handlers.ListLoginFailures(user.ID, 10)
+	identities, _ := handlers.ListUserIdentities(user.ID)
+	linked := make(map[string]bool)
+	for _, identity := range identities {
+		linked[identity.Provider] = true
+	}
+
+	// 关联第三方账号后跳回设置页时的提示
+	session := sessions.Default(c)
+	flashes := gin.H{}
+	for _, key := range handlers.FlashKeys {
+		if messages := session.Flashes(key); len(messages) > 0 {
+			flashes[key] = messages[0]
+		}
+	}
+	if len(flashes) > 0 {
+		session.Save()
+	}
+
 	data := gin.H{
-		"user": user,
+		"user":              user,
+		"accessTokens":      tokens,
+		"tokenScopes":       models.AllScopes,
+		"userSessions":      serializers.NewSessions(userSessions, session.ID()),
+		"recoveryCodeCount": handlers.CountRecoveryCodes(user.ID),
+		"loginFailures":     serializers.NewLoginFailures(loginFailures),
+		"identities":        serializers.NewIdentities(identities),
+		"linkedProviders":   linked,
+		"oauthProviders":    handlers.OAuthProviders(),
+		"flashes":           flashes,
 	}
-	c.HTML(http.StatusOK, "settings.tmpl", data)
+	responses.HTML(c, http.StatusOK, "settings.tmpl", data)
 }
 
 func publishHandler(c *gin.Context) {
@@ -658,41 +732,44 @@ func publishHandler(c *gin.Context) {
 		user = userObj.(*models.User)
 	} else {
 		// 用户未登录，重定向到登录页面
-		c.Redirect(http.StatusFound, "/login")
+		redirectToLogin(c)
 		return
 	}
 
-	// 获取所有分类
-	var categories []models.Category
-	database.DB.Where("status_code = ?", 1).Find(&categories)
+	// 分类按上下级组织，等级不足的分类不能选择
+	categories := handlers.CategoryTree()
 
 	data := gin.H{
 		"user":       user,
 		"categories": categories,
+		"anyLevel":   user.Can(models.PermManagePosts),
 	}
-	c.HTML(http.StatusOK, "publish.tmpl", data)
+	responses.HTML(c, http.StatusOK, "publish.tmpl", data)
 }
 
 func registerHandler(c *gin.Context) {
 	data := gin.H{
-		"CurrentPath": "/register",
+		"CurrentPath":    "/register",
+		"oauthProviders": handlers.OAuthProviders(),
 	}
-	c.HTML(http.StatusOK, "auth.tmpl", data)
+	responses.HTML(c, http.StatusOK, "auth.tmpl", data)
 }
 
 func loginHandler(c *gin.Context) {
 	data := gin.H{
-		"CurrentPath": "/login",
+		"CurrentPath":    "/login",
+		"oauthProviders": handlers.OAuthProviders(),
 	}
-	c.HTML(http.StatusOK, "auth.tmpl", data)
+	responses.HTML(c, http.StatusOK, "auth.tmpl", data)
 }
 
 func logoutHandler(c *gin.Context) {
 	// 获取session
 	session := sessions.Default(c)
 
-	// 清除session中的用户信息
+	// 清除session中的用户信息，并删除服务端保存的会话
 	session.Clear()
+	session.Options(sessions.Options{Path: "/", MaxAge: -1})
 
 	// 保存session更改
 	if err := session.Save(); err != nil {
@@ -705,6 +782,11 @@ func logoutHandler(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/")
 }
 
+// redirectToLogin 跳转到登录页，登录后回到当前页面
+func redirectToLogin(c *gin.Context) {
+	c.Redirect(http.StatusFound, "/login?redirect_to="+url.QueryEscape(c.Request.URL.RequestURI()))
+}
+
 func loginSubmit(c *gin.Context) {
 
 	// 测试密码：xZ3(Uq)sDQ6qYEY]
@@ -712,72 +794,57 @@ func loginSubmit(c *gin.Context) {
 	identifier := c.PostForm("email") // 可以是邮箱或用户名
 	password := c.PostForm("password")
 	remember := c.PostForm("remember")
+	// 登录后返回的页面，只接受站内路径
+	redirectTo := utils.SafeRedirectPath(c.PostForm("redirect_to"))
+	if redirectTo == "" {
+		redirectTo = "/"
+	}
 
 	// 基本验证
 	if identifier == "" || password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "用户名/邮箱和密码不能为空",
-		})
+		responses.BadRequest(c, "用户名/邮箱和密码不能为空")
 		return
 	}
 
-	// 查询用户（支持邮箱或用户名登录）
-	var user models.User
-	if err := database.DB.Where("email = ? OR name = ?", identifier, identifier).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "用户不存在",
-		})
+	// 校验账号密码（支持邮箱或用户名登录），失败次数过多时需要等待
+	user, err := handlers.AuthenticatePassword(c, identifier, password)
+	if err != nil {
+		var throttled *handlers.LoginThrottledError
+		if errors.As(err, &throttled) {
+			responses.TooManyRequests(c, throttled.Wait, err.Error())
+			return
+		}
+		responses.Unauthorized(c, err.Error())
 		return
 	}
 
-	// 验证密码
-	if !utils.CheckPassword(password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "密码错误",
-		})
+	// 被封禁的账号或 IP 不能登录
+	if sanction := handlers.LoginSanction(c, user); sanction != nil {
+		handlers.SanctionFailed(c, sanction)
 		return
 	}
 
-	// 获取session
-	session := sessions.Default(c)
-
-	// 设置用户信息到session
-	session.Set("user_id", user.ID)
-
-	// 根据"记住密码"选项设置过期时间
-	if remember == "on" {
-		// 设置30天过期
-		session.Options(sessions.Options{
-			MaxAge: 30 * 24 * 60 * 60, // 30天
-		})
-	} else {
-		// 设置会话结束时失效（浏览器关闭时）
-		session.Options(sessions.Options{
-			MaxAge: 0, // 浏览器会话期间有效
-		})
-	}
-
-	// 保存session
-
-	// 保存session
-	if err := session.Save(); err != nil {
+	// 建立登录会话，"记住密码"时保持30天；启用两步验证的用户还需要输入验证码
+	twoFactorRequired, err := handlers.StartLogin(c, user, remember == "on", redirectTo)
+	if err != nil {
 		fmt.Printf("Session保存失败: %v\n", err)
-	} else {
-		fmt.Println("Session保存成功")
+		responses.Internal(c, "登录失败，请稍后重试")
+		return
+	}
+	if twoFactorRequired {
+		responses.OK(c, "请输入两步验证码", gin.H{
+			"two_factor_required": true,
+			"redirect":            "/login/two-factor",
+		})
+		return
 	}
 
 	// 登录成功
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "登录成功",
-		"data": gin.H{
-			"user_id": user.ID,
-			"email":   user.Email,
-			"name":    user.Name,
-		},
+	responses.OK(c, "登录成功", gin.H{
+		"user_id":  user.ID,
+		"email":    user.Email,
+		"name":     user.Name,
+		"redirect": redirectTo,
 	})
 
 }
@@ -792,18 +859,12 @@ func registerSubmit(c *gin.Context) {
 
 	// 基本验证
 	if username == "" || email == "" || password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "用户名、邮箱和密码不能为空",
-		})
+		responses.BadRequest(c, "用户名、邮箱和密码不能为空")
 		return
 	}
 
 	if password != confirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "两次输入的密码不一致",
-		})
+		responses.BadRequest(c, "两次输入的密码不一致")
 		return
 	}
 
@@ -814,30 +875,21 @@ func registerSubmit(c *gin.Context) {
 	}
 
 	if !isAgreeTerms {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "请同意用户协议",
-		})
+		responses.BadRequest(c, "请同意用户协议")
 		return
 	}
 
 	// 检查用户是否已存在
 	var existingUser models.User
 	if err := database.DB.Where("email = ?", email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "该邮箱已被注册",
-		})
+		responses.BadRequest(c, "该邮箱已被注册")
 		return
 	}
 
 	// 密码加密
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "密码加密失败",
-		})
+		responses.Internal(c, "密码加密失败")
 		return
 	}
 	// 随机一个avatar图像
@@ -846,128 +898,32 @@ func registerSubmit(c *gin.Context) {
 
 	// 创建新用户
 	newUser := models.User{
-		Name:       username,
-		Email:      email,
-		Password:   hashedPassword,
-		AgreeTerms: isAgreeTerms,
-		Avatar:     avatarURL,
+		Name:        username,
+		Email:       email,
+		Password:    hashedPassword,
+		PasswordSet: true,
+		AgreeTerms:  isAgreeTerms,
+		Avatar:      avatarURL,
 	}
 
 	// 保存到数据库
 	if err := database.DB.Create(&newUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "用户注册失败",
-		})
+		responses.Internal(c, "用户注册失败")
 		return
 	}
 
-	// 返回响应
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Login data received",
-		"data": gin.H{
-			"user_id": newUser.ID,
-			"email":   newUser.Email,
-		},
-	})
-}
-
-func searchPostsHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
-	} else {
-		fmt.Println("未获取到用户信息")
-	}
-	// 获取页码参数，默认为第1页
-	pageStr := c.Query("page")
-	// 获取路由categories/:type中type参数
-	qStr := c.Query("q")
-	page := 1
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	// 发送验证邮件，验证之前不能发帖和评论
+	message := "注册成功，验证邮件已发送到您的邮箱"
+	if err := handlers.SendEmailVerification(&newUser, newUser.Email, models.EmailVerifyRegister); err != nil {
+		fmt.Printf("发送验证邮件失败: %v\n", err)
+		message = "注册成功，验证邮件发送失败，请登录后在账户设置中重新发送"
 	}
 
-	// 每页显示的帖子数量
-	limit := 10
-	offset := (page - 1) * limit
-
-	// 查询总记录数
-	var total int64
-	database.DB.Model(&models.Post{}).Count(&total)
-
-	// 查询当前页的帖子
-	var posts []models.Post
-	postQuery := database.DB.Order("created_at DESC").Offset(offset).Limit(limit)
-
-	// 修改查询条件为模糊搜索
-	postQuery = postQuery.Where("title LIKE ?", "%"+qStr+"%")
-
-	postQuery.Find(&posts)
-
-	// 计算总页数
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-
-	// 创建带有友好时间的帖子结构
-	type PostWithFriendlyTime struct {
-		models.Post
-		TimeAgo string
-	}
-
-	var postsWithTimeAgo []PostWithFriendlyTime
-	for _, post := range posts {
-		timeAgo := utils.GetTimeAgo(post.CreatedAt)
-		postsWithTimeAgo = append(postsWithTimeAgo, PostWithFriendlyTime{
-			Post:    post,
-			TimeAgo: timeAgo,
-		})
-	}
-
-	// 获取在线用户数
-	var onlineCount int64
-	cutoffTime := time.Now().Add(-30 * time.Minute)
-	database.DB.Model(&models.UserOnlineStatus{}).
-		Where("last_active_time > ?", cutoffTime).
-		Count(&onlineCount)
-
-	// 获取站点统计信息
-	var userCount, postCount, commentCount int64
-	database.DB.Model(&models.User{}).Count(&userCount)
-	database.DB.Model(&models.Post{}).Count(&postCount)
-	database.DB.Model(&models.Comment{}).Count(&commentCount)
-
-	// 获取所有分类
-	var categories []models.Category
-	database.DB.Where("status_code = ?", 1).Find(&categories)
-
-	// 1、统计注册用户
-	// 2、统计文章数量
-	// 3、统计回复评论数量
-	// 4、数据表categories查询所有分类
-
-	data := gin.H{
-		"CurrentTime":  time.Now().Format("2006-01-02 15:04:05"),
-		"posts":        postsWithTimeAgo,
-		"currentPage":  page,
-		"totalPages":   totalPages,
-		"hasPrev":      page > 1,
-		"hasNext":      page < totalPages,
-		"prevPage":     page - 1,
-		"nextPage":     page + 1,
-		"user":         user,
-		"userCount":    userCount,
-		"postCount":    postCount,
-		"commentCount": commentCount,
-		"onlineCount":  onlineCount,
-		"categories":   categories,
-	}
-
-	c.HTML(http.StatusOK, "search.tmpl", data)
+	// 返回响应
+	responses.OK(c, message, gin.H{
+		"user_id": newUser.ID,
+		"email":   newUser.Email,
+	})
 }
 
 func searchUsersHandler(c *gin.Context) {
@@ -1040,13 +996,13 @@ func searchUsersHandler(c *gin.Context) {
 		"searchKeyword": qStr,
 	}
 
-	c.HTML(http.StatusOK, "member.tmpl", data)
+	responses.HTML(c, http.StatusOK, "member.tmpl", data)
 }
 
 func rssHandler(c *gin.Context) {
     // 查询最新的帖子
     var posts []models.Post
-    database.DB.Order("created_at DESC").Limit(20).Find(&posts)
+    database.DB.Scopes(policies.VisiblePosts(nil)).Order("created_at DESC").Limit(20).Find(&posts)
 
     // 获取当前域名
     scheme := "http"
@@ -1099,7 +1055,7 @@ func rssHandler(c *gin.Context) {
         rss.Channel.Items = append(rss.Channel.Items, RSSItem{
             Title:       post.Title,
             Link:        postLink,
-            Description: fmt.Sprintf("<![CDATA[%s]]>", post.Content),
+            Description: fmt.Sprintf("<![CDATA[%s]]>", serializers.SafeContent(post.Content, post.Markdown)),
             PubDate:     post.CreatedAt,
             GUID:        postLink,
         })
@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"gin-doniai/stores"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP 把 gin 按受信任代理设置解析出的客户端IP放入请求上下文，会话存储据此记录登录设备的IP，
+// 需要注册在 sessions 中间件之前
+func ClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = stores.WithClientIP(c.Request, c.ClientIP())
+		c.Next()
+	}
+}
@@ -0,0 +1,17 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated 标记旧版 API 已废弃，并通过 Link 头指向替代的新版本地址
+func Deprecated(from, to string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		successor := to + strings.TrimPrefix(c.Request.URL.Path, from)
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legacyResponseKeys 旧版 /api 接口成功时存放数据的字段，键为请求方法和去掉前缀的路由。
+// 值为空字符串时把 data 中的字段展开到顶层；未列出的接口旧版本就使用 data 字段
+var legacyResponseKeys = map[string]string{
+	"GET /online/count":        "",
+	"POST /posts":              "post",
+	"GET /posts":               "posts",
+	"GET /posts/:id":           "post",
+	"PUT /posts/:id":           "post",
+	"POST /posts/:id/like":     "",
+	"POST /posts/:id/favorite": "",
+	"POST /users":              "user",
+	"GET /users":               "users",
+	"GET /users/:id":           "user",
+	"PUT /users/:id":           "user",
+	"POST /comments/:id/like":  "",
+}
+
+// LegacyResponse 把统一响应格式转换为旧版 /api 接口的格式，与 Deprecated 一起用于兼容旧地址：
+// 保留 success、message 和 data，失败时增加 error，旧版本放在其他字段中的数据按 legacyResponseKeys 补上，
+// 列表同时返回 count。不去掉 from 前缀的新版本地址不受影响
+func LegacyResponse(from string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &legacyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if !w.buffered {
+			return
+		}
+		body := w.buf.Bytes()
+		if legacy, ok := legacyBody(c.Request.Method+" "+strings.TrimPrefix(c.FullPath(), from), body); ok {
+			body = legacy
+		}
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// legacyWriter 缓存 JSON 响应，请求处理完后再转换写出；其他类型的响应（如导出文件）直接写出
+type legacyWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	decided  bool
+	buffered bool
+}
+
+func (w *legacyWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffered = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if !w.buffered {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *legacyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// legacyBody 转换统一格式的响应，不是统一格式时返回 false
+func legacyBody(route string, body []byte) ([]byte, bool) {
+	var envelope struct {
+		Success bool            `json:"success"`
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Code == "" {
+		return nil, false
+	}
+
+	out := map[string]interface{}{
+		"success": envelope.Success,
+		"message": envelope.Message,
+	}
+	if !envelope.Success {
+		out["error"] = envelope.Message
+	} else if len(envelope.Data) > 0 {
+		out["data"] = envelope.Data
+		if key, ok := legacyResponseKeys[route]; ok {
+			if key == "" {
+				var fields map[string]json.RawMessage
+				if json.Unmarshal(envelope.Data, &fields) == nil {
+					for name, value := range fields {
+						if _, exists := out[name]; !exists {
+							out[name] = value
+						}
+					}
+				}
+			} else {
+				out[key] = envelope.Data
+				var items []json.RawMessage
+				if json.Unmarshal(envelope.Data, &items) == nil {
+					out["count"] = len(items)
+				}
+			}
+		}
+	}
+
+	legacy, err := json.Marshal(out)
+	if err != nil {
+		return nil, false
+	}
+	return legacy, true
+}
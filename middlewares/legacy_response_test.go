@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"gin-doniai/responses"
+
+	"github.com/gin-gonic/gin"
+)
+
+func legacyTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	register := func(api *gin.RouterGroup) {
+		api.GET("/posts", func(c *gin.Context) {
+			responses.List(c, []gin.H{{"id": 1}, {"id": 2}}, responses.NewMeta(1, 20, 2))
+		})
+		api.GET("/posts/:id", func(c *gin.Context) {
+			if c.Param("id") != "1" {
+				responses.NotFound(c, "文章不存在")
+				return
+			}
+			responses.OK(c, "", gin.H{"id": 1, "title": "标题"})
+		})
+		api.POST("/posts/:id/like", func(c *gin.Context) {
+			responses.OK(c, "操作成功", gin.H{"likes": 3})
+		})
+		api.GET("/comments/:id", func(c *gin.Context) {
+			responses.OK(c, "", gin.H{"id": 5})
+		})
+		api.GET("/export", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/x-ndjson", []byte("{\"id\":1}\n"))
+		})
+		api.GET("/forbidden", RequireLogin(), func(c *gin.Context) {})
+	}
+	register(router.Group("/api/v1"))
+	register(router.Group("/api", Deprecated("/api", "/api/v1"), LegacyResponse("/api")))
+	return router
+}
+
+func TestLegacyResponse(t *testing.T) {
+	router := legacyTestRouter()
+	tests := []struct {
+		method string
+		path   string
+		status int
+		want   string
+	}{
+		{"GET", "/api/posts", 200, `{"count":2,"data":[{"id":1},{"id":2}],"message":"","posts":[{"id":1},{"id":2}],"success":true}`},
+		{"GET", "/api/posts/1", 200, `{"data":{"id":1,"title":"标题"},"message":"","post":{"id":1,"title":"标题"},"success":true}`},
+		{"GET", "/api/posts/2", 404, `{"error":"文章不存在","message":"文章不存在","success":false}`},
+		{"POST", "/api/posts/1/like", 200, `{"data":{"likes":3},"likes":3,"message":"操作成功","success":true}`},
+		{"GET", "/api/comments/5", 200, `{"data":{"id":5},"message":"","success":true}`},
+		{"GET", "/api/forbidden", 401, `{"error":"用户未登录","message":"用户未登录","success":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d，期望 %d", w.Code, tt.status)
+			}
+			assertJSON(t, w.Body.Bytes(), tt.want)
+			if w.Header().Get("Deprecation") != "true" {
+				t.Error("缺少 Deprecation 响应头")
+			}
+		})
+	}
+}
+
+func TestLegacyResponseLeavesV1AndNonJSON(t *testing.T) {
+	router := legacyTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/posts/1", nil))
+	assertJSON(t, w.Body.Bytes(), `{"success":true,"code":"OK","message":"","data":{"id":1,"title":"标题"}}`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/export", nil))
+	if got := w.Body.String(); got != "{\"id\":1}\n" {
+		t.Errorf("非 JSON 响应 = %q，期望原样返回", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func assertJSON(t *testing.T, body []byte, want string) {
+	t.Helper()
+	var got, expected interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("响应不是 JSON: %s", body)
+	}
+	json.Unmarshal([]byte(want), &expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("响应 = %s，期望 %s", body, want)
+	}
+}
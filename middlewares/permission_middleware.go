@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"gin-doniai/models"
+	"gin-doniai/responses"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser 从上下文获取当前登录用户
+func currentUser(c *gin.Context) *models.User {
+	if userObj, exists := c.Get("user"); exists && userObj != nil {
+		if user, ok := userObj.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// RequireLogin 要求用户已登录
+func RequireLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentUser(c) == nil {
+			responses.Abort(c, http.StatusUnauthorized, responses.CodeUnauthorized, "用户未登录")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 要求当前用户拥有指定权限
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+		if user == nil {
+			responses.Abort(c, http.StatusUnauthorized, responses.CodeUnauthorized, "用户未登录")
+			return
+		}
+		if !user.Can(perm) {
+			responses.Abort(c, http.StatusForbidden, responses.CodeForbidden, "没有操作权限")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail 要求当前用户已验证邮箱，未验证的账户不能发帖和评论
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+		if user == nil {
+			responses.Abort(c, http.StatusUnauthorized, responses.CodeUnauthorized, "用户未登录")
+			return
+		}
+		if !user.EmailVerified {
+			responses.Abort(c, http.StatusForbidden, responses.CodeEmailUnverified, "请先验证邮箱，验证邮件可以在账户设置中重新发送")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/policies"
+	"gin-doniai/responses"
+
+	"github.com/gin-gonic/gin"
+)
+
+// muteContextKey 当前用户生效中的禁言保存在上下文中的键
+const muteContextKey = "sanction_mute"
+
+// applySanctions 检查当前用户和 IP 的处罚：封禁的账号拒绝访问（退出登录除外），
+// 封禁的 IP 拒绝登录、注册和提交修改，禁言时把处罚保存到上下文，由 RequireNotMuted 拦截发帖和评论。
+// 返回 false 表示请求已被拒绝。
+func applySanctions(c *gin.Context, user *models.User) bool {
+	if strings.HasPrefix(c.Request.URL.Path, "/static") {
+		return true
+	}
+
+	if user != nil {
+		var sanctions []models.UserSanction
+		database.DB.Scopes(policies.ActiveSanctions()).
+			Where("user_id = ? AND type IN ?", user.ID, []string{models.SanctionBan, models.SanctionMute}).
+			Order("expires_at IS NULL DESC, expires_at DESC").
+			Find(&sanctions)
+		for i := range sanctions {
+			if sanctions[i].Type == models.SanctionBan && c.Request.URL.Path != "/logout" {
+				rejectSanctioned(c, responses.CodeAccountBanned, &sanctions[i])
+				return false
+			}
+		}
+		for i := range sanctions {
+			if sanctions[i].Type == models.SanctionMute {
+				c.Set(muteContextKey, &sanctions[i])
+				c.Set(responses.SanctionNoticeKey, sanctions[i].Notice())
+				break
+			}
+		}
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	var ban models.UserSanction
+	err := database.DB.Scopes(policies.ActiveSanctions()).
+		Where("type = ? AND ip_address = ?", models.SanctionIPBan, c.ClientIP()).
+		First(&ban).Error
+	if err == nil {
+		rejectSanctioned(c, responses.CodeIPBanned, &ban)
+		return false
+	}
+	return true
+}
+
+// rejectSanctioned 拒绝被处罚的请求，接口和修改请求返回 JSON，页面请求显示处罚说明
+func rejectSanctioned(c *gin.Context, code responses.ErrorCode, sanction *models.UserSanction) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") || c.Request.Method != http.MethodGet {
+		responses.Abort(c, http.StatusForbidden, code, sanction.Notice())
+		return
+	}
+	responses.HTML(c, http.StatusForbidden, "403.tmpl", gin.H{"Message": sanction.Notice()})
+	c.Abort()
+}
+
+// RequireNotMuted 被禁言的用户不能发帖和评论，需要放在 RequireLogin 之后
+func RequireNotMuted() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mute, ok := c.Get(muteContextKey); ok {
+			responses.Abort(c, http.StatusForbidden, responses.CodeAccountMuted, mute.(*models.UserSanction).Notice())
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/responses"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 最近使用时间的更新间隔，避免每个请求都写库
+const tokenTouchInterval = time.Minute
+
+// bearerToken 从 Authorization 头中取出 Bearer 令牌
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
+
+// authenticateToken 校验个人访问令牌，成功时返回令牌（已加载所属用户）
+func authenticateToken(c *gin.Context, raw string) *models.AccessToken {
+	if raw == "" {
+		return nil
+	}
+	var token models.AccessToken
+	if err := database.DB.Preload("User").Where("token_hash = ?", utils.HashToken(raw)).First(&token).Error; err != nil {
+		return nil
+	}
+	// 用户已被删除时 Preload 不会加载到用户
+	if token.IsExpired() || token.User.ID == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > tokenTouchInterval {
+		database.DB.Model(&token).UpdateColumns(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": c.ClientIP(),
+		})
+	}
+	return &token
+}
+
+// currentToken 获取当前请求使用的访问令牌，会话登录时返回 nil
+func currentToken(c *gin.Context) *models.AccessToken {
+	if tokenObj, exists := c.Get("access_token"); exists {
+		if token, ok := tokenObj.(*models.AccessToken); ok {
+			return token
+		}
+	}
+	return nil
+}
+
+// RequireScope 使用访问令牌时要求令牌拥有指定权限范围，会话登录的用户不受限制
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := currentToken(c); token != nil && !token.HasScope(scope) {
+			responses.Abort(c, http.StatusForbidden, responses.CodeForbidden, "访问令牌缺少权限: "+scope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession 要求通过浏览器会话登录，令牌管理、修改密码等敏感操作不允许使用访问令牌
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentUser(c) == nil {
+			responses.Abort(c, http.StatusUnauthorized, responses.CodeUnauthorized, "用户未登录")
+			return
+		}
+		if currentToken(c) != nil {
+			responses.Abort(c, http.StatusForbidden, responses.CodeForbidden, "该操作不支持访问令牌，请登录后在网页中操作")
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,9 +2,11 @@ package middlewares
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"gin-doniai/database"
 	"gin-doniai/models"
+	"gin-doniai/responses"
 	"gin-doniai/workers"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -14,6 +16,22 @@ import (
 // UserAndOnlineStatusMiddleware 合并的用户信息和在线状态中间件
 func UserAndOnlineStatusMiddleware(onlineStatusChan chan workers.OnlineStatusUpdate) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API 请求可以使用个人访问令牌代替会话，令牌无效时直接拒绝而不回退到会话
+		if raw, ok := bearerToken(c); ok && strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			token := authenticateToken(c, raw)
+			if token == nil {
+				responses.Abort(c, http.StatusUnauthorized, responses.CodeUnauthorized, "访问令牌无效或已过期")
+				return
+			}
+			c.Set("user", &token.User)
+			c.Set("access_token", token)
+			if !applySanctions(c, &token.User) {
+				return
+			}
+			c.Next()
+			return
+		}
+
 		session := sessions.Default(c)
 		userID := session.Get("user_id")
 
@@ -31,23 +49,26 @@ func UserAndOnlineStatusMiddleware(onlineStatusChan chan workers.OnlineStatusUpd
 					user = &currentUser
 				}
 			}
-		} else {
-			// 当没有user_id时，不进行重定向以避免循环重定向
-			// 只是简单地设置user为nil
-			fmt.Println("Middleware - Session中没有user_id")
 		}
+		// 没有 user_id 时 user 为 nil，不进行重定向以避免循环重定向
 
 		// 设置用户信息到上下文
 		if user != nil {
             c.Set("user", user)
         }
 
+		// 封禁的账号和 IP 在这里拒绝，禁言由发帖、评论路由上的 RequireNotMuted 拦截
+		if !applySanctions(c, user) {
+			return
+		}
+
 		// 处理在线状态更新（只对非静态资源请求处理）
 		if user != nil && !strings.HasPrefix(c.Request.URL.Path, "/static") {
 			// 发送在线状态更新消息到队列
 			select {
 			case onlineStatusChan <- workers.OnlineStatusUpdate{
 				UserID:    user.ID,
+				SessionID: session.ID(),
 				IP:        c.ClientIP(),
 				UserAgent: c.GetHeader("User-Agent"),
 			}:
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 个人访问令牌的权限范围
+const (
+	ScopePostsRead     = "posts:read"     // 读取文章和评论（包括受等级限制的文章）
+	ScopePostsWrite    = "posts:write"    // 发布、修改、删除、点赞、收藏文章
+	ScopeCommentsWrite = "comments:write" // 发表、修改、删除、点赞评论
+	ScopeProfileWrite  = "profile:write"  // 修改个人资料
+	ScopeAdmin         = "admin"          // 完全访问，包括管理接口
+)
+
+// AllScopes 全部可选的权限范围及说明
+var AllScopes = []struct {
+	Name  string
+	Label string
+}{
+	{ScopePostsRead, "读取文章和评论"},
+	{ScopePostsWrite, "发布和管理文章"},
+	{ScopeCommentsWrite, "发表和管理评论"},
+	{ScopeProfileWrite, "修改个人资料"},
+	{ScopeAdmin, "完全访问（包括管理接口）"},
+}
+
+// IsValidScope 判断权限范围是否有效
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s.Name == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessToken 个人访问令牌，只保存令牌的 SHA-256 摘要
+type AccessToken struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"index;not null"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	TokenHash  string         `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string         `json:"prefix" gorm:"size:16"`  // 令牌前几位，便于用户辨认
+	Scopes     string         `json:"scopes" gorm:"size:255"` // 逗号分隔
+	ExpiresAt  *time.Time     `json:"expires_at"`             // 为空表示永不过期
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	LastUsedIP string         `json:"last_used_ip" gorm:"size:45"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"` // 撤销即软删除
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// 表名
+func (AccessToken) TableName() string {
+	return "access_tokens"
+}
+
+// ScopeList 返回权限范围列表
+func (t *AccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope 判断令牌是否拥有指定权限范围，admin 包含所有权限
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired 令牌是否已过期
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
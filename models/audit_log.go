@@ -0,0 +1,214 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计日志的操作类型，格式为「对象.操作」
+const (
+	AuditLogin            = "user.login"             // 登录成功（密码或第三方账号，启用两步验证时在第二步完成后记录）
+	AuditPasswordChange   = "user.password_change"   // 在账户设置中修改密码
+	AuditPasswordReset    = "user.password_reset"    // 通过邮件链接重置密码
+	AuditTwoFactorEnable  = "user.two_factor_enable" // 启用两步验证
+	AuditTwoFactorDisable = "user.two_factor_disable"
+	AuditIdentityLink     = "user.identity_link" // 关联第三方账号
+	AuditIdentityUnlink   = "user.identity_unlink"
+	AuditTokenCreate      = "user.token_create" // 创建个人访问令牌
+	AuditTokenDelete      = "user.token_delete"
+	AuditUserCreate       = "user.create" // 管理员创建用户
+	AuditUserUpdate       = "user.update" // 管理员修改用户资料或角色
+	AuditUserDelete       = "user.delete"
+	AuditUserRestore      = "user.restore"
+	AuditUserForceDelete  = "user.force_delete"
+
+	AuditPostUpdate      = "post.update" // 版主修改他人的文章
+	AuditPostDelete      = "post.delete"
+	AuditPostRestore     = "post.restore"
+	AuditPostForceDelete = "post.force_delete"
+	AuditPostMove        = "post.move"   // 修改文章分类
+	AuditPostStatus      = "post.status" // 审核或修改文章状态
+
+	AuditCommentUpdate  = "comment.update"
+	AuditCommentDelete  = "comment.delete"
+	AuditCommentRestore = "comment.restore"
+	AuditCommentStatus  = "comment.status"
+
+	AuditCategoryCreate  = "category.create"
+	AuditCategoryUpdate  = "category.update"
+	AuditCategoryDelete  = "category.delete"
+	AuditCategoryRestore = "category.restore"
+	AuditCategorySort    = "category.sort"
+
+	AuditReportHandle   = "report.handle" // 处理举报
+	AuditWordCreate     = "sensitive_word.create"
+	AuditWordUpdate     = "sensitive_word.update"
+	AuditWordDelete     = "sensitive_word.delete"
+	AuditSanctionCreate = "sanction.create"
+	AuditSanctionRevoke = "sanction.revoke"
+	AuditLogExport      = "audit.export" // 导出审计日志
+	AuditTrashPurge     = "trash.purge"  // 定时任务永久删除回收站中过期的内容
+)
+
+// 审计日志的对象类型
+const (
+	AuditTargetUser          = "user"
+	AuditTargetPost          = "post"
+	AuditTargetComment       = "comment"
+	AuditTargetCategory      = "category"
+	AuditTargetReport        = "report"
+	AuditTargetSensitiveWord = "sensitive_word"
+	AuditTargetSanction      = "sanction"
+	AuditTargetAuditLog      = "audit_log"
+)
+
+// AuditTargetTypes 对象类型和中文说明
+var AuditTargetTypes = []struct {
+	Type  string
+	Label string
+}{
+	{AuditTargetUser, "用户"},
+	{AuditTargetPost, "文章"},
+	{AuditTargetComment, "评论"},
+	{AuditTargetCategory, "分类"},
+	{AuditTargetReport, "举报"},
+	{AuditTargetSensitiveWord, "敏感词"},
+	{AuditTargetSanction, "处罚"},
+	{AuditTargetAuditLog, "审计日志"},
+}
+
+// AuditActions 操作类型和中文说明，按后台筛选列表中的顺序排列
+var AuditActions = []struct {
+	Action string
+	Label  string
+}{
+	{AuditLogin, "登录"},
+	{AuditPasswordChange, "修改密码"},
+	{AuditPasswordReset, "重置密码"},
+	{AuditTwoFactorEnable, "启用两步验证"},
+	{AuditTwoFactorDisable, "关闭两步验证"},
+	{AuditIdentityLink, "关联第三方账号"},
+	{AuditIdentityUnlink, "解除关联第三方账号"},
+	{AuditTokenCreate, "创建访问令牌"},
+	{AuditTokenDelete, "删除访问令牌"},
+	{AuditUserCreate, "创建用户"},
+	{AuditUserUpdate, "修改用户"},
+	{AuditUserDelete, "删除用户"},
+	{AuditUserRestore, "恢复用户"},
+	{AuditUserForceDelete, "永久删除用户"},
+	{AuditPostUpdate, "修改文章"},
+	{AuditPostDelete, "删除文章"},
+	{AuditPostRestore, "恢复文章"},
+	{AuditPostForceDelete, "永久删除文章"},
+	{AuditPostMove, "移动文章"},
+	{AuditPostStatus, "修改文章状态"},
+	{AuditCommentUpdate, "修改评论"},
+	{AuditCommentDelete, "删除评论"},
+	{AuditCommentRestore, "恢复评论"},
+	{AuditCommentStatus, "修改评论状态"},
+	{AuditCategoryCreate, "新建分类"},
+	{AuditCategoryUpdate, "修改分类"},
+	{AuditCategoryDelete, "删除分类"},
+	{AuditCategoryRestore, "恢复分类"},
+	{AuditCategorySort, "分类排序"},
+	{AuditReportHandle, "处理举报"},
+	{AuditWordCreate, "添加敏感词"},
+	{AuditWordUpdate, "修改敏感词"},
+	{AuditWordDelete, "删除敏感词"},
+	{AuditSanctionCreate, "处罚用户"},
+	{AuditSanctionRevoke, "解除处罚"},
+	{AuditLogExport, "导出审计日志"},
+	{AuditTrashPurge, "清理回收站"},
+}
+
+// ErrAuditLogReadOnly 审计日志只能新增，不能修改或删除
+var ErrAuditLogReadOnly = errors.New("audit logs are append-only")
+
+// AuditChange 一个字段修改前后的值，新建时 From 为空，删除时 To 为空
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// FromText 修改前的值，用于页面展示
+func (c AuditChange) FromText() string {
+	return auditValueText(c.From)
+}
+
+// ToText 修改后的值，用于页面展示
+func (c AuditChange) ToText() string {
+	return auditValueText(c.To)
+}
+
+func auditValueText(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprint(value)
+}
+
+// AuditLog 审计日志，记录管理操作和账号安全相关的操作
+type AuditLog struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ActorID     uint      `json:"actor_id" gorm:"index"`     // 执行操作的用户
+	ActorName   string    `json:"actor_name" gorm:"size:50"` // 操作时的用户名，用户改名或被删除后仍能看到
+	Action      string    `json:"action" gorm:"size:50;not null;index"`
+	TargetType  string    `json:"target_type" gorm:"size:20;index:idx_audit_logs_target"`
+	TargetID    uint      `json:"target_id" gorm:"index:idx_audit_logs_target"`
+	TargetLabel string    `json:"target_label" gorm:"size:255"` // 操作时对象的标题或名称
+	Changes     string    `json:"changes" gorm:"type:text"`     // 修改前后的字段，JSON 格式
+	IPAddress   string    `json:"ip_address" gorm:"size:45;index"`
+	UserAgent   string    `json:"user_agent" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// 表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// BeforeUpdate 禁止修改审计日志
+func (AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogReadOnly
+}
+
+// BeforeDelete 禁止删除审计日志
+func (AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogReadOnly
+}
+
+// ActionLabel 操作类型的中文说明
+func (l AuditLog) ActionLabel() string {
+	for _, a := range AuditActions {
+		if a.Action == l.Action {
+			return a.Label
+		}
+	}
+	return l.Action
+}
+
+// TargetTypeLabel 对象类型的中文说明
+func (l AuditLog) TargetTypeLabel() string {
+	for _, t := range AuditTargetTypes {
+		if t.Type == l.TargetType {
+			return t.Label
+		}
+	}
+	return l.TargetType
+}
+
+// ChangeSet 解析修改前后的字段，没有记录或格式错误时返回 nil
+func (l AuditLog) ChangeSet() map[string]AuditChange {
+	if l.Changes == "" {
+		return nil
+	}
+	var changes map[string]AuditChange
+	if err := json.Unmarshal([]byte(l.Changes), &changes); err != nil {
+		return nil
+	}
+	return changes
+}
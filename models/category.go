@@ -8,8 +8,14 @@ import (
 
 type Category struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
+	ParentID      uint           `json:"parent_id" gorm:"default:0;index"` // 上级分类，0 表示顶级分类，只支持两级
 	Name          string         `json:"name" gorm:"size:40;not null"`
 	Alias         string         `json:"alias" gorm:"size:40"`
+	Description   string         `json:"description" gorm:"size:255"`
+	Icon          string         `json:"icon" gorm:"size:32"`             // 图标，emoji 或简短文字
+	Color         string         `json:"color" gorm:"size:7"`             // 主题色，如 #1a73e8
+	SortOrder     int            `json:"sort_order" gorm:"default:0"`     // 手动排序，数字小的在前
+	MinPostLevel  int            `json:"min_post_level" gorm:"default:0"` // 发帖所需的最低用户等级，0 表示不限
 	IsRecommended bool           `json:"is_recommended" gorm:"default:false"`
 	RecommendRank int            `json:"recommend_rank" gorm:"default:0"`
 	StatusCode    int            `json:"status_code" gorm:"default:1"` // 1:正常 2:禁用 3:待审核
@@ -7,19 +7,24 @@ import (
 
 type Comment struct {
     ID        uint           `json:"id" gorm:"primaryKey"`
-    Content   string         `json:"content" gorm:"type:text;not null"`    // 评论内容
+    Content   string         `json:"content" gorm:"type:text;not null"`    // 评论内容（渲染后的HTML）
+    Markdown  string         `json:"markdown" gorm:"type:text"`            // 评论Markdown原文
     PostID    uint           `json:"post_id" gorm:"not null"`              // 关联的文章ID
     UserID    uint           `json:"user_id" gorm:"not null"`              // 评论用户ID
     ParentID  uint           `json:"parent_id" gorm:"default:0"`           // 父评论ID(用于回复)
     IsRecommended bool       `json:"is_recommended" gorm:"default:false"`  // 是否推荐
     RecommendRank int        `json:"recommend_rank" gorm:"default:0"`      // 推荐排序
-    StatusCode int           `json:"status_code" gorm:"default:1"`         // 1:正常 2:禁用 3:待审核
+    StatusCode int           `json:"status_code" gorm:"default:1;index"`   // 1:正常 2:禁用 3:待审核
+    ModerationReason string  `json:"moderation_reason" gorm:"size:255"`    // 进入审核或被拒绝的原因
+    ReviewedBy uint          `json:"reviewed_by" gorm:"default:0"`         // 审核人，0 表示未经人工审核
+    ReviewedAt *time.Time    `json:"reviewed_at"`
     LikeCount    int         `json:"like_count" gorm:"default:0"`    // 点赞数
     DislikeCount int         `json:"dislike_count" gorm:"default:0"` // 反对数
     ReplyCount   int         `json:"reply_count" gorm:"default:0"`   // 回复数
     CreatedAt time.Time      `json:"created_at"`
     UpdatedAt time.Time      `json:"updated_at"`
     DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+    DeletedBy uint           `json:"deleted_by" gorm:"default:0"` // 删除人，作者只能恢复自己删除的评论
 
     // 添加用户关联字段
     User      User           `json:"user" gorm:"foreignKey:UserID"`
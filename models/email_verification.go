@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// 邮箱验证的用途
+const (
+	EmailVerifyRegister = "register" // 注册后验证邮箱
+	EmailVerifyChange   = "change"   // 修改邮箱时验证新地址
+)
+
+// EmailVerification 邮箱验证令牌，只保存令牌摘要，确认后 Email 才会写入用户资料
+type EmailVerification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	Email     string     `json:"email" gorm:"size:100;not null"`
+	Purpose   string     `json:"purpose" gorm:"size:20;not null"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// 表名
+func (EmailVerification) TableName() string {
+	return "email_verifications"
+}
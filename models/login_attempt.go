@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// 登录失败的原因
+const (
+	LoginFailureWrongPassword = "wrong_password" // 密码错误
+	LoginFailureUnknownUser   = "unknown_user"   // 账号不存在
+	LoginFailureTwoFactor     = "two_factor"     // 两步验证码错误
+)
+
+// LoginAttempt 登录失败记录，用于在安全设置中向用户展示
+type LoginAttempt struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"index"` // 账号不存在时为0
+	Identifier string    `json:"identifier" gorm:"size:255"`
+	IPAddress  string    `json:"ip_address" gorm:"size:45;index"`
+	UserAgent  string    `json:"user_agent" gorm:"size:500"`
+	Reason     string    `json:"reason" gorm:"size:20"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// 表名
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// ReasonLabel 失败原因的中文说明
+func (a LoginAttempt) ReasonLabel() string {
+	switch a.Reason {
+	case LoginFailureWrongPassword:
+		return "密码错误"
+	case LoginFailureTwoFactor:
+		return "两步验证码错误"
+	default:
+		return "账号不存在"
+	}
+}
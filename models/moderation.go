@@ -0,0 +1,22 @@
+package models
+
+// 内容状态，文章、评论和分类共用 StatusCode 字段
+const (
+	StatusNormal   = 1 // 正常（审核通过）
+	StatusDisabled = 2 // 禁用（审核未通过）
+	StatusPending  = 3 // 待审核
+)
+
+// StatusLabel 内容状态的名称
+func StatusLabel(status int) string {
+	switch status {
+	case StatusNormal:
+		return "正常"
+	case StatusDisabled:
+		return "未通过"
+	case StatusPending:
+		return "待审核"
+	default:
+		return "未知"
+	}
+}
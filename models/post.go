@@ -12,22 +12,36 @@ type Post struct {
     Author    string         `json:"author" gorm:"size:40;not null"`
     Category  string         `json:"category" gorm:"size:100;not null"`
     CategoryId int           `json:"category_id" gorm:"default:0"`
-    Content   string         `json:"content" gorm:"type:text;not null"`
+    Content   string         `json:"content" gorm:"type:mediumtext;not null"` // 服务端渲染并过滤后的HTML
+    Markdown  string         `json:"markdown" gorm:"type:mediumtext"`         // Markdown原文
     Tags      string         `json:"tags" gorm:"size:255;not null"`
     Views     int            `json:"views" gorm:"default:0"`      // 浏览数
     Replies   int            `json:"replies" gorm:"default:0"`    // 回复数
     Favorites int            `json:"favorites" gorm:"default:0"`  // 收藏数
     Likes     int            `json:"likes" gorm:"default:0"`      // 点赞数
     ReadLimit int            `json:"read_limit" gorm:"default:1"` // 阅读限制: 1-公开, 2-Lv1, 3-Lv2, 4-私有
+    StatusCode int           `json:"status_code" gorm:"not null;default:1;index"` // 1:正常 2:禁用 3:待审核
+    ModerationReason string  `json:"moderation_reason" gorm:"size:255"`          // 进入审核或被拒绝的原因
+    ReviewedBy uint          `json:"reviewed_by" gorm:"default:0"`               // 审核人，0 表示未经人工审核
+    ReviewedAt *time.Time    `json:"reviewed_at"`
     CreatedAt time.Time      `json:"created_at"`
     UpdatedAt time.Time      `json:"updated_at"`
     DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+    DeletedBy uint           `json:"deleted_by" gorm:"default:0"` // 删除人，作者只能恢复自己删除的文章
 
     // 明确指定外键关系
     User        User           `gorm:"foreignKey:UserID"`
 }
 
 
+// 阅读限制
+const (
+    ReadLimitPublic  = 1 // 公开
+    ReadLimitLv1     = 2 // 需要Lv1及以上
+    ReadLimitLv2     = 3 // 需要Lv2及以上
+    ReadLimitPrivate = 4 // 仅作者可见
+)
+
 // 表名
 func (Post) TableName() string {
     return "posts"
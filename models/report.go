@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// 举报对象的类型
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+)
+
+// 举报原因
+const (
+	ReportReasonSpam    = "spam"    // 垃圾广告
+	ReportReasonAbuse   = "abuse"   // 辱骂攻击
+	ReportReasonIllegal = "illegal" // 违法违规
+	ReportReasonOther   = "other"   // 其他
+)
+
+// 举报的处理状态
+const (
+	ReportOpen      = "open"      // 待处理
+	ReportResolved  = "resolved"  // 已处理，内容被下架
+	ReportDismissed = "dismissed" // 已驳回，内容保留
+)
+
+// Report 用户对文章或评论的举报，同一用户对同一内容只能举报一次
+type Report struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	TargetType string     `json:"target_type" gorm:"size:20;not null;uniqueIndex:idx_reports_target_user,priority:1;index:idx_reports_target,priority:1"`
+	TargetID   uint       `json:"target_id" gorm:"not null;uniqueIndex:idx_reports_target_user,priority:2;index:idx_reports_target,priority:2"`
+	UserID     uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_reports_target_user,priority:3"` // 举报人
+	Reason     string     `json:"reason" gorm:"size:20;not null"`
+	Note       string     `json:"note" gorm:"size:500"`
+	Trusted    bool       `json:"trusted" gorm:"default:false"` // 举报时举报人是否达到信任等级，只有这类举报计入自动隐藏
+	Status     string     `json:"status" gorm:"size:20;not null;default:open;index"`
+	HandledBy  uint       `json:"handled_by" gorm:"default:0"`
+	HandledAt  *time.Time `json:"handled_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// 表名
+func (Report) TableName() string {
+	return "reports"
+}
+
+// ReasonLabel 举报原因的中文说明
+func (r Report) ReasonLabel() string {
+	return ReportReasonLabel(r.Reason)
+}
+
+// ReportReasonLabel 举报原因的中文说明
+func ReportReasonLabel(reason string) string {
+	switch reason {
+	case ReportReasonSpam:
+		return "垃圾广告"
+	case ReportReasonAbuse:
+		return "辱骂攻击"
+	case ReportReasonIllegal:
+		return "违法违规"
+	default:
+		return "其他"
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+// 角色定义
+const (
+	RoleAdmin     = "admin"     // 管理员
+	RoleModerator = "moderator" // 版主
+	RoleMember    = "member"    // 普通会员
+)
+
+// Permission 权限标识
+type Permission string
+
+const (
+	PermManageUsers      Permission = "users:manage"    // 管理用户（修改、删除）
+	PermManagePosts      Permission = "posts:manage"    // 管理任意文章（修改、删除）
+	PermManageComments   Permission = "comments:manage" // 管理任意评论（修改、删除）
+	PermForceDelete      Permission = "content:force_delete"
+	PermModerate         Permission = "content:moderate"  // 审核文章和评论
+	PermManageWords      Permission = "words:manage"      // 维护敏感词
+	PermSanction         Permission = "users:sanction"    // 禁言、封禁用户和 IP
+	PermManageCategories Permission = "categories:manage" // 管理分类
+	PermViewAuditLog     Permission = "audit:read"        // 查看和导出审计日志
+)
+
+// 各角色拥有的权限
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermManageUsers,
+		PermManagePosts,
+		PermManageComments,
+		PermForceDelete,
+		PermModerate,
+		PermManageWords,
+		PermSanction,
+		PermManageCategories,
+		PermViewAuditLog,
+	},
+	RoleModerator: {
+		PermManagePosts,
+		PermManageComments,
+		PermModerate,
+		PermSanction,
+	},
+	RoleMember: {},
+}
+
+// IsValidRole 判断角色名是否有效
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can 判断用户是否拥有指定权限
+func (u *User) Can(perm Permission) bool {
+	if u == nil {
+		return false
+	}
+	for _, p := range rolePermissions[u.Role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// IsModerator 是否为版主或管理员
+func (u *User) IsModerator() bool {
+	return u != nil && (u.Role == RoleModerator || u.Role == RoleAdmin)
+}
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u != nil && u.Role == RoleAdmin
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// 处罚类型
+const (
+	SanctionMute  = "mute"   // 禁言：可以浏览，不能发帖和评论
+	SanctionBan   = "ban"    // 封禁：不能登录，已登录的会话也无法使用
+	SanctionIPBan = "ip_ban" // 封禁 IP：该 IP 不能登录、注册和提交任何修改
+)
+
+// UserSanction 版主对用户或 IP 的处罚，到期或撤销后失效
+type UserSanction struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Type      string     `json:"type" gorm:"size:10;not null;index"`
+	UserID    uint       `json:"user_id" gorm:"index"`            // 封禁 IP 时为0
+	IPAddress string     `json:"ip_address" gorm:"size:45;index"` // 仅封禁 IP 时使用
+	Reason    string     `json:"reason" gorm:"size:255;not null"`
+	StartsAt  time.Time  `json:"starts_at"`
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"` // 为空表示永久
+	CreatedBy uint       `json:"created_by"`              // 执行处罚的版主
+	RevokedAt *time.Time `json:"revoked_at"`
+	RevokedBy uint       `json:"revoked_by" gorm:"default:0"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	User      User `json:"user" gorm:"foreignKey:UserID;constraint:-"` // 封禁 IP 时没有关联用户，不创建外键
+	Moderator User `json:"moderator" gorm:"foreignKey:CreatedBy"`
+}
+
+// 表名
+func (UserSanction) TableName() string {
+	return "user_sanctions"
+}
+
+// IsValidSanctionType 判断处罚类型是否有效
+func IsValidSanctionType(t string) bool {
+	return t == SanctionMute || t == SanctionBan || t == SanctionIPBan
+}
+
+// TypeLabel 处罚类型的中文说明
+func (s UserSanction) TypeLabel() string {
+	switch s.Type {
+	case SanctionMute:
+		return "禁言"
+	case SanctionBan:
+		return "封禁账号"
+	default:
+		return "封禁 IP"
+	}
+}
+
+// IsActive 判断处罚在指定时间是否生效
+func (s UserSanction) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && !s.StartsAt.After(now) && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
+
+// Notice 展示给被处罚用户的说明，包含原因和解除时间
+func (s UserSanction) Notice() string {
+	var prefix string
+	switch s.Type {
+	case SanctionMute:
+		prefix = "您已被禁言，暂时不能发帖和评论"
+	case SanctionBan:
+		prefix = "您的账号已被封禁"
+	default:
+		prefix = "您所在的网络已被禁止登录和发布内容"
+	}
+	until := "永久有效"
+	if s.ExpiresAt != nil {
+		until = "将于 " + s.ExpiresAt.Format("2006-01-02 15:04") + " 解除"
+	}
+	return prefix + "。原因：" + s.Reason + "，" + until + "。"
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gin-doniai/wordfilter"
+)
+
+// SensitiveWord 敏感词规则，由管理员维护，修改后立即重新加载
+type SensitiveWord struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Word      string    `json:"word" gorm:"size:100;not null;uniqueIndex"`
+	Action    string    `json:"action" gorm:"size:10;not null;default:review"` // reject、mask 或 review
+	CreatedBy uint      `json:"created_by" gorm:"default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 表名
+func (SensitiveWord) TableName() string {
+	return "sensitive_words"
+}
+
+// Rule 转换为过滤规则
+func (w SensitiveWord) Rule() wordfilter.Rule {
+	return wordfilter.Rule{Word: w.Word, Action: wordfilter.Action(w.Action)}
+}
+
+// ActionLabel 处理方式的中文说明
+func (w SensitiveWord) ActionLabel() string {
+	switch wordfilter.Action(w.Action) {
+	case wordfilter.Reject:
+		return "禁止发布"
+	case wordfilter.Mask:
+		return "替换为 *"
+	default:
+		return "人工审核"
+	}
+}
@@ -9,10 +9,12 @@ type User struct {
     ID        uint           `json:"id" gorm:"primaryKey"`
     Name      string         `json:"name" gorm:"size:100;not null"`
     Email     string         `json:"email" gorm:"size:100;uniqueIndex;not null"`
-    Password  string         `json:"password" gorm:"size:255;not null"`
+    Password  string         `json:"-" gorm:"size:255;not null"` // 不参与JSON序列化，对外输出请使用 serializers
+    PasswordSet bool         `json:"password_set" gorm:"not null;default:false"` // 第三方登录创建的账户使用随机密码，设置密码前不能用密码登录
     Avatar    string         `json:"avatar" gorm:"size:255;not null"`
     Age       int            `json:"age" gorm:"default:0"`
     Level     int            `json:"level" gorm:"default:1"`
+    Role      string         `json:"role" gorm:"size:20;default:member"` // 角色: admin, moderator, member
     AgreeTerms bool          `json:"agree_terms" gorm:"default:false"` // 修改为布尔类型
     CreatedAt time.Time      `json:"created_at"`
     UpdatedAt time.Time      `json:"updated_at"`
@@ -22,6 +24,15 @@ type User struct {
     Motto         string    `json:"motto"`          // 个人格言
     Github        string    `json:"github"`         // GitHub账号
     GoogleAccount string    `json:"google_account"` // Google账户
+
+    // 邮箱验证
+    EmailVerified   bool       `json:"email_verified" gorm:"default:false"`
+    EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+    // 两步验证
+    TwoFactorEnabled     bool   `json:"two_factor_enabled" gorm:"default:false"`
+    TwoFactorSecret      string `json:"-" gorm:"size:64"`
+    TwoFactorLastCounter int64  `json:"-" gorm:"default:0"` // 最近一次使用的验证码时间窗口，防止重放
 }
 
 // 表名
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// 第三方登录平台
+const (
+	ProviderGitHub = "github"
+	ProviderGoogle = "google"
+)
+
+// UserIdentity 用户关联的第三方账号，按平台和平台用户ID唯一，每个用户在同一平台只能关联一个账号
+type UserIdentity struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_identities_user_provider"`
+	Provider       string    `json:"provider" gorm:"size:20;not null;uniqueIndex:idx_user_identities_provider_uid;uniqueIndex:idx_user_identities_user_provider"`
+	ProviderUserID string    `json:"provider_user_id" gorm:"size:100;not null;uniqueIndex:idx_user_identities_provider_uid"`
+	Username       string    `json:"username" gorm:"size:100"` // 平台上的用户名，如 GitHub login
+	Email          string    `json:"email" gorm:"size:100"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// 表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// 平台显示名称，OpenID Connect 平台的名称来自配置
+var providerLabels = map[string]string{
+	ProviderGitHub: "GitHub",
+	ProviderGoogle: "Google",
+}
+
+// RegisterProviderLabel 登记平台的显示名称，只在启动时调用
+func RegisterProviderLabel(provider, label string) {
+	providerLabels[provider] = label
+}
+
+// ProviderLabel 平台名称
+func ProviderLabel(provider string) string {
+	if label, ok := providerLabels[provider]; ok {
+		return label
+	}
+	return provider
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession 服务端保存的登录会话，Cookie 中只保存签名后的 SessionKey
+type UserSession struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	SessionKey   string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	UserID       uint      `json:"user_id" gorm:"index"` // 0 表示未登录的访客会话
+	Data         []byte    `json:"-" gorm:"type:blob"`   // gob 编码的会话数据
+	IPAddress    string    `json:"ip_address" gorm:"size:45"`
+	UserAgent    string    `json:"user_agent" gorm:"type:text"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 平台名称用于路由 /auth/:provider 和 user_identities.provider（最长20个字符）
+var reProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,19}$`)
+
+// ClaimMapping 用户资料对应的声明名称，支持用 . 访问嵌套对象
+type ClaimMapping struct {
+	Username      string
+	Name          string
+	Email         string
+	EmailVerified string
+	Avatar        string
+}
+
+// Config 一个 OpenID Connect 登录平台的配置
+type Config struct {
+	Name         string // 平台标识，如 company
+	Label        string // 显示名称，如 "公司账号"
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // 为空时由调用方根据站点地址生成
+	Scopes       []string
+	Claims       ClaimMapping
+	// TrustEmail 为 true 时，IdP 不返回 email_verified 也视为邮箱已验证，只应用于自己管理的 IdP
+	TrustEmail bool
+}
+
+// ConfigsFromEnv 读取环境变量中配置的平台：
+//
+//	OIDC_PROVIDERS              平台名称列表，逗号分隔，如 company,gitea
+//	OIDC_<NAME>_ISSUER          issuer 地址，发现文档为 <issuer>/.well-known/openid-configuration
+//	OIDC_<NAME>_CLIENT_ID、OIDC_<NAME>_CLIENT_SECRET
+//	OIDC_<NAME>_REDIRECT_URL    默认为 APP_BASE_URL/auth/<name>/callback
+//	OIDC_<NAME>_LABEL           显示名称，默认为平台名称
+//	OIDC_<NAME>_SCOPES          默认 "openid profile email"
+//	OIDC_<NAME>_CLAIM_USERNAME、_CLAIM_NAME、_CLAIM_EMAIL、_CLAIM_EMAIL_VERIFIED、_CLAIM_AVATAR
+//	                            声明名称，默认 preferred_username、name、email、email_verified、picture
+//	OIDC_<NAME>_TRUST_EMAIL     true 时视为邮箱已验证
+//
+// <NAME> 为大写的平台名称，- 替换为 _。配置有误的平台不会返回，错误合并后一起返回。
+func ConfigsFromEnv() ([]Config, error) {
+	var configs []Config
+	var errs []error
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !reProviderName.MatchString(name) {
+			errs = append(errs, fmt.Errorf("oidc: 平台名称 %q 无效，只能包含小写字母、数字、- 和 _，最长20个字符", name))
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("oidc: 平台 %s 重复配置", name))
+			continue
+		}
+		seen[name] = true
+
+		cfg, err := configFromEnv(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, errors.Join(errs...)
+}
+
+func configFromEnv(name string) (Config, error) {
+	prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	env := func(key, fallback string) string {
+		if v := strings.TrimSpace(os.Getenv(prefix + key)); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	cfg := Config{
+		Name:         name,
+		Label:        env("LABEL", name),
+		Issuer:       env("ISSUER", ""),
+		ClientID:     env("CLIENT_ID", ""),
+		ClientSecret: env("CLIENT_SECRET", ""),
+		RedirectURL:  env("REDIRECT_URL", ""),
+		Scopes:       strings.FieldsFunc(env("SCOPES", "openid profile email"), func(r rune) bool { return r == ' ' || r == ',' }),
+		Claims: ClaimMapping{
+			Username:      env("CLAIM_USERNAME", "preferred_username"),
+			Name:          env("CLAIM_NAME", "name"),
+			Email:         env("CLAIM_EMAIL", "email"),
+			EmailVerified: env("CLAIM_EMAIL_VERIFIED", "email_verified"),
+			Avatar:        env("CLAIM_AVATAR", "picture"),
+		},
+		TrustEmail: strings.EqualFold(env("TRUST_EMAIL", ""), "true"),
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return Config{}, fmt.Errorf("oidc: 平台 %s 缺少 %sISSUER 或 %sCLIENT_ID", name, prefix, prefix)
+	}
+
+	// 必须请求 openid 才会返回 ID Token
+	hasOpenID := false
+	for _, s := range cfg.Scopes {
+		hasOpenID = hasOpenID || s == "openid"
+	}
+	if !hasOpenID {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return cfg, nil
+}
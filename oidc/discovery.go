@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Metadata 发现文档（/.well-known/openid-configuration）中用到的字段
+type Metadata struct {
+	Issuer                 string   `json:"issuer"`
+	AuthorizationEndpoint  string   `json:"authorization_endpoint"`
+	TokenEndpoint          string   `json:"token_endpoint"`
+	UserinfoEndpoint       string   `json:"userinfo_endpoint"`
+	JWKSURI                string   `json:"jwks_uri"`
+	IDTokenSigningAlgs     []string `json:"id_token_signing_alg_values_supported"`
+	CodeChallengeMethods   []string `json:"code_challenge_methods_supported"`
+	ScopesSupported        []string `json:"scopes_supported"`
+	TokenEndpointAuthTypes []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// Discover 读取 issuer 的发现文档，文档中的 issuer 必须与配置一致，否则可能是被替换的文档
+func Discover(ctx context.Context, client *http.Client, issuer string) (*Metadata, error) {
+	wellKnown := strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+
+	var m Metadata
+	if err := getJSON(ctx, client, wellKnown, &m); err != nil {
+		return nil, fmt.Errorf("oidc: 读取发现文档失败: %w", err)
+	}
+	if strings.TrimRight(m.Issuer, "/") != strings.TrimRight(issuer, "/") {
+		return nil, fmt.Errorf("oidc: 发现文档中的 issuer %q 与配置的 %q 不一致", m.Issuer, issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, errors.New("oidc: 发现文档缺少 authorization_endpoint、token_endpoint 或 jwks_uri")
+	}
+	return &m, nil
+}
+
+// getJSON 请求 url 并解析 JSON 响应
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 返回 %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
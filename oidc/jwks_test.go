@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestPublicKey(t *testing.T) {
+	testKeys(t)
+	offCurve := ecJWK("ec", &testECKey.PublicKey)
+	offCurve.Y = b64(new(big.Int).Add(testECKey.PublicKey.Y, big.NewInt(1)).Bytes())
+	badExponent := rsaJWK("rsa", &rsa.PublicKey{N: testRSAKey.N, E: 1})
+	p521 := ecJWK("ec", &testECKey.PublicKey)
+	p521.Crv = elliptic.P521().Params().Name
+	emptyN := rsaJWK("rsa", &testRSAKey.PublicKey)
+	emptyN.N = ""
+
+	tests := []struct {
+		name string
+		jwk  jsonWebKey
+		ok   bool
+	}{
+		{"RSA 2048", rsaJWK("rsa", &testRSAKey.PublicKey), true},
+		{"RSA 1024 位", rsaJWK("rsa", &testRSA1024.PublicKey), false},
+		{"RSA 指数为 1", badExponent, false},
+		{"RSA 缺少 n", emptyN, false},
+		{"EC P-256", ecJWK("ec", &testECKey.PublicKey), true},
+		{"EC 点不在曲线上", offCurve, false},
+		{"不支持的曲线", p521, false},
+		{"对称密钥", jsonWebKey{Kty: "oct", Kid: "hs"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.jwk.publicKey()
+			if tt.ok && (err != nil || key == nil) {
+				t.Fatalf("publicKey 失败: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("publicKey 应当拒绝该公钥")
+			}
+		})
+	}
+}
+
+func TestKeySetSkipsInvalidKeys(t *testing.T) {
+	testKeys(t)
+	enc := rsaJWK("enc-1", &testRSAKey.PublicKey)
+	enc.Use = "enc"
+	offCurve := ecJWK("ec-bad", &testECKey.PublicKey)
+	offCurve.X = b64(new(big.Int).Add(testECKey.PublicKey.X, big.NewInt(1)).Bytes())
+	srv := newJWKSServer(t,
+		rsaJWK("rsa-1", &testRSAKey.PublicKey),
+		rsaJWK("rsa-short", &testRSA1024.PublicKey),
+		offCurve,
+		enc,
+	)
+	keys := srv.keySet()
+
+	if _, err := keys.Key(context.Background(), "rsa-1"); err != nil {
+		t.Fatalf("Key(rsa-1) 失败: %v", err)
+	}
+	for _, kid := range []string{"rsa-short", "ec-bad", "enc-1"} {
+		if _, err := keys.Key(context.Background(), kid); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Key(%s) 错误 = %v，期望 %v", kid, err, ErrKeyNotFound)
+		}
+	}
+	// 只剩一个有效公钥时 kid 可以为空
+	if _, err := keys.Key(context.Background(), ""); err != nil {
+		t.Errorf("Key(\"\") 失败: %v", err)
+	}
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	testKeys(t)
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &testRSAKey.PublicKey))
+	keys := srv.keySet()
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "rsa-1"); err != nil {
+		t.Fatalf("Key(rsa-1) 失败: %v", err)
+	}
+	if _, err := keys.Key(ctx, "rsa-1"); err != nil {
+		t.Fatalf("Key(rsa-1) 失败: %v", err)
+	}
+	if n := srv.requestCount(); n != 1 {
+		t.Fatalf("JWKS 请求次数 = %d，期望 1", n)
+	}
+
+	// IdP 轮换密钥，刚获取过时未知 kid 不会立即重新请求
+	srv.setKeys(rsaJWK("rsa-1", &testRSAKey.PublicKey), ecJWK("ec-2", &testECKey.PublicKey))
+	if _, err := keys.Key(ctx, "ec-2"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Key(ec-2) 错误 = %v，期望 %v", err, ErrKeyNotFound)
+	}
+	if n := srv.requestCount(); n != 1 {
+		t.Fatalf("JWKS 请求次数 = %d，期望 1", n)
+	}
+
+	// 超过重新获取的间隔后，未知 kid 触发重新请求
+	keys.fetchedAt = time.Now().Add(-jwksRefreshBackoff - time.Second)
+	if _, err := keys.Key(ctx, "ec-2"); err != nil {
+		t.Fatalf("Key(ec-2) 失败: %v", err)
+	}
+	if n := srv.requestCount(); n != 2 {
+		t.Fatalf("JWKS 请求次数 = %d，期望 2", n)
+	}
+
+	// 缓存过期后已知的 kid 也会重新请求，被移除的公钥不再可用
+	srv.setKeys(ecJWK("ec-2", &testECKey.PublicKey))
+	keys.fetchedAt = time.Now().Add(-jwksCacheTTL - time.Second)
+	if _, err := keys.Key(ctx, "rsa-1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Key(rsa-1) 错误 = %v，期望 %v", err, ErrKeyNotFound)
+	}
+	if n := srv.requestCount(); n != 3 {
+		t.Fatalf("JWKS 请求次数 = %d，期望 3", n)
+	}
+}
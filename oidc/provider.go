@@ -0,0 +1,136 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// SupportedAlgorithms 支持的 ID Token 签名算法
+var SupportedAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384"}
+
+// 发现文档读取失败后，间隔这么久才会再次尝试
+const discoveryRetryInterval = 30 * time.Second
+
+// Provider 一个 OpenID Connect 登录平台。发现文档在第一次登录时读取并缓存，
+// IdP 暂时不可用不会影响服务启动。
+type Provider struct {
+	Config Config
+	Client *http.Client
+
+	mu       sync.Mutex
+	metadata *Metadata
+	verifier *Verifier
+	failedAt time.Time
+	lastErr  error
+}
+
+// NewProvider 创建登录平台
+func NewProvider(cfg Config) *Provider {
+	return &Provider{
+		Config: cfg,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Metadata 返回发现文档
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+	if time.Since(p.failedAt) < discoveryRetryInterval {
+		return nil, p.lastErr
+	}
+
+	m, err := Discover(ctx, p.Client, p.Config.Issuer)
+	if err != nil {
+		p.failedAt, p.lastErr = time.Now(), err
+		return nil, err
+	}
+	p.metadata = m
+	p.verifier = &Verifier{
+		Issuer:     m.Issuer,
+		ClientID:   p.Config.ClientID,
+		Keys:       NewKeySet(m.JWKSURI, p.Client),
+		Algorithms: allowedAlgorithms(m.IDTokenSigningAlgs),
+	}
+	return m, nil
+}
+
+// OAuth2Config 返回授权码流程的配置
+func (p *Provider) OAuth2Config(ctx context.Context) (*oauth2.Config, error) {
+	m, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.Config.ClientID,
+		ClientSecret: p.Config.ClientSecret,
+		RedirectURL:  p.Config.RedirectURL,
+		Scopes:       p.Config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  m.AuthorizationEndpoint,
+			TokenURL: m.TokenEndpoint,
+		},
+	}, nil
+}
+
+// Claims 校验令牌响应中的 ID Token，再用 userinfo 接口补充 ID Token 中没有的声明
+func (p *Provider) Claims(ctx context.Context, token *oauth2.Token, nonce string) (Claims, error) {
+	m, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, errors.New("oidc: 令牌响应中没有 id_token")
+	}
+	claims, err := p.verifier.Verify(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if m.UserinfoEndpoint == "" {
+		return claims, nil
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.Client)
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	var userinfo Claims
+	if err := getJSON(ctx, client, m.UserinfoEndpoint, &userinfo); err != nil {
+		return nil, fmt.Errorf("oidc: 读取 userinfo 失败: %w", err)
+	}
+	// userinfo 的 sub 必须与 ID Token 一致，否则可能是其他用户的令牌
+	if userinfo.String("sub") != claims.String("sub") {
+		return nil, errors.New("oidc: userinfo 与 ID Token 的 sub 不一致")
+	}
+	for k, v := range userinfo {
+		if _, ok := claims[k]; !ok {
+			claims[k] = v
+		}
+	}
+	return claims, nil
+}
+
+// allowedAlgorithms 发现文档声明的算法中本站支持的部分，未声明时按规范默认为 RS256
+func allowedAlgorithms(advertised []string) []string {
+	if len(advertised) == 0 {
+		return []string{"RS256"}
+	}
+	var result []string
+	for _, alg := range advertised {
+		for _, supported := range SupportedAlgorithms {
+			if alg == supported {
+				result = append(result, alg)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,233 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 允许的时钟误差
+const clockSkew = time.Minute
+
+var (
+	ErrInvalidToken = errors.New("oidc: ID Token 格式无效")
+	ErrSignature    = errors.New("oidc: ID Token 签名无效")
+)
+
+// Claims ID Token 或 userinfo 中的声明
+type Claims map[string]interface{}
+
+// String 读取字符串声明，支持用 . 访问嵌套对象，如 "profile.avatar"
+func (c Claims) String(name string) string {
+	v, _ := c.lookup(name).(string)
+	return v
+}
+
+// Bool 读取布尔声明，部分 IdP 会把 email_verified 写成字符串 "true"
+func (c Claims) Bool(name string) bool {
+	switch v := c.lookup(name).(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func (c Claims) lookup(name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	var cur interface{} = map[string]interface{}(c)
+	for _, part := range strings.Split(name, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// Verifier 校验 ID Token 的签名和 iss、aud、exp、nonce
+type Verifier struct {
+	Issuer   string
+	ClientID string
+	Keys     *KeySet
+	// Algorithms 允许的签名算法，为空时只允许 RS256
+	Algorithms []string
+	// Now 当前时间，测试时可以替换
+	Now func() time.Time
+}
+
+// Verify 校验 ID Token 并返回其中的声明，nonce 必须与发起登录时生成的一致
+func (v *Verifier) Verify(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !v.algorithmAllowed(header.Alg) {
+		return nil, fmt.Errorf("oidc: 不允许的签名算法 %q", header.Alg)
+	}
+
+	key, err := v.Keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.validateClaims(claims, nonce); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) algorithmAllowed(alg string) bool {
+	allowed := v.Algorithms
+	if len(allowed) == 0 {
+		allowed = []string{"RS256"}
+	}
+	for _, a := range allowed {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verifier) validateClaims(claims Claims, nonce string) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+
+	if iss := claims.String("iss"); strings.TrimRight(iss, "/") != strings.TrimRight(v.Issuer, "/") {
+		return fmt.Errorf("oidc: issuer %q 不匹配", iss)
+	}
+	if claims.String("sub") == "" {
+		return errors.New("oidc: ID Token 缺少 sub")
+	}
+
+	// aud 可以是字符串或数组；有多个 aud 时 azp 必须是本站
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	found := false
+	for _, a := range audiences {
+		found = found || a == v.ClientID
+	}
+	if !found {
+		return errors.New("oidc: ID Token 不是签发给本站的")
+	}
+	if azp := claims.String("azp"); len(audiences) > 1 && azp != v.ClientID {
+		return errors.New("oidc: ID Token 的 azp 不匹配")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return errors.New("oidc: ID Token 已过期")
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return errors.New("oidc: ID Token 签发时间无效")
+	}
+	if nonce != "" && claims.String("nonce") != nonce {
+		return errors.New("oidc: nonce 不匹配")
+	}
+	return nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	if len(alg) != 5 {
+		return ErrSignature
+	}
+	var h hash.Hash
+	var hashID crypto.Hash
+	switch alg[2:] {
+	case "256":
+		h, hashID = sha256.New(), crypto.SHA256
+	case "384":
+		h, hashID = sha512.New384(), crypto.SHA384
+	case "512":
+		h, hashID = sha512.New(), crypto.SHA512
+	default:
+		return ErrSignature
+	}
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, hashID, digest, signature) != nil {
+			return ErrSignature
+		}
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(pub, hashID, digest, signature, nil) != nil {
+			return ErrSignature
+		}
+	case "ES":
+		// JWS 中的 ECDSA 签名是定长的 r||s，不是 ASN.1
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrSignature
+		}
+	default:
+		return ErrSignature
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
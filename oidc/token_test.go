@@ -0,0 +1,303 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://idp.example.com"
+	testClientID = "doniai"
+	testNonce    = "n-0S6_WzA2Mj"
+)
+
+var testNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   testIssuer,
+		"sub":   "248289761001",
+		"aud":   testClientID,
+		"exp":   testNow.Add(10 * time.Minute).Unix(),
+		"iat":   testNow.Unix(),
+		"nonce": testNonce,
+	}
+}
+
+// withClaims 在有效声明的基础上修改，值为 nil 时删除该声明
+func withClaims(changes map[string]interface{}) map[string]interface{} {
+	claims := validClaims()
+	for k, v := range changes {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func newTestVerifier(t *testing.T, algorithms ...string) *Verifier {
+	t.Helper()
+	testKeys(t)
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &testRSAKey.PublicKey), ecJWK("ec-1", &testECKey.PublicKey))
+	return &Verifier{
+		Issuer:     testIssuer,
+		ClientID:   testClientID,
+		Keys:       srv.keySet(),
+		Algorithms: algorithms,
+		Now:        func() time.Time { return testNow },
+	}
+}
+
+func TestVerify(t *testing.T) {
+	testKeys(t)
+	// HS256 用 RSA 公钥作为密钥，模拟算法混淆攻击
+	rsaSecret := testRSAKey.PublicKey.N.Bytes()
+
+	tests := []struct {
+		name       string
+		algorithms []string
+		token      func(t *testing.T) string
+		nonce      string
+		wantErr    error // 为 nil 且 fail 为 false 时期望通过
+		fail       bool
+	}{
+		{
+			name:  "RS256 有效",
+			token: func(t *testing.T) string { return signJWT(t, "RS256", "rsa-1", testRSAKey, validClaims()) },
+			nonce: testNonce,
+		},
+		{
+			name:       "ES256 有效",
+			algorithms: []string{"RS256", "ES256"},
+			token:      func(t *testing.T) string { return signJWT(t, "ES256", "ec-1", testECKey, validClaims()) },
+			nonce:      testNonce,
+		},
+		{
+			name:       "PS256 有效",
+			algorithms: []string{"PS256"},
+			token:      func(t *testing.T) string { return signJWT(t, "PS256", "rsa-1", testRSAKey, validClaims()) },
+			nonce:      testNonce,
+		},
+		{
+			name:  "alg 为 none",
+			token: func(t *testing.T) string { return signJWT(t, "none", "rsa-1", nil, validClaims()) },
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name:       "允许列表包含 none 时仍拒绝无签名",
+			algorithms: []string{"RS256", "none"},
+			token:      func(t *testing.T) string { return signJWT(t, "none", "rsa-1", nil, validClaims()) },
+			nonce:      testNonce,
+			wantErr:    ErrSignature,
+		},
+		{
+			name:  "HS256 用公钥作为密钥",
+			token: func(t *testing.T) string { return signJWT(t, "HS256", "rsa-1", rsaSecret, validClaims()) },
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name:       "允许列表包含 HS256 时仍拒绝",
+			algorithms: []string{"RS256", "HS256"},
+			token:      func(t *testing.T) string { return signJWT(t, "HS256", "rsa-1", rsaSecret, validClaims()) },
+			nonce:      testNonce,
+			wantErr:    ErrSignature,
+		},
+		{
+			name:       "未在允许列表中的 ES256",
+			algorithms: []string{"RS256"},
+			token:      func(t *testing.T) string { return signJWT(t, "ES256", "ec-1", testECKey, validClaims()) },
+			nonce:      testNonce,
+			fail:       true,
+		},
+		{
+			name:       "RS256 头部配 EC 公钥",
+			algorithms: []string{"RS256", "ES256"},
+			token:      func(t *testing.T) string { return signJWT(t, "RS256", "ec-1", testRSAKey, validClaims()) },
+			nonce:      testNonce,
+			wantErr:    ErrSignature,
+		},
+		{
+			name:    "未知 kid",
+			token:   func(t *testing.T) string { return signJWT(t, "RS256", "rsa-2", testRSAKey, validClaims()) },
+			nonce:   testNonce,
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "有多个公钥时缺少 kid",
+			token:   func(t *testing.T) string { return signJWT(t, "RS256", "", testRSAKey, validClaims()) },
+			nonce:   testNonce,
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "签名后篡改声明",
+			token: func(t *testing.T) string {
+				forged := signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"sub": "1"}))
+				valid := signJWT(t, "RS256", "rsa-1", testRSAKey, validClaims())
+				return forged[:strings.LastIndex(forged, ".")] + valid[strings.LastIndex(valid, "."):]
+			},
+			nonce:   testNonce,
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "格式错误",
+			token:   func(t *testing.T) string { return "not.a-jwt" },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "已过期",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "过期时间在允许的时钟误差内",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()}))
+			},
+			nonce: testNonce,
+		},
+		{
+			name: "缺少 exp",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"exp": nil}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "iat 在未来",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"iat": testNow.Add(5 * time.Minute).Unix()}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "issuer 不匹配",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"iss": "https://evil.example.com"}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "issuer 末尾多一个斜杠",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"iss": testIssuer + "/"}))
+			},
+			nonce: testNonce,
+		},
+		{
+			name: "缺少 sub",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"sub": nil}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "aud 不匹配",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"aud": "other-client"}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "aud 数组不包含本站",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"aud": []string{"a", "b"}, "azp": "a"}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "多个 aud 缺少 azp",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"aud": []string{testClientID, "other-client"}}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "多个 aud 的 azp 不是本站",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"aud": []string{testClientID, "other-client"}, "azp": "other-client"}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+		{
+			name: "多个 aud 的 azp 是本站",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"aud": []string{testClientID, "other-client"}, "azp": testClientID}))
+			},
+			nonce: testNonce,
+		},
+		{
+			name: "单个 aud 数组",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"aud": []string{testClientID}}))
+			},
+			nonce: testNonce,
+		},
+		{
+			name:  "nonce 不匹配",
+			token: func(t *testing.T) string { return signJWT(t, "RS256", "rsa-1", testRSAKey, validClaims()) },
+			nonce: "another-nonce",
+			fail:  true,
+		},
+		{
+			name: "缺少 nonce",
+			token: func(t *testing.T) string {
+				return signJWT(t, "RS256", "rsa-1", testRSAKey, withClaims(map[string]interface{}{"nonce": nil}))
+			},
+			nonce: testNonce,
+			fail:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(t, tt.algorithms...)
+			claims, err := v.Verify(context.Background(), tt.token(t), tt.nonce)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify 错误 = %v，期望 %v", err, tt.wantErr)
+				}
+			case tt.fail:
+				if err == nil {
+					t.Fatal("Verify 应当失败")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Verify 失败: %v", err)
+				}
+				if claims.String("sub") != "248289761001" {
+					t.Errorf("sub = %q，期望 248289761001", claims.String("sub"))
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyESSignatureLength(t *testing.T) {
+	v := newTestVerifier(t, "ES256")
+	token := signJWT(t, "ES256", "ec-1", testECKey, validClaims())
+	// ES256 签名必须是 64 字节的 r||s，截掉一个字节后应当失败
+	sig := token[strings.LastIndex(token, ".")+1:]
+	short := token[:strings.LastIndex(token, ".")+1] + sig[:len(sig)-2]
+	if _, err := v.Verify(context.Background(), short, testNonce); !errors.Is(err, ErrSignature) {
+		t.Errorf("Verify 错误 = %v，期望 %v", err, ErrSignature)
+	}
+}
@@ -0,0 +1,235 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gin-doniai/responses"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Endpoint 接口的补充说明，按处理函数登记；路由本身从 gin 中读取，
+// 新增路由即使没有登记也会出现在文档中
+type Endpoint struct {
+	Summary    string
+	Tags       []string
+	Auth       bool        // 是否需要登录
+	Permission string      // 需要的权限，写入接口描述
+	Scope      string      // 使用访问令牌时需要的权限范围
+	Session    bool        // 只允许浏览器会话，不接受访问令牌
+	Query      []Parameter // 额外的查询参数
+	Request    interface{} // 请求体类型的零值
+	Response   interface{} // data 字段类型的零值，nil 表示不返回 data
+	List       bool        // 列表接口，data 为 Response 的数组并带分页信息
+	Status     int         // 成功时的状态码，默认 200
+}
+
+var (
+	endpointsMu sync.RWMutex
+	endpoints   = map[string]Endpoint{}
+)
+
+// Describe 为处理函数登记接口说明
+func Describe(handler gin.HandlerFunc, e Endpoint) {
+	endpointsMu.Lock()
+	defer endpointsMu.Unlock()
+	endpoints[handlerName(handler)] = e
+}
+
+// Lookup 返回处理函数登记的接口说明，handler 为 gin.RouteInfo.Handler 中的函数名
+func Lookup(handler string) (Endpoint, bool) {
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+	e, ok := endpoints[handler]
+	return e, ok
+}
+
+// handlerName 与 gin.RouteInfo.Handler 使用相同的函数名
+func handlerName(h gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+}
+
+var rePathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Build 根据已注册的路由生成文档，只包含以 prefix 开头的路由
+func Build(info Info, routes gin.RoutesInfo, prefix string) *Document {
+	reg := newSchemaRegistry()
+	envelope := reg.schemaOf(reflect.TypeOf(responses.Envelope{}))
+	meta := reg.schemaOf(reflect.TypeOf(responses.Meta{}))
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				"session": {
+					Type:        "apiKey",
+					In:          "cookie",
+					Name:        "mysession",
+					Description: "登录后浏览器会话，POST、PUT、PATCH、DELETE 请求还需要在 X-CSRF-Token 请求头中带上页面 <meta name=\"csrf-token\"> 的令牌",
+				},
+				"bearer": {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "dnai_...",
+					Description:  "个人访问令牌，在账户设置页面创建",
+				},
+			},
+		},
+	}
+
+	// 固定顺序，保证多次生成的 operationId 一致
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		e := endpoints[route.Handler]
+		op := &Operation{
+			OperationID: operationID(route.Handler),
+			Summary:     e.Summary,
+			Tags:        e.Tags,
+			Responses:   map[string]*Response{},
+		}
+		var notes []string
+		if e.Permission != "" {
+			notes = append(notes, "需要权限: "+e.Permission)
+		}
+		if e.Scope != "" {
+			notes = append(notes, "访问令牌需要权限范围: "+e.Scope)
+		}
+		if e.Session {
+			notes = append(notes, "只能通过浏览器会话调用")
+		}
+		op.Description = strings.Join(notes, "；")
+
+		path := rePathParam.ReplaceAllString(route.Path, "{$1}")
+		for _, m := range rePathParam.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, &Parameter{Name: m[1], In: "path", Required: true, Schema: paramSchema(m[1])})
+		}
+		for i := range e.Query {
+			q := e.Query[i]
+			q.In = "query"
+			if q.Schema == nil {
+				q.Schema = paramSchema(q.Name)
+			}
+			op.Parameters = append(op.Parameters, &q)
+		}
+		if e.List {
+			op.Parameters = append(op.Parameters,
+				&Parameter{Name: "page", In: "query", Description: "页码，从 1 开始", Schema: &Schema{Type: "integer"}},
+				&Parameter{Name: "per_page", In: "query", Description: "每页数量，最大 100", Schema: &Schema{Type: "integer"}},
+			)
+		}
+
+		if e.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(reg.schemaOf(reflect.TypeOf(e.Request))),
+			}
+		}
+
+		success := envelope
+		if e.Response != nil || e.List {
+			extra := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			if e.Response != nil {
+				data := reg.schemaOf(reflect.TypeOf(e.Response))
+				if e.List {
+					data = &Schema{Type: "array", Items: data}
+				}
+				extra.Properties["data"] = data
+			}
+			if e.List {
+				extra.Properties["meta"] = meta
+			}
+			success = &Schema{AllOf: []*Schema{envelope, extra}}
+		}
+		status := e.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op.Responses[strconv.Itoa(status)] = &Response{Description: "成功", Content: jsonContent(success)}
+		op.Responses["default"] = &Response{Description: "失败，code 为错误码", Content: jsonContent(envelope)}
+
+		if e.Session {
+			op.Security = []map[string][]string{{"session": {}}}
+		} else if e.Auth || e.Permission != "" {
+			op.Security = []map[string][]string{{"session": {}}, {"bearer": {}}}
+		}
+		if e.Auth || e.Permission != "" || e.Session {
+			op.Responses["401"] = &Response{Description: "未登录", Content: jsonContent(envelope)}
+		}
+		if e.Permission != "" || e.Scope != "" || e.Session {
+			op.Responses["403"] = &Response{Description: "没有权限", Content: jsonContent(envelope)}
+		}
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		switch route.Method {
+		case http.MethodGet:
+			item.Get = op
+		case http.MethodPost:
+			item.Post = op
+		case http.MethodPut:
+			item.Put = op
+		case http.MethodPatch:
+			item.Patch = op
+		case http.MethodDelete:
+			item.Delete = op
+		}
+	}
+
+	doc.Components.Schemas = reg.schemas
+	return doc
+}
+
+// Handler 返回输出 OpenAPI 文档的处理函数，文档在首次请求时根据当前路由生成
+func Handler(engine *gin.Engine, info Info, prefix string) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *Document
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc = Build(info, engine.Routes(), prefix)
+		})
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// operationID 由处理函数名生成，如 gin-doniai/handlers.CreatePost -> CreatePost
+func operationID(handler string) string {
+	return handler[strings.LastIndex(handler, ".")+1:]
+}
+
+// paramSchema 以 id 结尾的参数视为整数，其余为字符串
+func paramSchema(name string) *Schema {
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		return &Schema{Type: "integer", Format: "int64"}
+	}
+	return &Schema{Type: "string"}
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
@@ -0,0 +1,70 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testItem struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func testListItems(c *gin.Context)  {}
+func testCreateItem(c *gin.Context) {}
+func testDeleteItem(c *gin.Context) {}
+
+func TestBuild(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/api/v1/items", testListItems)
+	engine.POST("/api/v1/items", testCreateItem)
+	engine.DELETE("/api/v1/items/:id", testDeleteItem)
+	engine.GET("/other", testListItems)
+
+	Describe(testListItems, Endpoint{Summary: "列表", Scope: "posts:read", Response: testItem{}, List: true})
+	Describe(testCreateItem, Endpoint{Summary: "创建", Auth: true, Request: testItem{}, Response: testItem{}, Status: http.StatusCreated})
+	Describe(testDeleteItem, Endpoint{Summary: "删除", Permission: "items:manage"})
+
+	doc := Build(Info{Title: "test", Version: "1"}, engine.Routes(), "/api/v1")
+
+	if len(doc.Paths) != 2 || doc.Paths["/other"] != nil {
+		t.Fatalf("Paths = %v，期望只包含 /api/v1 下的两个路径", doc.Paths)
+	}
+
+	list := doc.Paths["/api/v1/items"].Get
+	if list.OperationID != "testListItems" || list.Summary != "列表" {
+		t.Errorf("列表接口 = %q %q", list.OperationID, list.Summary)
+	}
+	if list.Security != nil || list.Responses["401"] != nil {
+		t.Error("不需要登录的接口不应有 security 和 401")
+	}
+	if list.Responses["403"] == nil || list.Description != "访问令牌需要权限范围: posts:read" {
+		t.Errorf("需要权限范围的接口描述 = %q", list.Description)
+	}
+	if len(list.Parameters) != 2 || list.Parameters[0].Name != "page" {
+		t.Errorf("列表接口应有分页参数，实际 %d 个参数", len(list.Parameters))
+	}
+
+	create := doc.Paths["/api/v1/items"].Post
+	if create.RequestBody == nil || create.Responses["201"] == nil || create.Responses["200"] != nil {
+		t.Error("创建接口应有请求体和 201 响应")
+	}
+	if len(create.Security) != 2 || create.Responses["401"] == nil || create.Responses["403"] != nil {
+		t.Errorf("需要登录的接口 security = %v", create.Security)
+	}
+
+	del := doc.Paths["/api/v1/items/{id}"].Delete
+	if len(del.Parameters) != 1 || del.Parameters[0].In != "path" || del.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("路径参数 = %+v", del.Parameters)
+	}
+	if del.Responses["401"] == nil || del.Responses["403"] == nil || del.Description != "需要权限: items:manage" {
+		t.Errorf("需要权限的接口描述 = %q", del.Description)
+	}
+
+	if doc.Components.Schemas["testItem"] == nil {
+		t.Errorf("components.schemas 中缺少 testItem：%v", doc.Components.Schemas)
+	}
+}
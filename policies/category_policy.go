@@ -0,0 +1,25 @@
+package policies
+
+import (
+	"gin-doniai/models"
+
+	"gorm.io/gorm"
+)
+
+// CanPostInCategory 判断用户能否在分类下发帖：分类需为正常状态，用户等级不低于分类要求，
+// 拥有文章管理权限的用户不受等级限制
+func CanPostInCategory(user *models.User, category *models.Category) bool {
+	if user == nil || category == nil || category.StatusCode != models.StatusNormal {
+		return false
+	}
+	return user.Level >= category.MinPostLevel || user.Can(models.PermManagePosts)
+}
+
+// OrderedCategories 按手动排序列出分类
+//
+//	database.DB.Scopes(policies.OrderedCategories()).Find(&categories)
+func OrderedCategories() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order("categories.sort_order ASC, categories.id ASC")
+	}
+}
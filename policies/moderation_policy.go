@@ -0,0 +1,139 @@
+package policies
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-doniai/models"
+	"gin-doniai/wordfilter"
+
+	"gorm.io/gorm"
+)
+
+// TrustedLevel 等级低于此值的用户发布的内容需要审核，0 表示不按等级审核。
+// 通过环境变量 MODERATION_TRUSTED_LEVEL 配置，默认为 0：新注册用户为 Lv1，等级只能由管理员修改，
+// 按等级审核时需要管理员手动为用户升级。
+func TrustedLevel() int {
+	if v, err := strconv.Atoi(os.Getenv("MODERATION_TRUSTED_LEVEL")); err == nil && v >= 0 {
+		return v
+	}
+	return 0
+}
+
+// MaxLinksWithoutReview 内容中的链接超过这个数量时需要审核
+const MaxLinksWithoutReview = 3
+
+// ResubmitReason 未通过审核的内容修改后重新进入审核
+const ResubmitReason = "修改后重新提交审核"
+
+var reLink = regexp.MustCompile(`(?i)https?://`)
+
+// ReviewReason 判断新发布或修改的内容是否需要审核，需要时返回原因，否则返回空字符串。
+// 拥有审核权限的用户发布的内容不需要审核。
+func ReviewReason(author *models.User, text string) string {
+	if author == nil || author.Can(models.PermModerate) {
+		return ""
+	}
+	if level := TrustedLevel(); level > 0 && author.Level < level {
+		return "Lv" + strconv.Itoa(level) + " 以下用户发布的内容需要审核"
+	}
+	if words := wordfilter.Check(text).Review; len(words) > 0 {
+		return "包含需要审核的词语：" + strings.Join(words, "、")
+	}
+	if len(reLink.FindAllStringIndex(text, MaxLinksWithoutReview+1)) > MaxLinksWithoutReview {
+		return "内容包含较多链接"
+	}
+	return ""
+}
+
+// InitialStatus 新内容的状态和进入审核的原因
+func InitialStatus(author *models.User, text string) (int, string) {
+	if reason := ReviewReason(author, text); reason != "" {
+		return models.StatusPending, reason
+	}
+	return models.StatusNormal, ""
+}
+
+// CanViewComment 判断访问者能否看到评论，post 为评论所属的文章。
+// 看不到所属文章时也看不到其中的评论；待审核和未通过的评论只有作者和审核人员可见
+func CanViewComment(viewer *models.User, comment *models.Comment, post *models.Post) bool {
+	if comment == nil || post == nil || post.ID != comment.PostID || !CanViewPost(viewer, post) {
+		return false
+	}
+	if comment.StatusCode == models.StatusNormal {
+		return true
+	}
+	if viewer != nil && comment.UserID == viewer.ID {
+		return true
+	}
+	return viewer.Can(models.PermModerate)
+}
+
+// VisibleComments 公开的评论列表只包含审核通过的评论
+//
+//	database.DB.Scopes(policies.VisibleComments()).Where("post_id = ?", id).Find(&comments)
+func VisibleComments() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("comments.status_code = ?", models.StatusNormal)
+	}
+}
+
+// ReportHideThreshold 来自可信用户的待处理举报达到此数量时内容自动隐藏并进入审核，0 表示不自动隐藏。
+// 通过环境变量 REPORT_HIDE_THRESHOLD 配置，默认为 3。
+func ReportHideThreshold() int {
+	if v, err := strconv.Atoi(os.Getenv("REPORT_HIDE_THRESHOLD")); err == nil && v >= 0 {
+		return v
+	}
+	return 3
+}
+
+// ReportHiddenReason 因举报自动隐藏的内容的审核原因，驳回举报时据此恢复显示
+const ReportHiddenReason = "多名用户举报，等待审核"
+
+// TrustedReporterDays 注册满这么多天的用户的举报才计入自动隐藏，避免用新注册的账号批量举报。
+// 通过环境变量 REPORT_TRUSTED_DAYS 配置，默认为 7，0 表示不限。
+func TrustedReporterDays() int {
+	if v, err := strconv.Atoi(os.Getenv("REPORT_TRUSTED_DAYS")); err == nil && v >= 0 {
+		return v
+	}
+	return 7
+}
+
+// IsTrustedReporter 判断用户的举报是否计入自动隐藏：审核人员，或等级不低于 TrustedLevel 且注册满 TrustedReporterDays 天的用户
+func IsTrustedReporter(user *models.User) bool {
+	if user == nil {
+		return false
+	}
+	if user.Can(models.PermModerate) {
+		return true
+	}
+	if user.Level < TrustedLevel() {
+		return false
+	}
+	return time.Since(user.CreatedAt) >= time.Duration(TrustedReporterDays())*24*time.Hour
+}
+
+// ActiveSanctions 筛选当前生效的处罚：已开始、未到期且未撤销
+//
+//	database.DB.Scopes(policies.ActiveSanctions()).Where("user_id = ?", user.ID).Find(&sanctions)
+func ActiveSanctions() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		now := time.Now()
+		return db.Where("user_sanctions.revoked_at IS NULL AND user_sanctions.starts_at <= ?", now).
+			Where("user_sanctions.expires_at IS NULL OR user_sanctions.expires_at > ?", now)
+	}
+}
+
+// CanSanction 判断能否处罚目标用户：不能处罚自己，只有管理员可以处罚拥有审核权限的用户，管理员不能被处罚
+func CanSanction(actor, target *models.User) bool {
+	if actor == nil || target == nil || actor.ID == target.ID || target.IsAdmin() {
+		return false
+	}
+	if target.Can(models.PermModerate) {
+		return actor.IsAdmin()
+	}
+	return actor.Can(models.PermSanction)
+}
@@ -0,0 +1,57 @@
+package policies
+
+import (
+	"testing"
+
+	"gin-doniai/models"
+)
+
+func TestCanViewComment(t *testing.T) {
+	author := &models.User{ID: 1, Role: models.RoleMember}
+	commenter := &models.User{ID: 2, Role: models.RoleMember}
+	member := &models.User{ID: 3, Role: models.RoleMember}
+	lv2 := &models.User{ID: 4, Role: models.RoleMember, Level: 2}
+	moderator := &models.User{ID: 5, Role: models.RoleModerator}
+
+	post := func(status, readLimit int) *models.Post {
+		p := &models.Post{UserId: 1, StatusCode: status, ReadLimit: readLimit}
+		p.ID = 10
+		return p
+	}
+	comment := func(status int) *models.Comment {
+		return &models.Comment{ID: 100, PostID: 10, UserID: 2, StatusCode: status}
+	}
+	otherPost := post(models.StatusNormal, models.ReadLimitPublic)
+	otherPost.ID = 11
+
+	tests := []struct {
+		name    string
+		viewer  *models.User
+		comment *models.Comment
+		post    *models.Post
+		want    bool
+	}{
+		{"公开文章的公开评论", nil, comment(models.StatusNormal), post(models.StatusNormal, models.ReadLimitPublic), true},
+		{"待审核评论对游客不可见", nil, comment(models.StatusPending), post(models.StatusNormal, models.ReadLimitPublic), false},
+		{"待审核评论对评论者可见", commenter, comment(models.StatusPending), post(models.StatusNormal, models.ReadLimitPublic), true},
+		{"待审核评论对版主可见", moderator, comment(models.StatusPending), post(models.StatusNormal, models.ReadLimitPublic), true},
+		{"私有文章的评论", member, comment(models.StatusNormal), post(models.StatusNormal, models.ReadLimitPrivate), false},
+		{"私有文章的评论对评论者也不可见", commenter, comment(models.StatusNormal), post(models.StatusNormal, models.ReadLimitPrivate), false},
+		{"私有文章的评论对文章作者可见", author, comment(models.StatusNormal), post(models.StatusNormal, models.ReadLimitPrivate), true},
+		{"等级不足", member, comment(models.StatusNormal), post(models.StatusNormal, models.ReadLimitLv2), false},
+		{"等级足够", lv2, comment(models.StatusNormal), post(models.StatusNormal, models.ReadLimitLv2), true},
+		{"待审核文章的评论", member, comment(models.StatusNormal), post(models.StatusPending, models.ReadLimitPublic), false},
+		{"未通过文章的评论", nil, comment(models.StatusNormal), post(models.StatusDisabled, models.ReadLimitPublic), false},
+		{"待审核文章的评论对版主可见", moderator, comment(models.StatusNormal), post(models.StatusPending, models.ReadLimitPublic), true},
+		{"文章与评论不对应", member, comment(models.StatusNormal), otherPost, false},
+		{"缺少文章", member, comment(models.StatusNormal), nil, false},
+		{"缺少评论", member, nil, post(models.StatusNormal, models.ReadLimitPublic), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanViewComment(tt.viewer, tt.comment, tt.post); got != tt.want {
+				t.Errorf("CanViewComment = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package policies
+
+import (
+	"os"
+	"strconv"
+
+	"gin-doniai/models"
+
+	"gorm.io/gorm"
+)
+
+// CanModifyPost 判断用户能否修改或删除文章：作者本人或拥有文章管理权限的用户
+func CanModifyPost(user *models.User, post *models.Post) bool {
+	if user == nil || post == nil {
+		return false
+	}
+	if uint(post.UserId) == user.ID {
+		return true
+	}
+	return user.Can(models.PermManagePosts)
+}
+
+// CanModifyComment 判断用户能否修改或删除评论：作者本人或拥有评论管理权限的用户
+func CanModifyComment(user *models.User, comment *models.Comment) bool {
+	if user == nil || comment == nil {
+		return false
+	}
+	if comment.UserID == user.ID {
+		return true
+	}
+	return user.Can(models.PermManageComments)
+}
+
+// CanRestorePost 判断用户能否恢复已删除的文章：拥有文章管理权限的用户，或自己删除文章的作者。
+// 被版主删除的文章，作者只能在回收站中看到，不能自行恢复
+func CanRestorePost(user *models.User, post *models.Post) bool {
+	if user == nil || post == nil {
+		return false
+	}
+	if user.Can(models.PermManagePosts) {
+		return true
+	}
+	return uint(post.UserId) == user.ID && post.DeletedBy == user.ID
+}
+
+// CanRestoreComment 判断用户能否恢复已删除的评论，规则与文章相同
+func CanRestoreComment(user *models.User, comment *models.Comment) bool {
+	if user == nil || comment == nil {
+		return false
+	}
+	if user.Can(models.PermManageComments) {
+		return true
+	}
+	return comment.UserID == user.ID && comment.DeletedBy == user.ID
+}
+
+// TrashRetentionDays 回收站中的内容保留的天数，超过后由定时任务永久删除，0 表示不自动清理。
+// 通过环境变量 TRASH_RETENTION_DAYS 配置，默认为 30。
+func TrashRetentionDays() int {
+	if v, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && v >= 0 {
+		return v
+	}
+	return 30
+}
+
+// RequiredLevel 返回阅读限制对应的最低用户等级，公开文章返回0
+func RequiredLevel(readLimit int) int {
+	switch readLimit {
+	case models.ReadLimitLv1:
+		return 1
+	case models.ReadLimitLv2:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// maxReadLimit 返回用户等级可阅读的最高阅读限制（不含私有）
+func maxReadLimit(viewer *models.User) int {
+	if viewer == nil {
+		return models.ReadLimitPublic
+	}
+	switch {
+	case viewer.Level >= 2:
+		return models.ReadLimitLv2
+	case viewer.Level >= 1:
+		return models.ReadLimitLv1
+	default:
+		return models.ReadLimitPublic
+	}
+}
+
+// IsPrivatePost 是否为仅作者可见的私有文章
+func IsPrivatePost(post *models.Post) bool {
+	return post.ReadLimit >= models.ReadLimitPrivate
+}
+
+// CanViewPost 判断访问者能否阅读文章，待审核和未通过的文章只有作者和审核人员可见
+func CanViewPost(viewer *models.User, post *models.Post) bool {
+	if post == nil {
+		return false
+	}
+	if viewer != nil && uint(post.UserId) == viewer.ID {
+		return true
+	}
+	if post.StatusCode != models.StatusNormal {
+		return viewer.Can(models.PermModerate)
+	}
+	if IsPrivatePost(post) {
+		return false
+	}
+	return post.ReadLimit <= maxReadLimit(viewer)
+}
+
+// IsHiddenPost 判断看不到的文章是否应对访问者表现为不存在：待审核、未通过和私有的文章是，
+// 只因等级不足而看不到的文章不是，这时应提示需要的等级
+func IsHiddenPost(viewer *models.User, post *models.Post) bool {
+	if CanViewPost(viewer, post) {
+		return false
+	}
+	return post == nil || post.StatusCode != models.StatusNormal || IsPrivatePost(post)
+}
+
+// SearchVisibility 搜索时的可见性条件，与 VisiblePosts 一致：阅读限制不超过 readLimit 的文章，以及 ownerID 本人的文章。
+// 搜索索引中只有审核通过的文章
+func SearchVisibility(viewer *models.User) (readLimit int, ownerID uint) {
+	if viewer == nil {
+		return models.ReadLimitPublic, 0
+	}
+	return maxReadLimit(viewer), viewer.ID
+}
+
+// VisiblePosts 文章列表查询的可见性过滤，所有读取文章列表的地方都应使用，只包含审核通过的文章
+//
+//	database.DB.Scopes(policies.VisiblePosts(user)).Find(&posts)
+func VisiblePosts(viewer *models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("posts.status_code = ?", models.StatusNormal)
+		if viewer == nil {
+			return db.Where("posts.read_limit <= ?", models.ReadLimitPublic)
+		}
+		return db.Where("(posts.read_limit <= ? OR posts.user_id = ?)", maxReadLimit(viewer), viewer.ID)
+	}
+}
@@ -0,0 +1,45 @@
+package policies
+
+import (
+	"testing"
+
+	"gin-doniai/models"
+)
+
+func TestIsHiddenPost(t *testing.T) {
+	author := &models.User{ID: 1, Role: models.RoleMember}
+	member := &models.User{ID: 2, Role: models.RoleMember}
+	moderator := &models.User{ID: 3, Role: models.RoleModerator}
+
+	post := func(status, readLimit int) *models.Post {
+		return &models.Post{UserId: 1, StatusCode: status, ReadLimit: readLimit}
+	}
+
+	tests := []struct {
+		name   string
+		viewer *models.User
+		post   *models.Post
+		hidden bool
+		view   bool
+	}{
+		{"公开文章", nil, post(models.StatusNormal, models.ReadLimitPublic), false, true},
+		{"等级不足提示等级而不是不存在", member, post(models.StatusNormal, models.ReadLimitLv2), false, false},
+		{"私有文章对他人不存在", member, post(models.StatusNormal, models.ReadLimitPrivate), true, false},
+		{"待审核文章对他人不存在", member, post(models.StatusPending, models.ReadLimitPublic), true, false},
+		{"未通过文章对游客不存在", nil, post(models.StatusDisabled, models.ReadLimitPublic), true, false},
+		{"待审核的等级文章对他人不存在", nil, post(models.StatusPending, models.ReadLimitLv1), true, false},
+		{"待审核文章对作者可见", author, post(models.StatusPending, models.ReadLimitPublic), false, true},
+		{"待审核文章对版主可见", moderator, post(models.StatusPending, models.ReadLimitPublic), false, true},
+		{"nil 文章", member, nil, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanViewPost(tt.viewer, tt.post); got != tt.view {
+				t.Errorf("CanViewPost = %v，期望 %v", got, tt.view)
+			}
+			if got := IsHiddenPost(tt.viewer, tt.post); got != tt.hidden {
+				t.Errorf("IsHiddenPost = %v，期望 %v", got, tt.hidden)
+			}
+		})
+	}
+}
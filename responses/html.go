@@ -0,0 +1,22 @@
+package responses
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// CSRFContextKey CSRF 中间件把当前会话的令牌保存在上下文中的键
+const CSRFContextKey = "csrf_token"
+
+// SanctionNoticeKey 当前用户被禁言时，用户中间件把处罚说明保存在上下文中的键
+const SanctionNoticeKey = "sanction_notice"
+
+// HTML 渲染页面，自动加入 csrfToken 供模板输出到 <meta name="csrf-token">，
+// 以及在页头展示的处罚说明 sanctionNotice
+func HTML(c *gin.Context, status int, name string, data gin.H) {
+	if data == nil {
+		data = gin.H{}
+	}
+	data["csrfToken"] = c.GetString(CSRFContextKey)
+	data["sanctionNotice"] = c.GetString(SanctionNoticeKey)
+	c.HTML(status, name, data)
+}
@@ -0,0 +1,131 @@
+package responses
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode 接口统一的错误码
+type ErrorCode string
+
+const (
+	CodeOK              ErrorCode = "OK"
+	CodeInvalidRequest  ErrorCode = "INVALID_REQUEST"    // 请求参数错误
+	CodeUnauthorized    ErrorCode = "UNAUTHORIZED"       // 未登录或凭证无效
+	CodeForbidden       ErrorCode = "FORBIDDEN"          // 没有权限
+	CodeLevelTooLow     ErrorCode = "LEVEL_TOO_LOW"      // 用户等级不足
+	CodeEmailUnverified ErrorCode = "EMAIL_UNVERIFIED"   // 邮箱尚未验证
+	CodeNotFound        ErrorCode = "NOT_FOUND"          // 资源不存在
+	CodeConflict        ErrorCode = "CONFLICT"           // 资源冲突（如重复注册）
+	CodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"  // 请求过于频繁
+	CodeCSRFInvalid     ErrorCode = "CSRF_TOKEN_INVALID" // CSRF 令牌缺失或不匹配
+	CodeContentRejected ErrorCode = "CONTENT_REJECTED"   // 内容包含禁止发布的词语
+	CodeAccountBanned   ErrorCode = "ACCOUNT_BANNED"     // 账号被封禁
+	CodeAccountMuted    ErrorCode = "ACCOUNT_MUTED"      // 账号被禁言
+	CodeIPBanned        ErrorCode = "IP_BANNED"          // IP 被封禁
+	CodeInternal        ErrorCode = "INTERNAL_ERROR"     // 服务器内部错误
+)
+
+// Meta 分页信息
+type Meta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// Envelope 所有JSON接口统一的响应格式
+type Envelope struct {
+	Success bool        `json:"success"`
+	Code    ErrorCode   `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
+}
+
+// OK 返回成功响应
+func OK(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, Envelope{Success: true, Code: CodeOK, Message: message, Data: data})
+}
+
+// Created 返回资源创建成功的响应
+func Created(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusCreated, Envelope{Success: true, Code: CodeOK, Message: message, Data: data})
+}
+
+// List 返回带分页信息的列表响应
+func List(c *gin.Context, data interface{}, meta *Meta) {
+	c.JSON(http.StatusOK, Envelope{Success: true, Code: CodeOK, Data: data, Meta: meta})
+}
+
+// Error 返回错误响应
+func Error(c *gin.Context, status int, code ErrorCode, message string) {
+	c.JSON(status, Envelope{Success: false, Code: code, Message: message})
+}
+
+// Abort 返回错误响应并中止后续处理（用于中间件）
+func Abort(c *gin.Context, status int, code ErrorCode, message string) {
+	c.AbortWithStatusJSON(status, Envelope{Success: false, Code: code, Message: message})
+}
+
+// BadRequest 400 请求参数错误
+func BadRequest(c *gin.Context, message string) {
+	Error(c, http.StatusBadRequest, CodeInvalidRequest, message)
+}
+
+// Unauthorized 401 未登录
+func Unauthorized(c *gin.Context, message string) {
+	Error(c, http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden 403 没有权限
+func Forbidden(c *gin.Context, message string) {
+	Error(c, http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound 404 资源不存在
+func NotFound(c *gin.Context, message string) {
+	Error(c, http.StatusNotFound, CodeNotFound, message)
+}
+
+// Internal 500 服务器内部错误
+func Internal(c *gin.Context, message string) {
+	Error(c, http.StatusInternalServerError, CodeInternal, message)
+}
+
+// TooManyRequests 429 请求过于频繁，retryAfter 大于0时设置 Retry-After 头
+func TooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+	Error(c, http.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
+// PageParams 解析分页参数 page 和 per_page，per_page 不超过100
+func PageParams(c *gin.Context, defaultPerPage int) (page, perPage int) {
+	page, _ = strconv.Atoi(c.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ = strconv.Atoi(c.Query("per_page"))
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	return page, perPage
+}
+
+// NewMeta 根据总数生成分页信息
+func NewMeta(page, perPage int, total int64) *Meta {
+	return &Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"gin-doniai/handlers"
+	"gin-doniai/middlewares"
+	"gin-doniai/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerAPIRoutes 注册 /api/v1 下的 JSON API 路由
+//
+// 使用个人访问令牌调用时由 RequireScope 检查令牌的权限范围，会话登录不受影响
+func registerAPIRoutes(api *gin.RouterGroup) {
+	var (
+		readPosts     = middlewares.RequireScope(models.ScopePostsRead)
+		writePosts    = middlewares.RequireScope(models.ScopePostsWrite)
+		writeComments = middlewares.RequireScope(models.ScopeCommentsWrite)
+		writeProfile  = middlewares.RequireScope(models.ScopeProfileWrite)
+		admin         = middlewares.RequireScope(models.ScopeAdmin)
+		verified      = middlewares.RequireVerifiedEmail()
+		notMuted      = middlewares.RequireNotMuted()
+	)
+
+	api.GET("/online/count", handlers.GetOnlineUserCount)
+	api.POST("/auth/forgot-password", handlers.ForgotPassword)
+	api.POST("/auth/reset-password", handlers.ProcessResetPassword)
+
+	commentRoutes := api.Group("/comments")
+	{
+		commentRoutes.POST("", middlewares.RequireLogin(), writeComments, verified, notMuted, handlers.CreateComment)
+		commentRoutes.GET("", readPosts, handlers.GetComments)
+		commentRoutes.GET("/:id", readPosts, handlers.GetComment)
+		commentRoutes.PUT("/:id", middlewares.RequireLogin(), writeComments, notMuted, handlers.UpdateComment)           // 作者或版主
+		commentRoutes.DELETE("/:id", middlewares.RequireLogin(), writeComments, handlers.DeleteComment)                  // 作者或版主
+		commentRoutes.POST("/:id/restore", middlewares.RequireLogin(), writeComments, notMuted, handlers.RestoreComment) // 从回收站恢复
+		commentRoutes.POST("/:id/like", middlewares.RequireLogin(), writeComments, handlers.LikeComment)
+		commentRoutes.POST("/:id/report", middlewares.RequireLogin(), writeComments, verified, handlers.ReportComment)
+	}
+
+	userRoutes := api.Group("/users")
+	{
+		userRoutes.POST("", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.CreateUser)                  // 创建用户
+		userRoutes.GET("", handlers.GetUsers)                                                                                   // 获取所有用户
+		userRoutes.GET("/:id", handlers.GetUser)                                                                                // 获取单个用户
+		userRoutes.PUT("/:id", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.UpdateUser)               // 更新用户
+		userRoutes.DELETE("/:id", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.DeleteUser)            // 删除用户（软删除）
+		userRoutes.DELETE("/:id/force", middlewares.RequirePermission(models.PermForceDelete), admin, handlers.ForceDeleteUser) // 强制删除
+		userRoutes.POST("/:id/restore", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.RestoreUser)     // 从回收站恢复
+		userRoutes.PUT("/profile", middlewares.RequireLogin(), writeProfile, handlers.UpdateUserProfile)                        // 更新用户资料
+		userRoutes.PUT("/password", middlewares.RequireSession(), handlers.UpdateUserPassword)                                  // 修改用户密码
+		userRoutes.PUT("/email", middlewares.RequireSession(), handlers.ChangeEmail)                                            // 修改邮箱（确认新邮箱后生效）
+		userRoutes.POST("/email/verification", middlewares.RequireSession(), handlers.ResendEmailVerification)                  // 重新发送验证邮件
+	}
+
+	postRoutes := api.Group("/posts")
+	{
+		postRoutes.POST("", middlewares.RequireLogin(), writePosts, verified, notMuted, handlers.CreatePost)                    // 创建文章（需已验证邮箱，禁言期间不可用）
+		postRoutes.GET("", readPosts, handlers.GetPosts)                                                                        // 获取所有文章
+		postRoutes.GET("/:id", readPosts, handlers.GetPost)                                                                     // 获取单个文章
+		postRoutes.PUT("/:id", middlewares.RequireLogin(), writePosts, notMuted, handlers.UpdatePost)                           // 更新文章（作者或版主）
+		postRoutes.DELETE("/:id", middlewares.RequireLogin(), writePosts, handlers.DeletePost)                                  // 删除文章（软删除，作者或版主）
+		postRoutes.POST("/:id/like", middlewares.RequireLogin(), writePosts, handlers.LikePost)                                 // 文章点赞
+		postRoutes.DELETE("/:id/force", middlewares.RequirePermission(models.PermForceDelete), admin, handlers.ForceDeletePost) // 强制删除
+		postRoutes.POST("/:id/restore", middlewares.RequireLogin(), writePosts, notMuted, handlers.RestorePost)                 // 从回收站恢复（自己删除的文章或版主）
+		postRoutes.POST("/:id/favorite", middlewares.RequireLogin(), writePosts, handlers.FavoritePost)                         // 文章收藏
+		postRoutes.POST("/:id/report", middlewares.RequireLogin(), writePosts, verified, handlers.ReportPost)                   // 举报文章（每人一次）
+	}
+
+	// 全文搜索，只返回当前用户可读的文章
+	searchRoutes := api.Group("/search")
+	{
+		searchRoutes.GET("/posts", readPosts, handlers.SearchPosts)
+	}
+
+	// 分类，子分类放在上级分类的 children 中
+	categoryRoutes := api.Group("/categories")
+	{
+		categoryRoutes.GET("", readPosts, handlers.GetCategories)
+		categoryRoutes.GET("/:id", readPosts, handlers.GetCategory)
+		categoryRoutes.POST("", middlewares.RequirePermission(models.PermManageCategories), admin, handlers.CreateCategory)
+		categoryRoutes.POST("/sort", middlewares.RequirePermission(models.PermManageCategories), admin, handlers.SortCategories) // 按 ids 的顺序重新排序
+		categoryRoutes.PUT("/:id", middlewares.RequirePermission(models.PermManageCategories), admin, handlers.UpdateCategory)
+		categoryRoutes.DELETE("/:id", middlewares.RequirePermission(models.PermManageCategories), admin, handlers.DeleteCategory) // 软删除，需先移走子分类
+	}
+
+	// 回收站，作者只能看到自己的内容，版主可以看到全部内容
+	trashRoutes := api.Group("/trash", middlewares.RequireLogin())
+	{
+		trashRoutes.GET("/posts", readPosts, handlers.ListTrashedPosts)
+		trashRoutes.GET("/comments", readPosts, handlers.ListTrashedComments)
+		trashRoutes.GET("/users", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.ListTrashedUsers)
+	}
+
+	// 审核队列，版主和管理员可用
+	moderationRoutes := api.Group("/moderation", middlewares.RequirePermission(models.PermModerate), admin)
+	{
+		moderationRoutes.GET("/posts", handlers.ListModerationPosts)
+		moderationRoutes.POST("/posts/:id", handlers.ReviewPost)
+		moderationRoutes.GET("/comments", handlers.ListModerationComments)
+		moderationRoutes.POST("/comments/:id", handlers.ReviewComment)
+		moderationRoutes.GET("/reports", handlers.ListReports)
+		moderationRoutes.POST("/reports/:id", handlers.HandleReport) // 对同一内容的所有待处理举报生效
+	}
+
+	// 禁言、封禁用户和 IP，版主和管理员可用
+	sanctionRoutes := api.Group("/sanctions", middlewares.RequirePermission(models.PermSanction), admin)
+	{
+		sanctionRoutes.GET("", handlers.ListSanctions)
+		sanctionRoutes.POST("", handlers.CreateSanction)
+		sanctionRoutes.DELETE("/:id", handlers.RevokeSanction) // 提前解除
+	}
+
+	// 管理后台，各类对象分别需要对应的管理权限
+	adminRoutes := api.Group("/admin")
+	{
+		adminRoutes.GET("/stats", middlewares.RequirePermission(models.PermModerate), admin, handlers.AdminStats) // 缓存1分钟
+		adminRoutes.GET("/users", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.AdminListUsers)
+		adminRoutes.POST("/users/bulk", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.AdminBulkUsers)
+		adminRoutes.GET("/posts", middlewares.RequirePermission(models.PermManagePosts), admin, handlers.AdminListPosts)
+		adminRoutes.POST("/posts/bulk", middlewares.RequirePermission(models.PermManagePosts), admin, handlers.AdminBulkPosts)
+		adminRoutes.GET("/comments", middlewares.RequirePermission(models.PermManageComments), admin, handlers.AdminListComments)
+		adminRoutes.POST("/comments/bulk", middlewares.RequirePermission(models.PermManageComments), admin, handlers.AdminBulkComments)
+		adminRoutes.GET("/categories", middlewares.RequirePermission(models.PermManageCategories), admin, handlers.AdminListCategories)
+		adminRoutes.POST("/categories/bulk", middlewares.RequirePermission(models.PermManageCategories), admin, handlers.AdminBulkCategories)
+		adminRoutes.GET("/audit-logs", middlewares.RequirePermission(models.PermViewAuditLog), admin, handlers.AdminListAuditLogs)
+		adminRoutes.GET("/audit-logs/export", middlewares.RequirePermission(models.PermViewAuditLog), admin, handlers.ExportAuditLogs) // JSON Lines
+	}
+
+	// 敏感词，修改后立即生效
+	wordRoutes := api.Group("/sensitive-words", middlewares.RequirePermission(models.PermManageWords), admin)
+	{
+		wordRoutes.GET("", handlers.GetSensitiveWords)
+		wordRoutes.POST("", handlers.CreateSensitiveWords)
+		wordRoutes.PUT("/:id", handlers.UpdateSensitiveWord)
+		wordRoutes.DELETE("/:id", handlers.DeleteSensitiveWord)
+	}
+
+	// 个人访问令牌只能在网页会话中管理，令牌不能用来创建新令牌
+	tokenRoutes := api.Group("/tokens", middlewares.RequireSession())
+	{
+		tokenRoutes.GET("", handlers.GetAccessTokens)
+		tokenRoutes.POST("", handlers.CreateAccessToken)
+		tokenRoutes.DELETE("/:id", handlers.DeleteAccessToken)
+	}
+
+	// 登录设备管理
+	sessionRoutes := api.Group("/sessions", middlewares.RequireSession())
+	{
+		sessionRoutes.GET("", handlers.GetSessions)
+		sessionRoutes.DELETE("", handlers.DeleteOtherSessions) // 其他设备全部下线
+		sessionRoutes.DELETE("/:id", handlers.DeleteSession)
+	}
+
+	// 关联的第三方账号，关联需要跳转到第三方授权页面：/auth/github?link=1
+	identityRoutes := api.Group("/identities", middlewares.RequireSession())
+	{
+		identityRoutes.GET("", handlers.GetIdentities)
+		identityRoutes.DELETE("/:provider", handlers.UnlinkIdentity)
+	}
+
+	// 两步验证只能在网页会话中设置
+	twoFactorRoutes := api.Group("/two-factor", middlewares.RequireSession())
+	{
+		twoFactorRoutes.POST("/setup", handlers.SetupTwoFactor)
+		twoFactorRoutes.POST("/enable", handlers.EnableTwoFactor)
+		twoFactorRoutes.POST("/disable", handlers.DisableTwoFactor)
+		twoFactorRoutes.POST("/recovery-codes", handlers.RegenerateRecoveryCodes)
+	}
+}
+
+// registerLegacyAPIRoutes 注册已废弃的 /api 路由，只保留 /api/v1 之前就有的接口，
+// 处理函数和中间件与 /api/v1 中的同名接口一致，新接口只在 /api/v1 下提供
+func registerLegacyAPIRoutes(api *gin.RouterGroup) {
+	var (
+		readPosts     = middlewares.RequireScope(models.ScopePostsRead)
+		writePosts    = middlewares.RequireScope(models.ScopePostsWrite)
+		writeComments = middlewares.RequireScope(models.ScopeCommentsWrite)
+		writeProfile  = middlewares.RequireScope(models.ScopeProfileWrite)
+		admin         = middlewares.RequireScope(models.ScopeAdmin)
+		verified      = middlewares.RequireVerifiedEmail()
+		notMuted      = middlewares.RequireNotMuted()
+	)
+
+	api.GET("/online/count", handlers.GetOnlineUserCount)
+	api.POST("/auth/forgot-password", handlers.ForgotPassword)
+	api.POST("/auth/reset-password", handlers.ProcessResetPassword)
+
+	commentRoutes := api.Group("/comments")
+	{
+		commentRoutes.POST("", middlewares.RequireLogin(), writeComments, verified, notMuted, handlers.CreateComment)
+		commentRoutes.GET("", readPosts, handlers.GetComments)
+		commentRoutes.GET("/:id", readPosts, handlers.GetComment)
+		commentRoutes.PUT("/:id", middlewares.RequireLogin(), writeComments, notMuted, handlers.UpdateComment)
+		commentRoutes.DELETE("/:id", middlewares.RequireLogin(), writeComments, handlers.DeleteComment)
+		commentRoutes.POST("/:id/like", middlewares.RequireLogin(), writeComments, handlers.LikeComment)
+	}
+
+	userRoutes := api.Group("/users")
+	{
+		userRoutes.POST("", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.CreateUser)
+		userRoutes.GET("", handlers.GetUsers)
+		userRoutes.GET("/:id", handlers.GetUser)
+		userRoutes.PUT("/:id", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.UpdateUser)
+		userRoutes.DELETE("/:id", middlewares.RequirePermission(models.PermManageUsers), admin, handlers.DeleteUser)
+		userRoutes.DELETE("/:id/force", middlewares.RequirePermission(models.PermForceDelete), admin, handlers.ForceDeleteUser)
+		userRoutes.PUT("/profile", middlewares.RequireLogin(), writeProfile, handlers.UpdateUserProfile)
+		userRoutes.PUT("/password", middlewares.RequireSession(), handlers.UpdateUserPassword)
+	}
+
+	postRoutes := api.Group("/posts")
+	{
+		postRoutes.POST("", middlewares.RequireLogin(), writePosts, verified, notMuted, handlers.CreatePost)
+		postRoutes.GET("", readPosts, handlers.GetPosts)
+		postRoutes.GET("/:id", readPosts, handlers.GetPost)
+		postRoutes.PUT("/:id", middlewares.RequireLogin(), writePosts, notMuted, handlers.UpdatePost)
+		postRoutes.DELETE("/:id", middlewares.RequireLogin(), writePosts, handlers.DeletePost)
+		postRoutes.POST("/:id/like", middlewares.RequireLogin(), writePosts, handlers.LikePost)
+		postRoutes.DELETE("/:id/force", middlewares.RequirePermission(models.PermForceDelete), admin, handlers.ForceDeletePost)
+		postRoutes.POST("/:id/favorite", middlewares.RequireLogin(), writePosts, handlers.FavoritePost)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-doniai/models"
+	"gin-doniai/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeProbe 以不同身份请求 /api/v1 和 /api 下的每个接口，通过中间件是否拦截推断接口实际的登录、权限和令牌要求。
+// 处理函数不会被真正执行到底：没有数据库，通过中间件后的 panic 会被恢复并视为放行
+type routeProbe struct {
+	engine *gin.Engine
+	setup  func(c *gin.Context)
+	status int // 被中间件拦截时的状态码，放行时为 0
+}
+
+func newRouteProbe() *routeProbe {
+	gin.SetMode(gin.TestMode)
+	p := &routeProbe{engine: gin.New()}
+	record := func(c *gin.Context) {
+		p.setup(c)
+		defer func() {
+			recover()
+			if c.IsAborted() {
+				p.status = c.Writer.Status()
+			}
+		}()
+		c.Next()
+	}
+	registerAPIRoutes(p.engine.Group("/api/v1", record))
+	registerLegacyAPIRoutes(p.engine.Group("/api", record))
+	return p
+}
+
+// blocked 以 user 和 token 的身份请求接口，返回中间件拦截时的状态码
+func (p *routeProbe) blocked(route gin.RouteInfo, user *models.User, token *models.AccessToken) int {
+	p.status = 0
+	p.setup = func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+		if token != nil {
+			c.Set("access_token", token)
+		}
+	}
+	path := strings.NewReplacer(":id", "1", ":provider", "github").Replace(route.Path)
+	p.engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(route.Method, path, nil))
+	return p.status
+}
+
+func TestAPIDocsMatchRoutes(t *testing.T) {
+	describeAPIRoutes()
+	p := newRouteProbe()
+
+	member := &models.User{ID: 1, Role: models.RoleMember, EmailVerified: true}
+	moderator := &models.User{ID: 1, Role: models.RoleModerator, EmailVerified: true}
+	admin := &models.User{ID: 1, Role: models.RoleAdmin, EmailVerified: true}
+	token := func(scope string) *models.AccessToken {
+		return &models.AccessToken{UserID: admin.ID, Scopes: scope, User: *admin}
+	}
+
+	for _, route := range p.engine.Routes() {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			e, ok := openapi.Lookup(route.Handler)
+			if !ok || e.Summary == "" {
+				t.Fatalf("%s 没有登记接口说明", route.Handler)
+			}
+			if status := p.blocked(route, admin, nil); status != 0 {
+				t.Fatalf("管理员会话被拦截（%d），无法推断接口要求", status)
+			}
+
+			auth := p.blocked(route, nil, nil) == http.StatusUnauthorized
+			if documented := e.Auth || e.Permission != "" || e.Session; documented != auth {
+				t.Errorf("文档中需要登录 = %v，路由实际 %v", documented, auth)
+			}
+
+			session := p.blocked(route, admin, token(models.ScopeAdmin)) != 0
+			if e.Session != session {
+				t.Errorf("文档中 Session = %v，路由实际 %v", e.Session, session)
+			}
+
+			// 会员被拦截说明需要权限，具体权限通过版主能否访问与角色权限表对照
+			needsPermission := p.blocked(route, member, nil) == http.StatusForbidden
+			if (e.Permission != "") != needsPermission {
+				t.Errorf("文档中 Permission = %q，路由实际需要权限 = %v", e.Permission, needsPermission)
+			} else if needsPermission {
+				moderatorAllowed := p.blocked(route, moderator, nil) == 0
+				if want := moderator.Can(models.Permission(e.Permission)); want != moderatorAllowed {
+					t.Errorf("文档中 Permission = %q，版主能否访问 = %v，路由实际 %v", e.Permission, want, moderatorAllowed)
+				}
+			}
+
+			if session {
+				if e.Scope != "" {
+					t.Errorf("只允许会话的接口不应登记 Scope = %q", e.Scope)
+				}
+				return
+			}
+			scope := ""
+			if p.blocked(route, admin, token("")) != 0 {
+				scope = models.ScopeAdmin
+				for _, s := range models.AllScopes {
+					if s.Name != models.ScopeAdmin && p.blocked(route, admin, token(s.Name)) == 0 {
+						scope = s.Name
+					}
+				}
+			}
+			if e.Scope != scope {
+				t.Errorf("文档中 Scope = %q，路由实际 %q", e.Scope, scope)
+			}
+		})
+	}
+}
+
+func TestLegacyAPIRoutes(t *testing.T) {
+	// /api/v1 之前就有的接口，/api 下不应再增加新接口
+	legacy := map[string]bool{
+		"GET /api/online/count":          true,
+		"POST /api/auth/forgot-password": true,
+		"POST /api/auth/reset-password":  true,
+		"POST /api/comments":             true,
+		"GET /api/comments":              true,
+		"GET /api/comments/:id":          true,
+		"PUT /api/comments/:id":          true,
+		"DELETE /api/comments/:id":       true,
+		"POST /api/comments/:id/like":    true,
+		"POST /api/users":                true,
+		"GET /api/users":                 true,
+		"GET /api/users/:id":             true,
+		"PUT /api/users/:id":             true,
+		"DELETE /api/users/:id":          true,
+		"DELETE /api/users/:id/force":    true,
+		"PUT /api/users/profile":         true,
+		"PUT /api/users/password":        true,
+		"POST /api/posts":                true,
+		"GET /api/posts":                 true,
+		"GET /api/posts/:id":             true,
+		"PUT /api/posts/:id":             true,
+		"DELETE /api/posts/:id":          true,
+		"POST /api/posts/:id/like":       true,
+		"DELETE /api/posts/:id/force":    true,
+		"POST /api/posts/:id/favorite":   true,
+	}
+
+	handlers := map[string]string{}
+	routes := newRouteProbe().engine.Routes()
+	for _, route := range routes {
+		handlers[route.Method+" "+route.Path] = route.Handler
+	}
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if strings.HasPrefix(route.Path, "/api/v1/") {
+			continue
+		}
+		if !legacy[key] {
+			t.Errorf("%s 不是 /api/v1 之前的接口，只应在 /api/v1 下注册", key)
+			continue
+		}
+		delete(legacy, key)
+		v1 := route.Method + " /api/v1" + strings.TrimPrefix(route.Path, "/api")
+		if handlers[v1] != route.Handler {
+			t.Errorf("%s 的处理函数 %s 与 %s 不一致", key, route.Handler, v1)
+		}
+	}
+	for key := range legacy {
+		t.Errorf("缺少旧接口 %s", key)
+	}
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"html/template"
+	"strings"
+)
+
+// highlightTerms 需要在结果中标出的词：搜索词中的单词、整段中日韩文字以及其中相邻的两个字，
+// 文章可能只包含整段文字的一部分
+func highlightTerms(query string) [][]rune {
+	var terms [][]rune
+	segments(query, func(runes []rune, start int, cjk bool) {
+		terms = append(terms, append([]rune(nil), runes...))
+		if cjk && len(runes) > 2 {
+			for i := 0; i+1 < len(runes); i++ {
+				terms = append(terms, append([]rune(nil), runes[i:i+2]...))
+			}
+		}
+	})
+	return terms
+}
+
+// marks 标出文本中出现搜索词的字符，单词只在前后不是字母、数字时才算命中
+func marks(runes []rune, terms [][]rune) ([]bool, int) {
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = fold(r)
+	}
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		cjk := isCJK(term[0])
+		for i := 0; i+len(term) <= len(folded); i++ {
+			if !hasPrefix(folded[i:], term) {
+				continue
+			}
+			end := i + len(term)
+			if !cjk && ((i > 0 && isWordRune(folded[i-1]) && !isCJK(folded[i-1])) ||
+				(end < len(folded) && isWordRune(folded[end]) && !isCJK(folded[end]))) {
+				continue
+			}
+			for j := i; j < end; j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	return marked, first
+}
+
+func hasPrefix(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if runes[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Highlight 从文本中截取最多 width 个字的片段，尽量包含第一处命中的搜索词，命中的词用 <mark> 标出，
+// 其余内容经过转义。width 不大于 0 时返回全文；文本中没有搜索词时返回开头的片段，found 为 false
+func Highlight(text, query string, width int) (snippet template.HTML, found bool) {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	marked, first := marks(runes, highlightTerms(query))
+
+	start, end := 0, len(runes)
+	if width > 0 && len(runes) > width {
+		if first > width/4 {
+			start = first - width/4
+		}
+		end = start + width
+		if end > len(runes) {
+			end = len(runes)
+			start = end - width
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i + 1
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString("<mark>")
+			b.WriteString(template.HTMLEscapeString(string(runes[i:j])))
+			b.WriteString("</mark>")
+		} else {
+			b.WriteString(template.HTMLEscapeString(string(runes[i:j])))
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return template.HTML(b.String()), first >= 0
+}
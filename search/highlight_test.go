@@ -0,0 +1,109 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		width int
+		want  string
+		found bool
+	}{
+		{
+			name:  "中文",
+			text:  "学习Go语言",
+			query: "语言",
+			want:  "学习Go<mark>语言</mark>",
+			found: true,
+		},
+		{
+			name:  "英文不区分大小写，保留原文大小写",
+			text:  "Learning GOLANG and go",
+			query: "golang",
+			want:  "Learning <mark>GOLANG</mark> and go",
+			found: true,
+		},
+		{
+			name:  "单词只匹配完整的词",
+			text:  "going to go",
+			query: "go",
+			want:  "going to <mark>go</mark>",
+			found: true,
+		},
+		{
+			name:  "中文旁边的英文单词",
+			text:  "用go写",
+			query: "go",
+			want:  "用<mark>go</mark>写",
+			found: true,
+		},
+		{
+			name:  "多字搜索词只命中部分",
+			text:  "语言学习",
+			query: "编程语言",
+			want:  "<mark>语言</mark>学习",
+			found: true,
+		},
+		{
+			name:  "没有命中时返回开头",
+			text:  "hello world",
+			query: "rust",
+			width: 5,
+			want:  "hello…",
+		},
+		{
+			name:  "截取命中附近的片段",
+			text:  strings.Repeat("一", 20) + "关键" + strings.Repeat("二", 20),
+			query: "关键",
+			width: 8,
+			want:  "…一一<mark>关键</mark>二二二二…",
+			found: true,
+		},
+		{
+			name:  "合并空白",
+			text:  "a\n\n  b\tc",
+			query: "b",
+			want:  "a <mark>b</mark> c",
+			found: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := Highlight(tt.text, tt.query, tt.width)
+			if string(got) != tt.want || found != tt.found {
+				t.Errorf("Highlight(%q, %q) = %q, %v，期望 %q, %v", tt.text, tt.query, got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+	}{
+		{`<script>alert(1)</script>`, "script"},
+		{`<script>alert(1)</script>`, "alert"},
+		{`<img src=x onerror="alert(1)">`, "img"},
+		{`<img src=x onerror="alert(1)">`, "<img"},
+		{`a & b <b>粗体</b>`, "粗体"},
+		{`"quoted" 'single'`, "quoted"},
+		{`<mark>伪造</mark>`, "mark"},
+		{`<svg onload=alert(1)>`, "没有命中"},
+	}
+	for _, tt := range tests {
+		for _, width := range []int{0, 10} {
+			got, _ := Highlight(tt.text, tt.query, width)
+			// 去掉高亮标签后不能再有未转义的 HTML 字符
+			rest := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(string(got))
+			if strings.ContainsAny(rest, `<>"'`) {
+				t.Errorf("Highlight(%q, %q, %d) = %q，包含未转义的 HTML", tt.text, tt.query, width, got)
+			}
+		}
+	}
+}
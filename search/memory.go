@@ -0,0 +1,242 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 文档的各个字段，标题和标签中的命中比正文更重要
+const (
+	fieldTitle = iota
+	fieldTags
+	fieldContent
+	fieldComments
+	numFields
+)
+
+var fieldWeights = [numFields]float64{3, 2, 1, 0.5}
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// 按相关度排序时，新发布的文章最多加权 recencyBoost，加权每 recencyHalfLife 减半
+const (
+	recencyBoost    = 0.5
+	recencyHalfLife = 30 * 24 * time.Hour
+)
+
+// 结果摘要的字数
+const snippetWidth = 160
+
+type termFreqs [numFields]int
+
+type storedDoc struct {
+	doc     Document
+	lengths [numFields]int // 各字段的词数
+	terms   []string
+}
+
+// MemoryIndex 进程内的倒排索引，服务启动时需要重新建立。多个实例部署时各实例的索引互相独立，
+// 应改用共享的搜索后端
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[uint]*storedDoc
+	postings map[string]map[uint]*termFreqs
+	totals   [numFields]int // 各字段的总词数，用于计算平均长度
+}
+
+// NewMemoryIndex 创建空的内存索引
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     map[uint]*storedDoc{},
+		postings: map[string]map[uint]*termFreqs{},
+	}
+}
+
+// Index 写入或替换文章的索引
+func (m *MemoryIndex) Index(doc Document) error {
+	freqs := map[string]*termFreqs{}
+	stored := &storedDoc{doc: doc}
+	add := func(field int, text string) {
+		for _, token := range Tokenize(text) {
+			f := freqs[token.Term]
+			if f == nil {
+				f = &termFreqs{}
+				freqs[token.Term] = f
+				stored.terms = append(stored.terms, token.Term)
+			}
+			f[field]++
+			stored.lengths[field]++
+		}
+	}
+	add(fieldTitle, doc.Title)
+	for _, tag := range doc.Tags {
+		add(fieldTags, tag)
+	}
+	add(fieldContent, doc.Content)
+	for _, comment := range doc.Comments {
+		add(fieldComments, comment)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(doc.ID)
+	m.docs[doc.ID] = stored
+	for term, f := range freqs {
+		list := m.postings[term]
+		if list == nil {
+			list = map[uint]*termFreqs{}
+			m.postings[term] = list
+		}
+		list[doc.ID] = f
+	}
+	for i := range m.totals {
+		m.totals[i] += stored.lengths[i]
+	}
+	return nil
+}
+
+// Delete 从索引中移除文章
+func (m *MemoryIndex) Delete(id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(id)
+	return nil
+}
+
+func (m *MemoryIndex) remove(id uint) {
+	stored, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range stored.terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	for i := range m.totals {
+		m.totals[i] -= stored.lengths[i]
+	}
+	delete(m.docs, id)
+}
+
+type scoredDoc struct {
+	stored *storedDoc
+	score  float64
+}
+
+// Search 所有搜索词都命中（标题、标签、正文或评论中任意一处）的文章才会返回，
+// 相关度按 BM25 计算，各字段按权重合并
+func (m *MemoryIndex) Search(q Query) (*Result, error) {
+	terms := QueryTerms(q.Text)
+	now := time.Now()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []scoredDoc
+	if len(terms) == 0 {
+		for _, stored := range m.docs {
+			if q.Match(&stored.doc) {
+				matches = append(matches, scoredDoc{stored: stored})
+			}
+		}
+	} else {
+		lists := make([]map[uint]*termFreqs, 0, len(terms))
+		for _, term := range terms {
+			list := m.postings[term]
+			if len(list) == 0 {
+				return &Result{}, nil
+			}
+			lists = append(lists, list)
+		}
+		// 从最短的倒排列表开始求交集
+		sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	candidates:
+		for id := range lists[0] {
+			for _, list := range lists[1:] {
+				if _, ok := list[id]; !ok {
+					continue candidates
+				}
+			}
+			stored := m.docs[id]
+			if !q.Match(&stored.doc) {
+				continue
+			}
+			score := m.score(id, stored, lists)
+			age := now.Sub(stored.doc.CreatedAt)
+			if age < 0 {
+				age = 0
+			}
+			score *= 1 + recencyBoost*math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+			matches = append(matches, scoredDoc{stored: stored, score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if q.Sort != SortNewest && len(terms) > 0 && a.score != b.score {
+			return a.score > b.score
+		}
+		if !a.stored.doc.CreatedAt.Equal(b.stored.doc.CreatedAt) {
+			return a.stored.doc.CreatedAt.After(b.stored.doc.CreatedAt)
+		}
+		return a.stored.doc.ID > b.stored.doc.ID
+	})
+
+	result := &Result{Total: len(matches)}
+	start := min(max(q.Offset, 0), len(matches))
+	end := len(matches)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, len(matches))
+	}
+	for _, match := range matches[start:end] {
+		result.Hits = append(result.Hits, m.hit(match, q.Text))
+	}
+	return result, nil
+}
+
+func (m *MemoryIndex) score(id uint, stored *storedDoc, lists []map[uint]*termFreqs) float64 {
+	n := float64(len(m.docs))
+	var score float64
+	for _, list := range lists {
+		df := float64(len(list))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		var tf float64
+		for field, count := range list[id] {
+			if count == 0 {
+				continue
+			}
+			norm := 1.0
+			if m.totals[field] > 0 {
+				avg := float64(m.totals[field]) / n
+				norm = 1 - bm25B + bm25B*float64(stored.lengths[field])/avg
+			}
+			tf += fieldWeights[field] * float64(count) / norm
+		}
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1)
+	}
+	return score
+}
+
+// hit 生成结果的标题和摘要，摘要优先取正文中命中的片段，正文没有命中时取评论中的片段
+func (m *MemoryIndex) hit(match scoredDoc, query string) Hit {
+	doc := &match.stored.doc
+	title, _ := Highlight(doc.Title, query, 0)
+	snippet, found := Highlight(doc.Content, query, snippetWidth)
+	if !found {
+		for _, comment := range doc.Comments {
+			if s, ok := Highlight(comment, query, snippetWidth); ok {
+				snippet = s
+				break
+			}
+		}
+	}
+	return Hit{ID: doc.ID, Score: match.score, Title: title, Snippet: snippet}
+}
@@ -0,0 +1,241 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 与 models 中的阅读限制一致：公开、Lv1、Lv2、仅作者可见
+const (
+	readPublic  = 1
+	readLv1     = 2
+	readLv2     = 3
+	readPrivate = 4
+)
+
+var baseTime = time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+
+func testIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	m := NewMemoryIndex()
+	docs := []Document{
+		{ID: 1, Title: "Go语言入门", Content: "学习 Go 语言的基础语法", Tags: []string{"Go", "入门"},
+			CategoryID: 1, UserID: 10, ReadLimit: readPublic, CreatedAt: baseTime},
+		{ID: 2, Title: "Rust 与 Go 的对比", Content: "两种语言的内存管理", Tags: []string{"Rust"},
+			CategoryID: 2, UserID: 11, ReadLimit: readPublic, CreatedAt: baseTime.AddDate(0, 0, 1)},
+		{ID: 3, Title: "Lv1 可读的 Go 笔记", Content: "并发模式", Tags: []string{"go"},
+			CategoryID: 1, UserID: 11, ReadLimit: readLv1, CreatedAt: baseTime.AddDate(0, 0, 2)},
+		{ID: 4, Title: "Lv2 可读的 Go 进阶", Content: "调度器", Tags: []string{"进阶"},
+			CategoryID: 3, UserID: 12, ReadLimit: readLv2, CreatedAt: baseTime.AddDate(0, 0, 3)},
+		{ID: 5, Title: "私人 Go 草稿", Content: "只有作者能看到", Tags: nil,
+			CategoryID: 1, UserID: 12, ReadLimit: readPrivate, CreatedAt: baseTime.AddDate(0, 0, 4)},
+		{ID: 6, Title: "Python 数据分析", Content: "pandas 教程", Comments: []string{"Go 也能做数据分析吗"},
+			CategoryID: 2, UserID: 10, ReadLimit: readPublic, CreatedAt: baseTime.AddDate(0, 0, 5)},
+	}
+	for _, doc := range docs {
+		if err := m.Index(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func hitIDs(t *testing.T, m *MemoryIndex, q Query) []uint {
+	t.Helper()
+	result, err := m.Search(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []uint{}
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	if result.Total < len(ids) {
+		t.Errorf("Total = %d，小于返回的结果数 %d", result.Total, len(ids))
+	}
+	return ids
+}
+
+func TestMemoryIndexVisibility(t *testing.T) {
+	m := testIndex(t)
+	tests := []struct {
+		name string
+		q    Query
+		want []uint
+	}{
+		{"游客只能看到公开文章", Query{Text: "go", Sort: SortNewest, ReadLimit: readPublic}, []uint{6, 2, 1}},
+		{"Lv1 用户", Query{Text: "go", Sort: SortNewest, ReadLimit: readLv1}, []uint{6, 3, 2, 1}},
+		{"Lv2 用户", Query{Text: "go", Sort: SortNewest, ReadLimit: readLv2}, []uint{6, 4, 3, 2, 1}},
+		{"作者能看到自己的私人文章", Query{Text: "go", Sort: SortNewest, ReadLimit: readPublic, OwnerID: 12}, []uint{6, 5, 4, 2, 1}},
+		{"其他用户看不到私人文章", Query{Text: "草稿", ReadLimit: readLv2, OwnerID: 11}, []uint{}},
+		{"没有搜索词时同样过滤", Query{Sort: SortNewest, ReadLimit: readPublic}, []uint{6, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hitIDs(t, m, tt.q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexFilters(t *testing.T) {
+	m := testIndex(t)
+	tests := []struct {
+		name string
+		q    Query
+		want []uint
+	}{
+		{"分类", Query{Text: "go", CategoryIDs: []uint{1}}, []uint{3, 1}},
+		{"多个分类", Query{Text: "go", CategoryIDs: []uint{1, 3}}, []uint{4, 3, 1}},
+		{"标签不区分大小写", Query{Tag: "GO"}, []uint{3, 1}},
+		{"中文标签", Query{Text: "go", Tag: "入门"}, []uint{1}},
+		{"作者", Query{Text: "go", UserID: 11}, []uint{3, 2}},
+		{"开始日期包含当天", Query{From: baseTime.AddDate(0, 0, 2)}, []uint{6, 4, 3}},
+		{"结束日期不包含", Query{To: baseTime.AddDate(0, 0, 2)}, []uint{2, 1}},
+		{"日期范围", Query{Text: "go", From: baseTime.AddDate(0, 0, 1), To: baseTime.AddDate(0, 0, 4)}, []uint{4, 3, 2}},
+		{"条件组合", Query{Text: "go", CategoryIDs: []uint{1}, UserID: 11, Tag: "go"}, []uint{3}},
+		{"没有符合条件的文章", Query{Text: "go", CategoryIDs: []uint{9}}, []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.ReadLimit = readLv2
+			tt.q.Sort = SortNewest
+			if got := hitIDs(t, m, tt.q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexSearchTerms(t *testing.T) {
+	m := testIndex(t)
+	tests := []struct {
+		name string
+		text string
+		want []uint
+	}{
+		{"所有搜索词都要命中", "go 语言", []uint{2, 1}},
+		{"中文多字", "内存管理", []uint{2}},
+		{"中文单字", "私", []uint{5}},
+		{"连续中文按相邻两个字匹配", "语言入门", []uint{1}},
+		{"不连续的字不命中", "语门", []uint{}},
+		{"评论中的内容", "数据分析 go", []uint{6}},
+		{"全角和大写", "ＲＵＳＴ", []uint{2}},
+		{"没有的词", "java", []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hitIDs(t, m, Query{Text: tt.text, Sort: SortNewest, ReadLimit: readPrivate})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("搜索 %q = %v，期望 %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexRelevance(t *testing.T) {
+	m := NewMemoryIndex()
+	m.Index(Document{ID: 1, Title: "其他", Content: "顺便提到 golang", ReadLimit: readPublic, CreatedAt: baseTime})
+	m.Index(Document{ID: 2, Title: "golang", Content: "golang golang", Tags: []string{"golang"}, ReadLimit: readPublic, CreatedAt: baseTime})
+	m.Index(Document{ID: 3, Title: "评论", Comments: []string{"golang"}, ReadLimit: readPublic, CreatedAt: baseTime})
+
+	if got := hitIDs(t, m, Query{Text: "golang", ReadLimit: readPublic}); !reflect.DeepEqual(got, []uint{2, 1, 3}) {
+		t.Errorf("按相关度排序 = %v，期望 [2 1 3]", got)
+	}
+	if got := hitIDs(t, m, Query{Text: "golang", Sort: SortNewest, ReadLimit: readPublic}); !reflect.DeepEqual(got, []uint{3, 2, 1}) {
+		t.Errorf("发布时间相同时按ID排序 = %v，期望 [3 2 1]", got)
+	}
+}
+
+func TestMemoryIndexPagination(t *testing.T) {
+	m := testIndex(t)
+	q := Query{Text: "go", Sort: SortNewest, ReadLimit: readPrivate, Offset: 2, Limit: 2}
+	result, err := m.Search(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 6 {
+		t.Errorf("Total = %d，期望 6", result.Total)
+	}
+	if got := hitIDs(t, m, q); !reflect.DeepEqual(got, []uint{4, 3}) {
+		t.Errorf("第二页 = %v，期望 [4 3]", got)
+	}
+	q.Offset = 10
+	if got := hitIDs(t, m, q); len(got) != 0 {
+		t.Errorf("超出范围的页 = %v，期望为空", got)
+	}
+}
+
+func TestMemoryIndexUpdateAndDelete(t *testing.T) {
+	m := testIndex(t)
+	q := Query{Text: "go", Sort: SortNewest, ReadLimit: readPublic}
+
+	// 重新索引时替换旧内容
+	m.Index(Document{ID: 1, Title: "Rust 入门", ReadLimit: readPublic, CreatedAt: baseTime})
+	if got := hitIDs(t, m, q); !reflect.DeepEqual(got, []uint{6, 2}) {
+		t.Errorf("更新后 = %v，期望 [6 2]", got)
+	}
+	// 修改阅读限制后游客不再能搜到
+	m.Index(Document{ID: 2, Title: "Rust 与 Go 的对比", ReadLimit: readLv1, CreatedAt: baseTime})
+	if got := hitIDs(t, m, q); !reflect.DeepEqual(got, []uint{6}) {
+		t.Errorf("修改阅读限制后 = %v，期望 [6]", got)
+	}
+	m.Delete(6)
+	m.Delete(99)
+	if got := hitIDs(t, m, q); len(got) != 0 {
+		t.Errorf("删除后 = %v，期望为空", got)
+	}
+	if _, ok := m.postings["python"]; ok {
+		t.Error("删除文章后倒排列表中仍有其中的词")
+	}
+}
+
+func TestMemoryIndexSnippets(t *testing.T) {
+	m := NewMemoryIndex()
+	m.Index(Document{
+		ID:        1,
+		Title:     `<script>alert("go")</script> Go 教程`,
+		Content:   `正文里的 <img src=x onerror=alert(1)> 和 go`,
+		ReadLimit: readPublic,
+		CreatedAt: baseTime,
+	})
+	m.Index(Document{
+		ID:        2,
+		Title:     "无关标题",
+		Content:   "正文没有命中",
+		Comments:  []string{`<b>评论</b> 提到 go & rust`},
+		ReadLimit: readPublic,
+		CreatedAt: baseTime,
+	})
+
+	result, err := m.Search(Query{Text: "go", Sort: SortNewest, ReadLimit: readPublic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 2 {
+		t.Fatalf("结果数 = %d，期望 2", len(result.Hits))
+	}
+	for _, hit := range result.Hits {
+		for _, s := range []string{string(hit.Title), string(hit.Snippet)} {
+			rest := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(s)
+			if strings.ContainsAny(rest, `<>"'`) {
+				t.Errorf("文章 %d 的结果包含未转义的 HTML: %q", hit.ID, s)
+			}
+		}
+	}
+
+	byID := map[uint]Hit{}
+	for _, hit := range result.Hits {
+		byID[hit.ID] = hit
+	}
+	if want := `&lt;script&gt;alert(&#34;<mark>go</mark>&#34;)&lt;/script&gt; <mark>Go</mark> 教程`; string(byID[1].Title) != want {
+		t.Errorf("标题 = %q，期望 %q", byID[1].Title, want)
+	}
+	// 正文没有命中时摘要取评论中的片段
+	if want := `&lt;b&gt;评论&lt;/b&gt; 提到 <mark>go</mark> &amp; rust`; string(byID[2].Snippet) != want {
+		t.Errorf("摘要 = %q，期望 %q", byID[2].Snippet, want)
+	}
+}
@@ -0,0 +1,150 @@
+// Package search 文章全文搜索。
+//
+// Backend 定义搜索后端需要实现的接口，文章和评论修改后由调用方写入或删除索引。
+// 内置的 memory 后端是进程内的倒排索引，中日韩文字按单字和相邻两个字切分，
+// 其余文字按连续的字母、数字切分为单词；其他后端通过 Register 注册后用 Setup 按名称启用。
+package search
+
+import (
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Document 一篇文章的索引内容，公开的评论并入所属文章，搜索结果以文章为单位
+type Document struct {
+	ID         uint
+	Title      string
+	Content    string // 正文纯文本
+	Tags       []string
+	Comments   []string // 公开评论的纯文本
+	CategoryID uint
+	UserID     uint
+	ReadLimit  int
+	CreatedAt  time.Time
+}
+
+// 排序方式
+const (
+	SortRelevance = "relevance" // 按相关度，较新的文章适当加权
+	SortNewest    = "newest"    // 按发布时间
+)
+
+// Query 搜索条件，Text 为空时列出符合筛选条件的全部文章
+type Query struct {
+	Text        string
+	CategoryIDs []uint // 为空表示不限分类
+	Tag         string
+	UserID      uint
+	From        time.Time // 发布时间不早于 From，零值表示不限
+	To          time.Time // 发布时间早于 To，零值表示不限
+	ReadLimit   int       // 访问者可阅读的最高阅读限制
+	OwnerID     uint      // 访问者本人的文章不受阅读限制，未登录时为 0
+	Sort        string
+	Offset      int
+	Limit       int
+}
+
+// Match 判断文档是否符合筛选条件（不含搜索词），供各后端过滤时使用
+func (q *Query) Match(doc *Document) bool {
+	if len(q.CategoryIDs) > 0 && !slices.Contains(q.CategoryIDs, doc.CategoryID) {
+		return false
+	}
+	if q.Tag != "" && !slices.ContainsFunc(doc.Tags, func(tag string) bool { return strings.EqualFold(tag, q.Tag) }) {
+		return false
+	}
+	if q.UserID != 0 && doc.UserID != q.UserID {
+		return false
+	}
+	if !q.From.IsZero() && doc.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !doc.CreatedAt.Before(q.To) {
+		return false
+	}
+	return doc.ReadLimit <= q.ReadLimit || (q.OwnerID != 0 && doc.UserID == q.OwnerID)
+}
+
+// Hit 一条搜索结果，Title 和 Snippet 为转义后的 HTML，命中的词用 <mark> 标出
+type Hit struct {
+	ID      uint
+	Score   float64
+	Title   template.HTML
+	Snippet template.HTML
+}
+
+// Result 搜索结果，Total 为符合条件的文章总数
+type Result struct {
+	Hits  []Hit
+	Total int
+}
+
+// Backend 搜索后端，需要支持并发调用
+type Backend interface {
+	// Index 写入或替换文章的索引
+	Index(doc Document) error
+	// Delete 从索引中移除文章，文章不存在时不报错
+	Delete(id uint) error
+	// Search 按条件搜索，返回 Offset、Limit 范围内的结果和总数
+	Search(q Query) (*Result, error)
+}
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]func() (Backend, error){
+		"memory": func() (Backend, error) { return NewMemoryIndex(), nil },
+	}
+	current Backend = NewMemoryIndex()
+)
+
+// Register 注册搜索后端，name 重复时覆盖
+func Register(name string, factory func() (Backend, error)) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+}
+
+// Setup 按名称创建并启用搜索后端，name 为空时使用内置的 memory 后端
+func Setup(name string) error {
+	if name == "" {
+		name = "memory"
+	}
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown search backend %q", name)
+	}
+	backend, err := factory()
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	current = backend
+	mu.Unlock()
+	return nil
+}
+
+func backend() Backend {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Index 使用当前的后端写入文章索引
+func Index(doc Document) error {
+	return backend().Index(doc)
+}
+
+// Delete 使用当前的后端移除文章索引
+func Delete(id uint) error {
+	return backend().Delete(id)
+}
+
+// Search 使用当前的后端搜索
+func Search(q Query) (*Result, error) {
+	return backend().Search(q)
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"unicode"
+)
+
+// Token 切分出的词，Start、End 为原文中的字符（rune）下标，区间左闭右开
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// isCJK 中日韩文字，没有空格分词，按字切分
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// fold 全角字母数字转半角并转为小写，不改变文本的字符数
+func fold(r rune) rune {
+	if r >= 0xFF01 && r <= 0xFF5E {
+		r -= 0xFEE0
+	}
+	return unicode.ToLower(r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// segments 把文本切分为连续的单词或连续的中日韩文字，cjk 表示该段是否为中日韩文字
+func segments(text string, emit func(runes []rune, start int, cjk bool)) {
+	runes := []rune(text)
+	for i := range runes {
+		runes[i] = fold(runes[i])
+	}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !isWordRune(r) {
+			i++
+			continue
+		}
+		cjk := isCJK(r)
+		j := i + 1
+		for j < len(runes) && isWordRune(runes[j]) && isCJK(runes[j]) == cjk {
+			j++
+		}
+		emit(runes[i:j], i, cjk)
+		i = j
+	}
+}
+
+// Tokenize 切分需要索引的文本：单词整体为一个词，中日韩文字每个字和相邻两个字各为一个词，
+// 这样单字和多字的搜索词都能命中
+func Tokenize(text string) []Token {
+	var tokens []Token
+	segments(text, func(runes []rune, start int, cjk bool) {
+		if !cjk {
+			tokens = append(tokens, Token{Term: string(runes), Start: start, End: start + len(runes)})
+			return
+		}
+		for i := range runes {
+			tokens = append(tokens, Token{Term: string(runes[i]), Start: start + i, End: start + i + 1})
+			if i+1 < len(runes) {
+				tokens = append(tokens, Token{Term: string(runes[i : i+2]), Start: start + i, End: start + i + 2})
+			}
+		}
+	})
+	return tokens
+}
+
+// QueryTerms 切分搜索词并去重：单词整体为一个词，连续的中日韩文字按相邻两个字切分，只有一个字时用单字
+func QueryTerms(text string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	segments(text, func(runes []rune, start int, cjk bool) {
+		if !cjk || len(runes) == 1 {
+			add(string(runes))
+			return
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			add(string(runes[i : i+2]))
+		}
+	})
+	return terms
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Token
+	}{
+		{
+			name: "中英文混排",
+			text: "Go语言教程v2",
+			want: []Token{
+				{"go", 0, 2},
+				{"语", 2, 3}, {"语言", 2, 4}, {"言", 3, 4}, {"言教", 3, 5}, {"教", 4, 5}, {"教程", 4, 6}, {"程", 5, 6},
+				{"v2", 6, 8},
+			},
+		},
+		{
+			name: "大小写和全角字母",
+			text: "GoLang ＧＯ",
+			want: []Token{{"golang", 0, 6}, {"go", 7, 9}},
+		},
+		{
+			name: "标点和空白分隔",
+			text: "hello, world! 你好。",
+			want: []Token{{"hello", 0, 5}, {"world", 7, 12}, {"你", 14, 15}, {"你好", 14, 16}, {"好", 15, 16}},
+		},
+		{
+			name: "日文和韩文按字切分",
+			text: "テスト 한국",
+			want: []Token{
+				{"テ", 0, 1}, {"テス", 0, 2}, {"ス", 1, 2}, {"スト", 1, 3}, {"ト", 2, 3},
+				{"한", 4, 5}, {"한국", 4, 6}, {"국", 5, 6},
+			},
+		},
+		{
+			name: "只有标点",
+			text: "…！？",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %v，期望 %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Go语言", []string{"go", "语言"}},
+		{"Go 语言教程 GO", []string{"go", "语言", "言教", "教程"}},
+		{"学", []string{"学"}},
+		{"学 Go", []string{"学", "go"}},
+		{"C++ 和 Rust", []string{"c", "和", "rust"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := QueryTerms(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryTerms(%q) = %v，期望 %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// AccessToken 个人访问令牌的对外表示，不包含令牌本身
+type AccessToken struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewAccessToken 生成访问令牌的对外表示
+func NewAccessToken(t *models.AccessToken) AccessToken {
+	return AccessToken{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.ScopeList(),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIP: t.LastUsedIP,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// NewAccessTokens 批量生成访问令牌的对外表示
+func NewAccessTokens(tokens []models.AccessToken) []AccessToken {
+	result := make([]AccessToken, 0, len(tokens))
+	for i := range tokens {
+		result = append(result, NewAccessToken(&tokens[i]))
+	}
+	return result
+}
+
+// CreatedAccessToken 新建令牌的响应，明文令牌只在创建时返回一次
+type CreatedAccessToken struct {
+	AccessToken
+	Token string `json:"token"`
+}
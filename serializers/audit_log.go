@@ -0,0 +1,50 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// AuditLog 审计日志的对外表示，接口和 JSON Lines 导出共用
+type AuditLog struct {
+	ID          uint                          `json:"id"`
+	ActorID     uint                          `json:"actor_id"`
+	ActorName   string                        `json:"actor_name"`
+	Action      string                        `json:"action"`
+	ActionLabel string                        `json:"action_label"`
+	TargetType  string                        `json:"target_type"`
+	TargetID    uint                          `json:"target_id"`
+	TargetLabel string                        `json:"target_label"`
+	Changes     map[string]models.AuditChange `json:"changes,omitempty"`
+	IPAddress   string                        `json:"ip_address"`
+	UserAgent   string                        `json:"user_agent"`
+	CreatedAt   time.Time                     `json:"created_at"`
+}
+
+// NewAuditLog 生成单条审计日志的对外表示
+func NewAuditLog(l *models.AuditLog) AuditLog {
+	return AuditLog{
+		ID:          l.ID,
+		ActorID:     l.ActorID,
+		ActorName:   l.ActorName,
+		Action:      l.Action,
+		ActionLabel: l.ActionLabel(),
+		TargetType:  l.TargetType,
+		TargetID:    l.TargetID,
+		TargetLabel: l.TargetLabel,
+		Changes:     l.ChangeSet(),
+		IPAddress:   l.IPAddress,
+		UserAgent:   l.UserAgent,
+		CreatedAt:   l.CreatedAt,
+	}
+}
+
+// NewAuditLogs 批量生成审计日志的对外表示
+func NewAuditLogs(list []models.AuditLog) []AuditLog {
+	result := make([]AuditLog, 0, len(list))
+	for i := range list {
+		result = append(result, NewAuditLog(&list[i]))
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// Category 分类的对外表示
+type Category struct {
+	ID            uint       `json:"id"`
+	ParentID      uint       `json:"parent_id"` // 0 表示顶级分类
+	Name          string     `json:"name"`
+	Alias         string     `json:"alias"`
+	Description   string     `json:"description"`
+	Icon          string     `json:"icon"`
+	Color         string     `json:"color"`
+	SortOrder     int        `json:"sort_order"`
+	MinPostLevel  int        `json:"min_post_level"` // 发帖所需的最低等级，0 表示不限
+	IsRecommended bool       `json:"is_recommended"`
+	RecommendRank int        `json:"recommend_rank"`
+	StatusCode    int        `json:"status_code"` // 1 正常 2 禁用
+	PostCount     int64      `json:"post_count"`  // 公开的文章数，不含子分类
+	Children      []Category `json:"children,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewCategory 生成分类的对外表示，postCount 为分类下的文章数
+func NewCategory(c *models.Category, postCount int64) Category {
+	return Category{
+		ID:            c.ID,
+		ParentID:      c.ParentID,
+		Name:          c.Name,
+		Alias:         c.Alias,
+		Description:   c.Description,
+		Icon:          c.Icon,
+		Color:         c.Color,
+		SortOrder:     c.SortOrder,
+		MinPostLevel:  c.MinPostLevel,
+		IsRecommended: c.IsRecommended,
+		RecommendRank: c.RecommendRank,
+		StatusCode:    c.StatusCode,
+		PostCount:     postCount,
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+		DeletedAt:     deletedAt(c.DeletedAt),
+	}
+}
+
+// NewCategories 批量生成分类的对外表示，postCounts 以分类ID为键
+func NewCategories(categories []models.Category, postCounts map[uint]int64) []Category {
+	result := make([]Category, 0, len(categories))
+	for i := range categories {
+		result = append(result, NewCategory(&categories[i], postCounts[categories[i].ID]))
+	}
+	return result
+}
+
+// NewCategoryTree 把已排好序的分类组织成两级，子分类放在上级分类的 children 中，
+// 上级分类不在列表中的子分类会被忽略
+func NewCategoryTree(categories []models.Category, postCounts map[uint]int64) []Category {
+	var tree []Category
+	index := map[uint]int{}
+	for i := range categories {
+		if categories[i].ParentID == 0 {
+			index[categories[i].ID] = len(tree)
+			tree = append(tree, NewCategory(&categories[i], postCounts[categories[i].ID]))
+		}
+	}
+	for i := range categories {
+		if parent, ok := index[categories[i].ParentID]; ok && categories[i].ParentID != 0 {
+			tree[parent].Children = append(tree[parent].Children, NewCategory(&categories[i], postCounts[categories[i].ID]))
+		}
+	}
+	if tree == nil {
+		tree = []Category{}
+	}
+	return tree
+}
@@ -0,0 +1,58 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// Comment 评论的对外表示
+type Comment struct {
+	ID               uint        `json:"id"`
+	Content          string      `json:"content"`
+	Markdown         string      `json:"markdown"`
+	PostID           uint        `json:"post_id"`
+	UserID           uint        `json:"user_id"`
+	User             *PublicUser `json:"user,omitempty"`
+	ParentID         uint        `json:"parent_id"`
+	LikeCount        int         `json:"like_count"`
+	DislikeCount     int         `json:"dislike_count"`
+	ReplyCount       int         `json:"reply_count"`
+	StatusCode       int         `json:"status_code"`                 // 1 正常 2 未通过审核 3 待审核
+	ModerationReason string      `json:"moderation_reason,omitempty"` // 进入审核或未通过的原因
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+	DeletedAt        *time.Time  `json:"deleted_at,omitempty"` // 仅管理后台和回收站会返回已删除的记录
+	DeletedBy        uint        `json:"deleted_by,omitempty"`
+}
+
+// NewComment 生成评论的对外表示
+func NewComment(c *models.Comment) Comment {
+	return Comment{
+		ID:               c.ID,
+		Content:          SafeContent(c.Content, c.Markdown),
+		Markdown:         c.Markdown,
+		PostID:           c.PostID,
+		UserID:           c.UserID,
+		User:             author(&c.User),
+		ParentID:         c.ParentID,
+		LikeCount:        c.LikeCount,
+		DislikeCount:     c.DislikeCount,
+		ReplyCount:       c.ReplyCount,
+		StatusCode:       c.StatusCode,
+		ModerationReason: c.ModerationReason,
+		CreatedAt:        c.CreatedAt,
+		UpdatedAt:        c.UpdatedAt,
+		DeletedAt:        deletedAt(c.DeletedAt),
+		DeletedBy:        c.DeletedBy,
+	}
+}
+
+// NewComments 批量生成评论的对外表示
+func NewComments(comments []models.Comment) []Comment {
+	result := make([]Comment, 0, len(comments))
+	for i := range comments {
+		result = append(result, NewComment(&comments[i]))
+	}
+	return result
+}
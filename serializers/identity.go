@@ -0,0 +1,31 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// Identity 关联的第三方账号，不包含平台用户ID
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Label     string    `json:"label"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewIdentities 批量生成关联账号的对外表示
+func NewIdentities(list []models.UserIdentity) []Identity {
+	result := make([]Identity, 0, len(list))
+	for _, i := range list {
+		result = append(result, Identity{
+			Provider:  i.Provider,
+			Label:     models.ProviderLabel(i.Provider),
+			Username:  i.Username,
+			Email:     i.Email,
+			CreatedAt: i.CreatedAt,
+		})
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+	"gin-doniai/utils"
+)
+
+// LoginFailure 展示给用户本人的登录失败记录
+type LoginFailure struct {
+	ID        uint      `json:"id"`
+	Reason    string    `json:"reason"`
+	Label     string    `json:"label"`
+	IPAddress string    `json:"ip_address"`
+	Device    string    `json:"device"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewLoginFailures 批量生成登录失败记录的对外表示
+func NewLoginFailures(list []models.LoginAttempt) []LoginFailure {
+	result := make([]LoginFailure, 0, len(list))
+	for _, a := range list {
+		result = append(result, LoginFailure{
+			ID:        a.ID,
+			Reason:    a.Reason,
+			Label:     a.ReasonLabel(),
+			IPAddress: a.IPAddress,
+			Device:    utils.DescribeUserAgent(a.UserAgent),
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	return result
+}
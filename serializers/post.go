@@ -0,0 +1,78 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+	"gin-doniai/utils"
+)
+
+// Post 文章的对外表示
+type Post struct {
+	ID               uint        `json:"id"`
+	Title            string      `json:"title"`
+	UserID           int         `json:"user_id"`
+	Author           string      `json:"author"`
+	User             *PublicUser `json:"user,omitempty"`
+	Category         string      `json:"category"`
+	CategoryID       int         `json:"category_id"`
+	Content          string      `json:"content"`
+	Markdown         string      `json:"markdown"`
+	Tags             []string    `json:"tags"`
+	Views            int         `json:"views"`
+	Replies          int         `json:"replies"`
+	Favorites        int         `json:"favorites"`
+	Likes            int         `json:"likes"`
+	ReadLimit        int         `json:"read_limit"`
+	StatusCode       int         `json:"status_code"`                 // 1 正常 2 未通过审核 3 待审核
+	ModerationReason string      `json:"moderation_reason,omitempty"` // 进入审核或未通过的原因
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+	DeletedAt        *time.Time  `json:"deleted_at,omitempty"` // 仅管理后台和回收站会返回已删除的记录
+	DeletedBy        uint        `json:"deleted_by,omitempty"`
+}
+
+// NewPost 生成文章的对外表示
+func NewPost(p *models.Post) Post {
+	return Post{
+		ID:               p.ID,
+		Title:            p.Title,
+		UserID:           p.UserId,
+		Author:           p.Author,
+		User:             author(&p.User),
+		Category:         p.Category,
+		CategoryID:       p.CategoryId,
+		Content:          SafeContent(p.Content, p.Markdown),
+		Markdown:         p.Markdown,
+		Tags:             utils.ParseTags(p.Tags),
+		Views:            p.Views,
+		Replies:          p.Replies,
+		Favorites:        p.Favorites,
+		Likes:            p.Likes,
+		ReadLimit:        p.ReadLimit,
+		StatusCode:       p.StatusCode,
+		ModerationReason: p.ModerationReason,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+		DeletedAt:        deletedAt(p.DeletedAt),
+		DeletedBy:        p.DeletedBy,
+	}
+}
+
+// SafeContent 可安全输出的内容HTML，与页面上显示的一致：有Markdown原文的内容在保存时已由服务端渲染并过滤，
+// 历史内容只有HTML，需要经过白名单过滤
+func SafeContent(content, markdown string) string {
+	if markdown != "" {
+		return content
+	}
+	return utils.SanitizeHTML(content)
+}
+
+// NewPosts 批量生成文章的对外表示
+func NewPosts(posts []models.Post) []Post {
+	result := make([]Post, 0, len(posts))
+	for i := range posts {
+		result = append(result, NewPost(&posts[i]))
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// Report 举报的对外表示，举报人只对审核人员展示
+type Report struct {
+	ID          uint        `json:"id"`
+	TargetType  string      `json:"target_type"` // post 或 comment
+	TargetID    uint        `json:"target_id"`
+	Reason      string      `json:"reason"`
+	ReasonLabel string      `json:"reason_label"`
+	Note        string      `json:"note"`
+	Status      string      `json:"status"` // open、resolved 或 dismissed
+	Reporter    *PublicUser `json:"reporter,omitempty"`
+	HandledAt   *time.Time  `json:"handled_at,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// NewReport 生成举报的对外表示
+func NewReport(r *models.Report) Report {
+	return Report{
+		ID:          r.ID,
+		TargetType:  r.TargetType,
+		TargetID:    r.TargetID,
+		Reason:      r.Reason,
+		ReasonLabel: r.ReasonLabel(),
+		Note:        r.Note,
+		Status:      r.Status,
+		Reporter:    author(&r.User),
+		HandledAt:   r.HandledAt,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+// NewReports 批量生成举报的对外表示
+func NewReports(reports []models.Report) []Report {
+	result := make([]Report, 0, len(reports))
+	for i := range reports {
+		result = append(result, NewReport(&reports[i]))
+	}
+	return result
+}
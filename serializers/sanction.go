@@ -0,0 +1,50 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// Sanction 处罚记录的对外表示
+type Sanction struct {
+	ID        uint        `json:"id"`
+	Type      string      `json:"type"` // mute、ban 或 ip_ban
+	TypeLabel string      `json:"type_label"`
+	User      *PublicUser `json:"user,omitempty"`
+	IPAddress string      `json:"ip_address,omitempty"`
+	Reason    string      `json:"reason"`
+	StartsAt  time.Time   `json:"starts_at"`
+	ExpiresAt *time.Time  `json:"expires_at"` // 为空表示永久
+	Active    bool        `json:"active"`
+	Moderator *PublicUser `json:"moderator,omitempty"`
+	RevokedAt *time.Time  `json:"revoked_at,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// NewSanction 生成处罚记录的对外表示
+func NewSanction(s *models.UserSanction) Sanction {
+	return Sanction{
+		ID:        s.ID,
+		Type:      s.Type,
+		TypeLabel: s.TypeLabel(),
+		User:      author(&s.User),
+		IPAddress: s.IPAddress,
+		Reason:    s.Reason,
+		StartsAt:  s.StartsAt,
+		ExpiresAt: s.ExpiresAt,
+		Active:    s.IsActive(time.Now()),
+		Moderator: author(&s.Moderator),
+		RevokedAt: s.RevokedAt,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// NewSanctions 批量生成处罚记录的对外表示
+func NewSanctions(sanctions []models.UserSanction) []Sanction {
+	result := make([]Sanction, 0, len(sanctions))
+	for i := range sanctions {
+		result = append(result, NewSanction(&sanctions[i]))
+	}
+	return result
+}
@@ -0,0 +1,24 @@
+package serializers
+
+import (
+	"gin-doniai/models"
+	"gin-doniai/search"
+)
+
+// SearchResult 搜索结果中的一篇文章，Highlight 和 Snippet 为转义后的 HTML，命中的词用 <mark> 标出
+type SearchResult struct {
+	Post
+	Score     float64 `json:"score"`     // 相关度，按发布时间排序或没有搜索词时为 0
+	Highlight string  `json:"highlight"` // 高亮后的标题
+	Snippet   string  `json:"snippet"`   // 正文或评论中命中的片段
+}
+
+// NewSearchResult 生成搜索结果的对外表示
+func NewSearchResult(p *models.Post, hit search.Hit) SearchResult {
+	return SearchResult{
+		Post:      NewPost(p),
+		Score:     hit.Score,
+		Highlight: string(hit.Title),
+		Snippet:   string(hit.Snippet),
+	}
+}
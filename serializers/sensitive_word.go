@@ -0,0 +1,38 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+)
+
+// SensitiveWord 敏感词规则的对外表示
+type SensitiveWord struct {
+	ID          uint      `json:"id"`
+	Word        string    `json:"word"`
+	Action      string    `json:"action"` // reject、mask 或 review
+	ActionLabel string    `json:"action_label"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NewSensitiveWord 生成敏感词规则的对外表示
+func NewSensitiveWord(w *models.SensitiveWord) SensitiveWord {
+	return SensitiveWord{
+		ID:          w.ID,
+		Word:        w.Word,
+		Action:      w.Action,
+		ActionLabel: w.ActionLabel(),
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+// NewSensitiveWords 批量生成敏感词规则的对外表示
+func NewSensitiveWords(words []models.SensitiveWord) []SensitiveWord {
+	result := make([]SensitiveWord, 0, len(words))
+	for i := range words {
+		result = append(result, NewSensitiveWord(&words[i]))
+	}
+	return result
+}
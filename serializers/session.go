@@ -0,0 +1,36 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+	"gin-doniai/utils"
+)
+
+// Session 登录设备的对外表示，不包含会话标识
+type Session struct {
+	ID           uint      `json:"id"`
+	Device       string    `json:"device"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	Current      bool      `json:"current"` // 是否为当前请求所在的会话
+}
+
+// NewSessions 批量生成登录设备的对外表示，currentKey 为当前会话的标识
+func NewSessions(list []models.UserSession, currentKey string) []Session {
+	result := make([]Session, 0, len(list))
+	for _, s := range list {
+		result = append(result, Session{
+			ID:           s.ID,
+			Device:       utils.DescribeUserAgent(s.UserAgent),
+			IPAddress:    s.IPAddress,
+			UserAgent:    s.UserAgent,
+			LastActiveAt: s.LastActiveAt,
+			CreatedAt:    s.CreatedAt,
+			Current:      s.SessionKey == currentKey,
+		})
+	}
+	return result
+}
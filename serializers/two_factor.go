@@ -0,0 +1,13 @@
+package serializers
+
+// TwoFactorSetup 启用两步验证前生成的密钥，需要用验证码确认后才会生效
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`     // otpauth:// 链接
+	QRCode string `json:"qr_code"` // SVG 二维码的 data URI
+}
+
+// RecoveryCodes 一次性恢复码，明文只在生成时返回一次
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,114 @@
+package serializers
+
+import (
+	"time"
+
+	"gin-doniai/models"
+
+	"gorm.io/gorm"
+)
+
+// PublicUser 对所有人公开的用户信息
+type PublicUser struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Avatar    string    `json:"avatar"`
+	Level     int       `json:"level"`
+	Role      string    `json:"role"`
+	Motto     string    `json:"motto"`
+	Github    string    `json:"github"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SelfUser 用户本人可见的信息
+type SelfUser struct {
+	PublicUser
+	Email            string    `json:"email"`
+	Age              int       `json:"age"`
+	AgreeTerms       bool      `json:"agree_terms"`
+	GoogleAccount    string    `json:"google_account"`
+	EmailVerified    bool      `json:"email_verified"`
+	PasswordSet      bool      `json:"password_set"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// AdminUser 管理员可见的信息
+type AdminUser struct {
+	SelfUser
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+// NewPublicUser 生成公开的用户信息
+func NewPublicUser(u *models.User) PublicUser {
+	return PublicUser{
+		ID:        u.ID,
+		Name:      u.Name,
+		Avatar:    u.Avatar,
+		Level:     u.Level,
+		Role:      u.Role,
+		Motto:     u.Motto,
+		Github:    u.Github,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+// NewSelfUser 生成用户本人可见的信息
+func NewSelfUser(u *models.User) SelfUser {
+	return SelfUser{
+		PublicUser:       NewPublicUser(u),
+		Email:            u.Email,
+		Age:              u.Age,
+		AgreeTerms:       u.AgreeTerms,
+		GoogleAccount:    u.GoogleAccount,
+		EmailVerified:    u.EmailVerified,
+		PasswordSet:      u.PasswordSet,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		UpdatedAt:        u.UpdatedAt,
+	}
+}
+
+// NewAdminUser 生成管理员可见的信息
+func NewAdminUser(u *models.User) AdminUser {
+	return AdminUser{SelfUser: NewSelfUser(u), DeletedAt: deletedAt(u.DeletedAt)}
+}
+
+// UserFor 根据访问者身份选择用户信息的投影：管理员、本人或公开。
+// token 为请求使用的访问令牌，会话登录时为 nil；使用令牌时只有 admin 权限范围的令牌能看到管理员投影
+func UserFor(viewer *models.User, token *models.AccessToken, u *models.User) interface{} {
+	switch {
+	case viewer != nil && viewer.Can(models.PermManageUsers) && (token == nil || token.HasScope(models.ScopeAdmin)):
+		return NewAdminUser(u)
+	case viewer != nil && viewer.ID == u.ID:
+		return NewSelfUser(u)
+	default:
+		return NewPublicUser(u)
+	}
+}
+
+// UsersFor 批量生成用户信息
+func UsersFor(viewer *models.User, token *models.AccessToken, users []models.User) []interface{} {
+	result := make([]interface{}, 0, len(users))
+	for i := range users {
+		result = append(result, UserFor(viewer, token, &users[i]))
+	}
+	return result
+}
+
+// author 关联的用户未加载时返回nil
+func author(u *models.User) *PublicUser {
+	if u == nil || u.ID == 0 {
+		return nil
+	}
+	public := NewPublicUser(u)
+	return &public
+}
+
+// deletedAt 软删除时间，未删除时返回nil
+func deletedAt(d gorm.DeletedAt) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
@@ -0,0 +1,38 @@
+package serializers
+
+import (
+	"fmt"
+	"testing"
+
+	"gin-doniai/models"
+)
+
+func TestUserFor(t *testing.T) {
+	target := &models.User{ID: 2, Name: "bob", Email: "bob@example.com"}
+	admin := &models.User{ID: 1, Role: models.RoleAdmin}
+	member := &models.User{ID: 3, Role: models.RoleMember}
+	self := &models.User{ID: 2, Role: models.RoleMember}
+
+	tests := []struct {
+		name   string
+		viewer *models.User
+		token  *models.AccessToken
+		want   interface{}
+	}{
+		{"游客", nil, nil, PublicUser{}},
+		{"普通用户", member, nil, PublicUser{}},
+		{"本人", self, nil, SelfUser{}},
+		{"管理员会话", admin, nil, AdminUser{}},
+		{"管理员的 admin 令牌", admin, &models.AccessToken{Scopes: models.ScopeAdmin}, AdminUser{}},
+		{"管理员的只读令牌", admin, &models.AccessToken{Scopes: models.ScopePostsRead}, PublicUser{}},
+		{"本人的只读令牌", self, &models.AccessToken{Scopes: models.ScopePostsRead}, SelfUser{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UserFor(tt.viewer, tt.token, target)
+			if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", tt.want) {
+				t.Errorf("UserFor 返回 %T，期望 %T", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// 管理后台：勾选记录后执行批量操作
+const bulkBar = document.querySelector('.admin-bulk');
+if (bulkBar) {
+    const resource = bulkBar.dataset.resource;
+    const actionSelect = bulkBar.querySelector('.admin-bulk-action');
+    const categorySelect = bulkBar.querySelector('.admin-bulk-category');
+    const statusSelect = bulkBar.querySelector('.admin-bulk-status');
+    const checkboxes = document.querySelectorAll('.admin-select');
+
+    const selectedIDs = () => Array.from(checkboxes)
+        .filter(checkbox => checkbox.checked)
+        .map(checkbox => parseInt(checkbox.value, 10));
+
+    const updateSelected = () => {
+        bulkBar.querySelector('.admin-selected').textContent = `已选择 ${selectedIDs().length} 项`;
+    };
+
+    checkboxes.forEach(checkbox => checkbox.addEventListener('change', updateSelected));
+
+    const selectAll = document.querySelector('.admin-select-all');
+    if (selectAll) {
+        selectAll.addEventListener('change', function() {
+            checkboxes.forEach(checkbox => {
+                checkbox.checked = this.checked;
+            });
+            updateSelected();
+        });
+    }
+
+    actionSelect.addEventListener('change', function() {
+        if (categorySelect) {
+            categorySelect.style.display = this.value === 'move' ? '' : 'none';
+        }
+        if (statusSelect) {
+            statusSelect.style.display = this.value === 'set_status' ? '' : 'none';
+        }
+    });
+
+    bulkBar.querySelector('.admin-bulk-apply').addEventListener('click', function() {
+        const ids = selectedIDs();
+        if (ids.length === 0) {
+            customAlert.error('请先勾选要操作的记录');
+            return;
+        }
+
+        const body = { ids: ids, action: actionSelect.value };
+        if (body.action === 'move') {
+            body.category_id = parseInt(categorySelect.value, 10);
+        }
+        if (body.action === 'set_status') {
+            body.status_code = parseInt(statusSelect.value, 10);
+            if (body.status_code === 2 && resource !== 'categories') {
+                const reason = prompt('请输入禁用原因（会展示给作者，可不填）');
+                if (reason === null) {
+                    return;
+                }
+                body.reason = reason.trim();
+            }
+        }
+        if (body.action === 'delete' && !confirm(`确定删除选中的 ${ids.length} 项吗？`)) {
+            return;
+        }
+
+        fetch(`/api/v1/admin/${resource}/bulk`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(body)
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => window.location.reload(), 1000);
+                } else {
+                    customAlert.error('操作失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 分类：新建或编辑
+const categoryForm = document.getElementById('categoryForm');
+if (categoryForm) {
+    const field = id => document.getElementById(id);
+
+    const resetCategoryForm = () => {
+        categoryForm.reset();
+        field('categoryId').value = '';
+        field('categoryFormTitle').textContent = '新建分类';
+    };
+
+    document.querySelectorAll('.edit-category-btn').forEach(button => {
+        button.addEventListener('click', function() {
+            const data = this.dataset;
+            field('categoryId').value = data.id;
+            field('categoryName').value = data.name;
+            field('categoryAlias').value = data.alias;
+            field('categoryParent').value = data.parent;
+            field('categoryDescription').value = data.description;
+            field('categoryIcon').value = data.categoryIcon;
+            field('categoryColor').value = data.color;
+            field('categorySortOrder').value = data.sort;
+            field('categoryMinPostLevel').value = data.level;
+            field('categoryRecommended').checked = data.recommended === 'true';
+            field('categoryRecommendRank').value = data.rank;
+            field('categoryStatus').value = data.status;
+            field('categoryFormTitle').textContent = '编辑分类：' + data.name;
+            categoryForm.scrollIntoView({ behavior: 'smooth' });
+        });
+    });
+
+    field('categoryFormReset').addEventListener('click', resetCategoryForm);
+
+    categoryForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        const id = field('categoryId').value;
+        fetch(id ? `/api/v1/categories/${id}` : '/api/v1/categories', {
+            method: id ? 'PUT' : 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({
+                name: field('categoryName').value.trim(),
+                alias: field('categoryAlias').value.trim(),
+                parent_id: parseInt(field('categoryParent').value, 10),
+                description: field('categoryDescription').value.trim(),
+                icon: field('categoryIcon').value.trim(),
+                color: field('categoryColor').value.trim(),
+                sort_order: parseInt(field('categorySortOrder').value, 10) || 0,
+                min_post_level: parseInt(field('categoryMinPostLevel').value, 10) || 0,
+                is_recommended: field('categoryRecommended').checked,
+                recommend_rank: parseInt(field('categoryRecommendRank').value, 10) || 0,
+                status_code: parseInt(field('categoryStatus').value, 10)
+            })
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => window.location.reload(), 1000);
+                } else {
+                    customAlert.error('保存失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
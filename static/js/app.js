@@ -1,3 +1,23 @@
+// 同站的修改请求（POST、PUT、PATCH、DELETE）自动带上 CSRF 令牌，令牌由服务端输出在 <meta name="csrf-token"> 中
+(function () {
+  const meta = document.querySelector('meta[name="csrf-token"]');
+  if (!meta || !meta.content || !window.fetch) return;
+
+  const csrfToken = meta.content;
+  const originalFetch = window.fetch.bind(window);
+  window.fetch = function (input, init = {}) {
+    const isRequest = input instanceof Request;
+    const method = (init.method || (isRequest ? input.method : 'GET')).toUpperCase();
+    const url = new URL(isRequest ? input.url : input, window.location.href);
+    if (url.origin === window.location.origin && !['GET', 'HEAD', 'OPTIONS'].includes(method)) {
+      const headers = new Headers(init.headers || (isRequest ? input.headers : undefined));
+      headers.set('X-CSRF-Token', csrfToken);
+      init = { ...init, headers };
+    }
+    return originalFetch(input, init);
+  };
+})();
+
 // 自定义Alert组件
 class CustomAlert {
   constructor() {
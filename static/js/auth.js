@@ -70,21 +70,23 @@ class AuthPage {
     });
   }
 
-  // 初始化第三方登录按钮
-  initSocialLogin() {
-    // GitHub登录按钮
-    const githubButtons = document.querySelectorAll('.btn-github');
-    githubButtons.forEach(button => {
-      button.addEventListener('click', () => {
-        window.location.href = '/auth/github';
-      });
-    });
+  // 登录后返回的页面，取自地址栏的 redirect_to 参数，由服务端校验
+  redirectTo() {
+    return new URLSearchParams(window.location.search).get('redirect_to') || '';
+  }
 
-    // Google登录按钮
-    const googleButtons = document.querySelectorAll('.btn-google');
-    googleButtons.forEach(button => {
+  // 第三方登录地址，带上登录后返回的页面
+  socialLoginURL(provider) {
+    const redirectTo = this.redirectTo();
+    return '/auth/' + provider + (redirectTo ? '?redirect_to=' + encodeURIComponent(redirectTo) : '');
+  }
+
+  // 初始化第三方登录按钮，按钮的 data-provider 为平台标识
+  initSocialLogin() {
+    const socialButtons = document.querySelectorAll('.btn-social[data-provider]');
+    socialButtons.forEach(button => {
       button.addEventListener('click', () => {
-        window.location.href = '/auth/google';
+        window.location.href = this.socialLoginURL(button.dataset.provider);
       });
     });
   }
@@ -266,16 +268,22 @@ class AuthPage {
         body: new URLSearchParams({
           'email': email.value,
           'password': password.value,
-          'remember': remember.checked ? 'on' : ''
+          'remember': remember.checked ? 'on' : '',
+          'redirect_to': this.redirectTo()
         })
       })
           .then(response => response.json())
           .then(data => {
             console.log('登录响应:', data);
-            if (data.status === 'success') {
+            if (data.success) {
+              // 启用了两步验证的账户需要继续输入验证码
+              if (data.data && data.data.two_factor_required) {
+                window.location.href = data.data.redirect;
+                return;
+              }
               this.showSuccess('登录成功！正在跳转...');
               setTimeout(() => {
-                window.location.href = '/';
+                window.location.href = (data.data && data.data.redirect) || '/';
               }, 500);
             } else {
               this.showError(email, document.getElementById('loginEmailError'), data.message || '登录失败');
@@ -326,11 +334,11 @@ class AuthPage {
           .then(response => response.json())
           .then(data => {
             console.log('注册响应:', data);
-            if (data.status === 'success') {
-              this.showSuccess('注册成功！正在跳转...');
+            if (data.success) {
+              this.showSuccess(data.message || '注册成功！正在跳转...');
               setTimeout(() => {
                 this.switchTab('login');
-              }, 1500);
+              }, 2500);
             } else {
               // 优化错误消息显示
               let errorMessage = '注册失败';
@@ -453,7 +461,7 @@ document.addEventListener('DOMContentLoaded', function() {
       }
 
       // 发送重置密码请求
-      fetch('/api/auth/forgot-password', {
+      fetch('/api/v1/auth/forgot-password', {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -31,7 +31,7 @@ document.addEventListener('DOMContentLoaded', function() {
         };
 
         // 提交数据到服务器
-        fetch('/api/comments', {
+        fetch('/api/v1/comments', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
@@ -49,7 +49,11 @@ document.addEventListener('DOMContentLoaded', function() {
                     }
                     // 显示成功消息
                     // alert('评论发表成功');
-                    customAlert.success('评论发表成功', 3500);
+                    customAlert.success(data.message || '评论发表成功', 3500);
+                    // 待审核的评论暂不显示，不需要刷新
+                    if (data.data && data.data.status_code === 3) {
+                        return;
+                    }
                     // 这里可以考虑重新加载评论列表或动态添加评论
                     location.reload(); // 简单处理，刷新页面
                 } else {
@@ -119,7 +123,7 @@ document.addEventListener('DOMContentLoaded', function() {
             const action = isLiked ? 'unlike' : 'like';
 
             // 发送请求
-            fetch(`/api/posts/${postId}/like`, {
+            fetch(`/api/v1/posts/${postId}/like`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -135,7 +139,7 @@ document.addEventListener('DOMContentLoaded', function() {
                         // 更新界面
                         const likeCountElement = this.querySelector('span:last-child');
                         if (likeCountElement) {
-                            likeCountElement.textContent = data.likes;
+                            likeCountElement.textContent = data.data.likes;
                         }
 
                         // 切换按钮状态
@@ -172,7 +176,7 @@ document.addEventListener('DOMContentLoaded', function() {
             const action = this.dataset.action;
 
             // 发送点赞请求到后端
-            fetch(`/api/comments/${commentId}/like`, {
+            fetch(`/api/v1/comments/${commentId}/like`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -402,7 +406,7 @@ document.addEventListener('DOMContentLoaded', function() {
             const action = isFavorited ? 'unfavorite' : 'favorite';
 
             // 发送请求到后端API（需要后端实现对应的API）
-            fetch(`/api/posts/${postId}/favorite`, {
+            fetch(`/api/v1/posts/${postId}/favorite`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -438,3 +442,74 @@ document.addEventListener('DOMContentLoaded', function() {
 });
 
 
+// 举报文章或评论
+document.addEventListener('DOMContentLoaded', function() {
+    document.querySelectorAll('.report-btn').forEach(button => {
+        button.addEventListener('click', function(e) {
+            e.preventDefault();
+            showReportDialog(this.dataset.type, this.dataset.id);
+        });
+    });
+});
+
+// 显示举报对话框，选择原因后提交
+function showReportDialog(type, id) {
+    const modal = document.createElement('div');
+    modal.className = 'share-modal';
+    modal.innerHTML = `
+        <div class="share-overlay"></div>
+        <div class="share-dialog">
+            <div class="share-header">
+                <h3>举报</h3>
+                <button class="share-close">&times;</button>
+            </div>
+            <div class="share-options">
+                <button class="share-option" data-reason="spam">垃圾广告</button>
+                <button class="share-option" data-reason="abuse">辱骂攻击</button>
+                <button class="share-option" data-reason="illegal">违法违规</button>
+                <button class="share-option" data-reason="other">其他</button>
+            </div>
+            <div class="report-note">
+                <textarea rows="3" maxlength="500" placeholder="补充说明（选填）"></textarea>
+            </div>
+        </div>
+    `;
+
+    document.body.appendChild(modal);
+
+    const close = () => {
+        document.body.removeChild(modal);
+    };
+
+    modal.querySelector('.share-overlay').addEventListener('click', close);
+    modal.querySelector('.share-close').addEventListener('click', close);
+
+    modal.querySelectorAll('.share-option').forEach(option => {
+        option.addEventListener('click', function() {
+            const note = modal.querySelector('.report-note textarea').value.trim();
+            fetch(`/api/v1/${type}/${id}/report`, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({
+                    reason: this.dataset.reason,
+                    note: note
+                })
+            })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.success) {
+                        customAlert.success(data.message);
+                    } else {
+                        customAlert.error(data.message || '举报失败');
+                    }
+                })
+                .catch(error => {
+                    console.error('Error:', error);
+                    customAlert.error('网络错误，请稍后重试');
+                });
+            close();
+        });
+    });
+}
@@ -0,0 +1,118 @@
+// 审核队列：通过或拒绝文章、评论，处理举报
+document.querySelectorAll('.moderation-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        const action = this.dataset.action;
+        let reason = '';
+        if (action === 'reject') {
+            reason = prompt('请输入拒绝原因');
+            if (reason === null) {
+                return;
+            }
+            reason = reason.trim();
+            if (!reason) {
+                customAlert.error('拒绝时必须填写原因');
+                return;
+            }
+        } else if (action === 'remove') {
+            reason = prompt('请输入下架原因（可不填，默认使用举报原因）');
+            if (reason === null) {
+                return;
+            }
+            reason = reason.trim();
+        }
+
+        const item = this.closest('.moderation-item');
+        fetch(`/api/v1/moderation/${this.dataset.type}/${this.dataset.id}`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ action: action, reason: reason })
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    item.remove();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('操作失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
+
+// 用户处罚：封禁 IP 时填写 IP 地址，其余填写用户名
+const sanctionForm = document.getElementById('sanctionForm');
+if (sanctionForm) {
+    const sanctionType = document.getElementById('sanctionType');
+    sanctionType.addEventListener('change', function() {
+        const ipBan = this.value === 'ip_ban';
+        document.getElementById('sanctionUserGroup').style.display = ipBan ? 'none' : '';
+        document.getElementById('sanctionIPGroup').style.display = ipBan ? '' : 'none';
+    });
+
+    sanctionForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        const body = {
+            type: sanctionType.value,
+            reason: document.getElementById('sanctionReason').value.trim(),
+            duration_hours: parseInt(document.getElementById('sanctionDuration').value, 10)
+        };
+        if (body.type === 'ip_ban') {
+            body.ip_address = document.getElementById('sanctionIP').value.trim();
+        } else {
+            body.username = document.getElementById('sanctionUsername').value.trim();
+        }
+
+        fetch('/api/v1/sanctions', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(body)
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => window.location.reload(), 1000);
+                } else {
+                    customAlert.error('处罚失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+document.querySelectorAll('.revoke-sanction-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        if (!confirm('确定提前解除这项处罚吗？')) {
+            return;
+        }
+
+        fetch(`/api/v1/sanctions/${this.dataset.sanctionId}`, {
+            method: 'DELETE'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    this.closest('tr').remove();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('解除失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
@@ -6,12 +6,10 @@ document.getElementById('profileForm').addEventListener('submit', function(e) {
 
     const formData = new FormData(this);
     const userData = {
-        motto: formData.get('motto'),
-        github: formData.get('github'),
-        google_account: formData.get('googleAccount')
+        motto: formData.get('motto')
     };
 
-    fetch('/api/users/profile', {
+    fetch('/api/v1/users/profile', {
         method: 'PUT',
         headers: {
             'Content-Type': 'application/json',
@@ -36,7 +34,9 @@ document.getElementById('profileForm').addEventListener('submit', function(e) {
 document.getElementById('securityForm').addEventListener('submit', function(e) {
     e.preventDefault();
 
-    const currentPassword = document.getElementById('currentPassword').value;
+    // 尚未设置密码的账户没有当前密码输入框
+    const currentPasswordInput = document.getElementById('currentPassword');
+    const currentPassword = currentPasswordInput ? currentPasswordInput.value : '';
     const newPassword = document.getElementById('newPassword').value;
     const confirmPassword = document.getElementById('confirmPassword').value;
 
@@ -57,7 +57,7 @@ document.getElementById('securityForm').addEventListener('submit', function(e) {
         new_password: newPassword
     };
 
-    fetch('/api/users/password', {
+    fetch('/api/v1/users/password', {
         method: 'PUT',
         headers: {
             'Content-Type': 'application/json',
@@ -67,9 +67,13 @@ document.getElementById('securityForm').addEventListener('submit', function(e) {
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                customAlert.success('密码修改成功');
+                customAlert.success(data.message || '密码修改成功');
                 // 清空密码输入框
                 document.getElementById('securityForm').reset();
+                // 首次设置密码后刷新页面显示当前密码输入框
+                if (!currentPasswordInput) {
+                    setTimeout(() => location.reload(), 1000);
+                }
             } else {
                 customAlert.error('密码修改失败: ' + data.message);
             }
@@ -80,6 +84,312 @@ document.getElementById('securityForm').addEventListener('submit', function(e) {
         });
 });
 
+// 创建个人访问令牌
+const tokenForm = document.getElementById('tokenForm');
+if (tokenForm) {
+    tokenForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        const scopes = Array.from(this.querySelectorAll('input[name="scopes"]:checked')).map(input => input.value);
+        if (scopes.length === 0) {
+            customAlert.error('请至少选择一个权限范围');
+            return;
+        }
+
+        const tokenData = {
+            name: document.getElementById('tokenName').value.trim(),
+            scopes: scopes,
+            expires_in_days: parseInt(document.getElementById('tokenExpires').value, 10)
+        };
+
+        fetch('/api/v1/tokens', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify(tokenData)
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    // 明文令牌只显示这一次
+                    document.getElementById('newTokenValue').value = data.data.token;
+                    document.getElementById('newTokenBox').style.display = 'block';
+                    document.getElementById('newTokenValue').select();
+                    tokenForm.reset();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('创建令牌失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 撤销个人访问令牌
+document.querySelectorAll('.revoke-token-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        if (!confirm('撤销后使用该令牌的程序将无法继续访问，确定撤销吗？')) {
+            return;
+        }
+
+        fetch(`/api/v1/tokens/${this.dataset.tokenId}`, {
+            method: 'DELETE'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    this.closest('tr').remove();
+                    customAlert.success('令牌已撤销');
+                } else {
+                    customAlert.error('撤销失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
+
+// 让指定设备下线
+document.querySelectorAll('.revoke-session-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        fetch(`/api/v1/sessions/${this.dataset.sessionId}`, {
+            method: 'DELETE'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    this.closest('tr').remove();
+                    customAlert.success('设备已下线');
+                } else {
+                    customAlert.error('操作失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
+
+// 退出其他所有设备
+const revokeOtherSessionsBtn = document.getElementById('revokeOtherSessionsBtn');
+if (revokeOtherSessionsBtn) {
+    revokeOtherSessionsBtn.addEventListener('click', function() {
+        if (!confirm('确定让当前设备以外的所有设备退出登录吗？')) {
+            return;
+        }
+
+        fetch('/api/v1/sessions', {
+            method: 'DELETE'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    document.querySelectorAll('.revoke-session-btn').forEach(btn => btn.closest('tr').remove());
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('操作失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 解除关联第三方账号
+document.querySelectorAll('.unlink-identity-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        if (!confirm('确定解除关联吗？')) {
+            return;
+        }
+
+        fetch(`/api/v1/identities/${this.dataset.provider}`, {
+            method: 'DELETE'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => location.reload(), 1000);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
+
+// 重新发送邮箱验证邮件
+const resendVerificationBtn = document.getElementById('resendVerificationBtn');
+if (resendVerificationBtn) {
+    resendVerificationBtn.addEventListener('click', function() {
+        fetch('/api/v1/users/email/verification', {
+            method: 'POST'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 修改邮箱：确认邮件发送到新邮箱
+const changeEmailForm = document.getElementById('changeEmailForm');
+if (changeEmailForm) {
+    changeEmailForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        fetch('/api/v1/users/email', {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({
+                email: document.getElementById('newEmail').value.trim(),
+                password: document.getElementById('changeEmailPassword').value
+            })
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    changeEmailForm.reset();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 两步验证：生成密钥并显示二维码
+const setupTwoFactorBtn = document.getElementById('setupTwoFactorBtn');
+if (setupTwoFactorBtn) {
+    setupTwoFactorBtn.addEventListener('click', function() {
+        fetch('/api/v1/two-factor/setup', {
+            method: 'POST'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    document.getElementById('twoFactorSecret').textContent = data.data.secret;
+                    document.getElementById('twoFactorQRCode').src = data.data.qr_code;
+                    document.getElementById('twoFactorSetupBox').style.display = 'block';
+                    setupTwoFactorBtn.style.display = 'none';
+                } else {
+                    customAlert.error('操作失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 显示一次性恢复码
+function showRecoveryCodes(codes) {
+    document.getElementById('recoveryCodes').textContent = codes.join('\n');
+    document.getElementById('recoveryCodesBox').style.display = 'block';
+}
+
+// 两步验证相关的提交，code 为验证码或恢复码
+function postTwoFactor(url, code) {
+    return fetch(url, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ code: code })
+    }).then(response => response.json());
+}
+
+const twoFactorEnableForm = document.getElementById('twoFactorEnableForm');
+if (twoFactorEnableForm) {
+    twoFactorEnableForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        postTwoFactor('/api/v1/two-factor/enable', document.getElementById('twoFactorEnableCode').value.trim())
+            .then(data => {
+                if (data.success) {
+                    document.getElementById('twoFactorSetupBox').style.display = 'none';
+                    showRecoveryCodes(data.data.recovery_codes);
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+const regenerateRecoveryCodesBtn = document.getElementById('regenerateRecoveryCodesBtn');
+if (regenerateRecoveryCodesBtn) {
+    regenerateRecoveryCodesBtn.addEventListener('click', function() {
+        postTwoFactor('/api/v1/two-factor/recovery-codes', document.getElementById('twoFactorManageCode').value.trim())
+            .then(data => {
+                if (data.success) {
+                    showRecoveryCodes(data.data.recovery_codes);
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+const disableTwoFactorBtn = document.getElementById('disableTwoFactorBtn');
+if (disableTwoFactorBtn) {
+    disableTwoFactorBtn.addEventListener('click', function() {
+        if (!confirm('确定关闭两步验证吗？关闭后登录只需要密码。')) {
+            return;
+        }
+
+        postTwoFactor('/api/v1/two-factor/disable', document.getElementById('twoFactorManageCode').value.trim())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => location.reload(), 1000);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
 // 切换密码可见性
 function togglePasswordVisibility(inputId) {
     const input = document.getElementById(inputId);
@@ -54,21 +54,20 @@ document.addEventListener('DOMContentLoaded', function (index) {
         // 获取表单数据
         const title = document.getElementById('title').value;
         const tags = document.getElementById('tags').value; // 隐藏字段，包含所有标签
-        const content = editor.getValue(); // CodeMirror编辑器内容
-        const htmlContent = marked.parse(content)
+        const content = editor.getValue(); // CodeMirror编辑器内容（Markdown原文，由服务端渲染）
         const category = document.querySelector('select[name="category"]').value;
         const readLimit = document.querySelector('select[name="readLimit"]').value;
 
         const data = {
             title: title,
             tags: tags,
-            content: htmlContent,
+            content: content,
             category_id: parseInt(category),
             read_limit: parseInt(readLimit)
         };
 
         // 发送到后端
-        fetch('/api/posts', {
+        fetch('/api/v1/posts', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
@@ -78,6 +77,12 @@ document.addEventListener('DOMContentLoaded', function (index) {
             .then(response => response.json())
             .then(result => {
                 if (result.success) {
+                    // 需要审核的文章发布后只有作者可见，跳转到文章页面
+                    if (result.data && result.data.status_code === 3) {
+                        customAlert.success(result.message);
+                        window.location.href = '/post-' + result.data.id + '-1';
+                        return;
+                    }
                     customAlert.success('文章发布成功！');
                     window.location.href = '/';
                 } else {
@@ -0,0 +1,89 @@
+// 敏感词管理：批量添加、修改处理方式、删除
+const sensitiveWordForm = document.getElementById('sensitiveWordForm');
+if (sensitiveWordForm) {
+    sensitiveWordForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        const words = document.getElementById('words').value
+            .split('\n')
+            .map(word => word.trim())
+            .filter(word => word);
+        if (words.length === 0) {
+            customAlert.error('请至少填写一个词语');
+            return;
+        }
+
+        fetch('/api/v1/sensitive-words', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({
+                words: words,
+                action: document.getElementById('action').value
+            })
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => window.location.reload(), 1000);
+                } else {
+                    customAlert.error('保存失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+document.querySelectorAll('.word-action').forEach(select => {
+    select.addEventListener('change', function() {
+        fetch(`/api/v1/sensitive-words/${this.dataset.wordId}`, {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ action: this.value })
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('修改失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
+
+document.querySelectorAll('.delete-word-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        if (!confirm('确定删除这个敏感词吗？')) {
+            return;
+        }
+
+        fetch(`/api/v1/sensitive-words/${this.dataset.wordId}`, {
+            method: 'DELETE'
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    this.closest('tr').remove();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('删除失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
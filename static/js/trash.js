@@ -0,0 +1,25 @@
+// 回收站：恢复文章、评论和用户
+document.querySelectorAll('.restore-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        const row = this.closest('tr');
+        fetch(`/api/v1/${this.dataset.type}/${this.dataset.id}/restore`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            }
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    row.remove();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('恢复失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
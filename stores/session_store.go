@@ -0,0 +1,210 @@
+package stores
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+
+	"gin-doniai/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+	"gorm.io/gorm"
+)
+
+// 未设置过期时间（浏览器会话 Cookie）时服务端保留会话的时长
+const defaultSessionLifetime = 7 * 24 * time.Hour
+
+// 最近活跃时间的更新间隔，避免每个请求都写库
+const sessionTouchInterval = time.Minute
+
+type clientIPKey struct{}
+
+// WithClientIP 在请求上下文中记录客户端IP，会话存储创建会话时使用。
+// IP 应由 gin 的 ClientIP 按受信任代理的设置解析，不直接信任请求头
+func WithClientIP(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+}
+
+// SessionStore 基于数据库的会话存储，实现 gin-contrib/sessions 的 Store 接口。
+// Cookie 中只保存签名后的会话标识，会话数据保存在 user_sessions 表中，
+// 删除记录即可让对应设备下线。
+type SessionStore struct {
+	db      *gorm.DB
+	codecs  []securecookie.Codec
+	options *gsessions.Options
+}
+
+// NewSessionStore 创建会话存储，keyPairs 用于签名（和可选加密）Cookie 中的会话标识
+func NewSessionStore(db *gorm.DB, keyPairs ...[]byte) *SessionStore {
+	return &SessionStore{
+		db:     db,
+		codecs: securecookie.CodecsFromPairs(keyPairs...),
+		options: &gsessions.Options{
+			Path:     "/",
+			MaxAge:   86400 * 30,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+	}
+}
+
+// Options 设置默认的 Cookie 选项
+func (s *SessionStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+	if s.options.MaxAge > 0 {
+		for _, codec := range s.codecs {
+			if sc, ok := codec.(*securecookie.SecureCookie); ok {
+				sc.MaxAge(s.options.MaxAge)
+			}
+		}
+	}
+}
+
+// Get 获取会话，同一请求内多次调用返回同一个会话
+func (s *SessionStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New 根据 Cookie 加载会话，Cookie 无效或会话已被撤销时返回新的空会话
+func (s *SessionStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	// 旧版 Cookie 存储或密钥更换后的 Cookie 无法解码，按未登录处理
+	var key string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &key, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	var record models.UserSession
+	if err := s.db.Where("session_key = ? AND expires_at > ?", key, time.Now()).First(&record).Error; err != nil {
+		return session, nil
+	}
+	if err := (securecookie.GobEncoder{}).Deserialize(record.Data, &session.Values); err != nil {
+		return session, nil
+	}
+	session.ID = key
+	session.IsNew = false
+	s.touch(&record, time.Now())
+	return session, nil
+}
+
+// Save 保存会话数据并写入 Cookie，MaxAge 小于 0 时删除会话
+func (s *SessionStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			s.db.Where("session_key = ?", session.ID).Delete(&models.UserSession{})
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	data, err := (securecookie.GobEncoder{}).Serialize(session.Values)
+	if err != nil {
+		return err
+	}
+	userID := sessionUserID(session.Values)
+	now := time.Now()
+	lifetime := defaultSessionLifetime
+	if session.Options.MaxAge > 0 {
+		lifetime = time.Duration(session.Options.MaxAge) * time.Second
+	}
+
+	var record models.UserSession
+	exists := session.ID != "" && s.db.Where("session_key = ?", session.ID).First(&record).Error == nil
+	// 登录用户发生变化时更换会话标识，防止会话固定攻击
+	if exists && record.UserID != userID {
+		s.db.Delete(&record)
+		exists = false
+	}
+
+	if exists {
+		updates := map[string]interface{}{
+			"data":       data,
+			"expires_at": now.Add(lifetime),
+		}
+		if now.Sub(record.LastActiveAt) > sessionTouchInterval {
+			updates["last_active_at"] = now
+		}
+		err = s.db.Model(&record).Updates(updates).Error
+	} else {
+		record = models.UserSession{
+			SessionKey:   newSessionKey(),
+			UserID:       userID,
+			Data:         data,
+			IPAddress:    clientIP(r),
+			UserAgent:    r.UserAgent(),
+			LastActiveAt: now,
+			ExpiresAt:    now.Add(lifetime),
+		}
+		err = s.db.Create(&record).Error
+		session.ID = record.SessionKey
+	}
+	if err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Cleanup 删除已过期的会话
+func (s *SessionStore) Cleanup() error {
+	return s.db.Where("expires_at < ?", time.Now()).Delete(&models.UserSession{}).Error
+}
+
+// sessionUserID 读取会话中的用户ID，兼容 uint 和 int 两种存储类型
+func sessionUserID(values map[interface{}]interface{}) uint {
+	switch id := values["user_id"].(type) {
+	case uint:
+		return id
+	case int:
+		if id > 0 {
+			return uint(id)
+		}
+	}
+	return 0
+}
+
+func newSessionKey() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// touch 更新会话的最近活跃时间，间隔不足 sessionTouchInterval 时跳过
+func (s *SessionStore) touch(record *models.UserSession, now time.Time) {
+	if now.Sub(record.LastActiveAt) <= sessionTouchInterval {
+		return
+	}
+	s.db.Model(record).UpdateColumn("last_active_at", now)
+}
+
+// clientIP 获取 WithClientIP 记录的客户端IP，没有记录时使用连接的对端地址
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
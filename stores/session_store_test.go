@@ -0,0 +1,21 @@
+package stores
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	r.Header.Set("X-Real-Ip", "198.51.100.2")
+
+	// 请求头可以被客户端伪造，不直接使用
+	if got := clientIP(r); got != "203.0.113.7" {
+		t.Errorf("clientIP = %q，期望对端地址 203.0.113.7", got)
+	}
+	if got := clientIP(WithClientIP(r, "192.0.2.10")); got != "192.0.2.10" {
+		t.Errorf("clientIP = %q，期望 WithClientIP 记录的 192.0.2.10", got)
+	}
+}
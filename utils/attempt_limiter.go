@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// AttemptPolicy 失败次数限制策略
+type AttemptPolicy struct {
+	FreeAttempts int           // 不受限制的失败次数
+	BaseDelay    time.Duration // 超出后第一次的等待时间，之后每次翻倍
+	MaxDelay     time.Duration // 单次等待时间上限
+	MaxAttempts  int           // 达到该次数后锁定
+	LockDuration time.Duration // 锁定时长
+	Window       time.Duration // 最后一次失败超过该时间后重新计数
+}
+
+type attemptRecord struct {
+	failures    int
+	lastFailure time.Time
+	blockedTill time.Time
+}
+
+// AttemptLimiter 按键（账号、IP 等）统计失败次数，超出后指数退避，达到上限后临时锁定
+type AttemptLimiter struct {
+	policy  AttemptPolicy
+	mu      sync.Mutex
+	records map[string]*attemptRecord
+}
+
+// NewAttemptLimiter 创建失败次数限制器
+func NewAttemptLimiter(policy AttemptPolicy) *AttemptLimiter {
+	return &AttemptLimiter{
+		policy:  policy,
+		records: make(map[string]*attemptRecord),
+	}
+}
+
+// Allow 判断该键当前是否允许尝试，不允许时返回需要等待的时间
+func (l *AttemptLimiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	r := l.record(key, now)
+	if r == nil || !now.Before(r.blockedTill) {
+		return 0, true
+	}
+	return r.blockedTill.Sub(now), false
+}
+
+// Fail 记录一次失败，返回之后需要等待的时间（0 表示可以立即重试）
+func (l *AttemptLimiter) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	r := l.record(key, now)
+	if r == nil {
+		r = &attemptRecord{}
+		l.records[key] = r
+	}
+	r.failures++
+	r.lastFailure = now
+
+	var delay time.Duration
+	switch {
+	case l.policy.MaxAttempts > 0 && r.failures >= l.policy.MaxAttempts:
+		delay = l.policy.LockDuration
+	case r.failures > l.policy.FreeAttempts:
+		delay = l.policy.BaseDelay << (r.failures - l.policy.FreeAttempts - 1)
+		if delay <= 0 || delay > l.policy.MaxDelay {
+			delay = l.policy.MaxDelay
+		}
+	}
+	if delay > 0 {
+		r.blockedTill = now.Add(delay)
+	}
+	return delay
+}
+
+// Reset 清除该键的失败记录，通常在成功后调用
+func (l *AttemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.records, key)
+}
+
+// Cleanup 删除已过期的记录，由定时任务调用
+func (l *AttemptLimiter) Cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for key := range l.records {
+		l.record(key, now)
+	}
+}
+
+// record 返回该键未过期的记录，已过期的记录会被删除
+func (l *AttemptLimiter) record(key string, now time.Time) *attemptRecord {
+	r, ok := l.records[key]
+	if !ok {
+		return nil
+	}
+	if now.Before(r.blockedTill) || now.Sub(r.lastFailure) < l.policy.Window {
+		return r
+	}
+	delete(l.records, key)
+	return nil
+}
@@ -0,0 +1,569 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownOptions 控制Markdown渲染行为
+type MarkdownOptions struct {
+	HardWraps bool // 单个换行渲染为 <br>（评论使用）
+	Mentions  bool // 将 @用户名 和 #评论ID 渲染为链接
+}
+
+// RenderMarkdown 在服务端将Markdown渲染为HTML，原始HTML一律按文本转义，
+// 最终输出再经过白名单过滤
+func RenderMarkdown(src string, opts MarkdownOptions) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "")
+	src = strings.ReplaceAll(src, "\t", "    ")
+
+	r := &mdRenderer{opts: opts}
+	var b strings.Builder
+	r.renderBlocks(&b, strings.Split(src, "\n"), false)
+	return SanitizeHTML(b.String())
+}
+
+type mdRenderer struct {
+	opts MarkdownOptions
+}
+
+var (
+	reATXHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	reFence        = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ ]*([^`\\s]*)")
+	reListItem     = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])( +|$)`)
+	reBlockquote   = regexp.MustCompile(`^ {0,3}> ?`)
+	reSetextH1     = regexp.MustCompile(`^ {0,3}=+[ ]*$`)
+	reSetextH2     = regexp.MustCompile(`^ {0,3}-+[ ]*$`)
+	reTableDivider = regexp.MustCompile(`^[ ]*\|?[ ]*:?-+:?[ ]*(\|[ ]*:?-+:?[ ]*)*\|?[ ]*$`)
+)
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isThematicBreak(line string) bool {
+	if indentOf(line) > 3 {
+		return false
+	}
+	s := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	if len(s) < 3 {
+		return false
+	}
+	c := s[0]
+	if c != '-' && c != '*' && c != '_' {
+		return false
+	}
+	return strings.Count(s, string(c)) == len(s)
+}
+
+// startsBlock 判断一行是否会打断段落
+func startsBlock(line string) bool {
+	if reATXHeading.MatchString(line) || reFence.MatchString(line) ||
+		reBlockquote.MatchString(line) || isThematicBreak(line) {
+		return true
+	}
+	if m := reListItem.FindStringSubmatch(line); m != nil && m[3] != "" {
+		marker := m[2]
+		// 有序列表只有从1开始时才能打断段落
+		return !isOrderedMarker(marker) || strings.TrimRight(marker, ".)") == "1"
+	}
+	return false
+}
+
+func isOrderedMarker(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// renderBlocks 渲染块级元素，tight 为真时段落不包裹 <p>（紧凑列表）
+func (r *mdRenderer) renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	i := 0
+	for i < len(lines) {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case reFence.MatchString(line):
+			i = r.renderFence(b, lines, i)
+		case indentOf(line) >= 4:
+			i = r.renderIndentedCode(b, lines, i)
+		case reATXHeading.MatchString(line):
+			m := reATXHeading.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, r.renderInline(strings.TrimSpace(m[2])), level)
+			i++
+		case isThematicBreak(line):
+			b.WriteString("<hr>\n")
+			i++
+		case reBlockquote.MatchString(line):
+			i = r.renderBlockquote(b, lines, i)
+		case reListItem.MatchString(line):
+			i = r.renderList(b, lines, i)
+		case i+1 < len(lines) && strings.Contains(line, "|") && reTableDivider.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			i = r.renderTable(b, lines, i)
+		default:
+			i = r.renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func (r *mdRenderer) renderFence(b *strings.Builder, lines []string, i int) int {
+	m := reFence.FindStringSubmatch(lines[i])
+	indent, marker, lang := len(m[1]), m[2], m[3]
+	var code []string
+	i++
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, marker[:1]) && strings.Trim(trimmed, marker[:1]) == "" && len(trimmed) >= len(marker) {
+			i++
+			break
+		}
+		line := lines[i]
+		if n := indentOf(line); n > 0 {
+			if n > indent {
+				n = indent
+			}
+			line = line[n:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *mdRenderer) renderIndentedCode(b *strings.Builder, lines []string, i int) int {
+	var code []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			code = append(code, "")
+			continue
+		}
+		if indentOf(line) < 4 {
+			break
+		}
+		code = append(code, line[4:])
+	}
+	// 去掉结尾的空行
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+	b.WriteString("<pre><code>")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *mdRenderer) renderBlockquote(b *strings.Builder, lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if loc := reBlockquote.FindStringIndex(line); loc != nil {
+			inner = append(inner, line[loc[1]:])
+			continue
+		}
+		// 段落的惰性延续行
+		if isBlank(line) || startsBlock(line) || len(inner) == 0 || isBlank(inner[len(inner)-1]) {
+			break
+		}
+		inner = append(inner, line)
+	}
+	b.WriteString("<blockquote>\n")
+	r.renderBlocks(b, inner, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+type mdListItem struct {
+	lines []string
+}
+
+func (r *mdRenderer) renderList(b *strings.Builder, lines []string, i int) int {
+	m := reListItem.FindStringSubmatch(lines[i])
+	ordered := isOrderedMarker(m[2])
+	delimiter := m[2][len(m[2])-1:]
+	start := 1
+	if ordered {
+		start, _ = strconv.Atoi(m[2][:len(m[2])-1])
+	}
+
+	var items []mdListItem
+	loose := false
+	contentCol := 0
+	pendingBlank := false
+
+	for i < len(lines) {
+		line := lines[i]
+		if isBlank(line) {
+			pendingBlank = true
+			if len(items) > 0 {
+				last := &items[len(items)-1]
+				last.lines = append(last.lines, "")
+			}
+			i++
+			continue
+		}
+
+		im := reListItem.FindStringSubmatch(line)
+		if im != nil && (len(items) == 0 || indentOf(line) < contentCol) {
+			// 同类型的列表项才能延续当前列表
+			if isOrderedMarker(im[2]) != ordered || im[2][len(im[2])-1:] != delimiter {
+				break
+			}
+			if len(items) > 0 && pendingBlank {
+				loose = true
+			}
+			markerWidth := len(im[1]) + len(im[2])
+			spaces := len(im[3])
+			if spaces == 0 || spaces > 4 {
+				spaces = 1
+			}
+			contentCol = markerWidth + spaces
+			first := ""
+			if len(line) > contentCol {
+				first = line[contentCol:]
+			}
+			items = append(items, mdListItem{lines: []string{first}})
+			pendingBlank = false
+			i++
+			continue
+		}
+
+		last := &items[len(items)-1]
+		if indentOf(line) >= contentCol {
+			if pendingBlank {
+				loose = true
+			}
+			last.lines = append(last.lines, line[contentCol:])
+			pendingBlank = false
+			i++
+			continue
+		}
+		// 段落的惰性延续行
+		if !pendingBlank && !startsBlock(line) {
+			last.lines = append(last.lines, strings.TrimLeft(line, " "))
+			i++
+			continue
+		}
+		break
+	}
+
+	// 结尾的空行不属于列表项
+	for idx := range items {
+		for len(items[idx].lines) > 0 && isBlank(items[idx].lines[len(items[idx].lines)-1]) {
+			items[idx].lines = items[idx].lines[:len(items[idx].lines)-1]
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	if ordered && start != 1 {
+		fmt.Fprintf(b, "<ol start=\"%d\">\n", start)
+	} else {
+		b.WriteString("<" + tag + ">\n")
+	}
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		r.renderBlocks(&inner, item.lines, !loose)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for k := 0; k < len(line); k++ {
+		if line[k] == '\\' && k+1 < len(line) && line[k+1] == '|' {
+			cell.WriteByte('|')
+			k++
+			continue
+		}
+		if line[k] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[k])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func (r *mdRenderer) renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitTableRow(lines[i])
+	var aligns []string
+	for _, spec := range splitTableRow(lines[i+1]) {
+		left, right := strings.HasPrefix(spec, ":"), strings.HasSuffix(spec, ":")
+		switch {
+		case left && right:
+			aligns = append(aligns, "center")
+		case right:
+			aligns = append(aligns, "right")
+		case left:
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+	cell := func(tag string, col int, text string) {
+		b.WriteString("<" + tag)
+		if col < len(aligns) && aligns[col] != "" {
+			b.WriteString(` align="` + aligns[col] + `"`)
+		}
+		b.WriteString(">" + r.renderInline(text) + "</" + tag + ">")
+	}
+
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for col, text := range header {
+		cell("th", col, text)
+	}
+	b.WriteString("</tr>\n</thead>\n")
+
+	i += 2
+	wroteBody := false
+	for ; i < len(lines) && !isBlank(lines[i]) && strings.Contains(lines[i], "|"); i++ {
+		if !wroteBody {
+			b.WriteString("<tbody>\n")
+			wroteBody = true
+		}
+		row := splitTableRow(lines[i])
+		b.WriteString("<tr>")
+		for col := range header {
+			text := ""
+			if col < len(row) {
+				text = row[col]
+			}
+			cell("td", col, text)
+		}
+		b.WriteString("</tr>\n")
+	}
+	if wroteBody {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+	return i
+}
+
+func (r *mdRenderer) renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var para []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		// Setext 风格标题
+		if len(para) > 0 && (reSetextH1.MatchString(line) || reSetextH2.MatchString(line)) {
+			level := 2
+			if reSetextH1.MatchString(line) {
+				level = 1
+			}
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, r.renderInline(strings.Join(para, "\n")), level)
+			return i + 1
+		}
+		if len(para) > 0 && startsBlock(line) {
+			break
+		}
+		para = append(para, strings.TrimLeft(line, " "))
+	}
+
+	text := r.renderInline(strings.Join(para, "\n"))
+	if tight {
+		b.WriteString(text + "\n")
+	} else {
+		b.WriteString("<p>" + text + "</p>\n")
+	}
+	return i
+}
+
+var (
+	reBackslashBreak = regexp.MustCompile(`\\\n`)
+	reEscapedChar    = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+	reAutolink       = regexp.MustCompile(`<((?:https?://|mailto:)[^\s<>]+)>`)
+	reImage          = regexp.MustCompile(`!\[([^\]]*)\]\(\s*((?:[^\s()]|\([^\s()]*\))*)(?:\s+"([^"]*)")?\s*\)`)
+	reLink           = regexp.MustCompile(`\[((?:[^\[\]]|\[[^\[\]]*\])*)\]\(\s*((?:[^\s()]|\([^\s()]*\))*)(?:\s+"([^"]*)")?\s*\)`)
+	reBareURL        = regexp.MustCompile(`https?://[^\s<>\x00"]+`)
+	reMention        = regexp.MustCompile(`(^|[^\p{L}\p{N}_@/])@([\p{L}\p{N}_\-]+)`)
+	reCommentRef     = regexp.MustCompile(`(^|[^\p{L}\p{N}_&#/])#(\d+)\b`)
+	reStrong         = regexp.MustCompile(`(?s)\*\*(\S(?:.*?\S)?)\*\*`)
+	reStrongUnder    = regexp.MustCompile(`(?s)(^|[^\p{L}\p{N}_])__(\S(?:.*?\S)?)__([^\p{L}\p{N}_]|$)`)
+	reStrike         = regexp.MustCompile(`(?s)~~(\S(?:.*?\S)?)~~`)
+	reEm             = regexp.MustCompile(`(?s)\*(\S(?:.*?\S)?)\*`)
+	reEmUnder        = regexp.MustCompile(`(?s)(^|[^\p{L}\p{N}_])_(\S(?:.*?\S)?)_([^\p{L}\p{N}_]|$)`)
+	reSoftBreak      = regexp.MustCompile(` {2,}\n`)
+	rePlaceholder    = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// inlineState 保存已渲染的片段，文本中用 \x00序号\x00 占位，避免后续规则重复处理
+type inlineState struct {
+	holds []string
+}
+
+func (s *inlineState) hold(fragment string) string {
+	s.holds = append(s.holds, fragment)
+	return "\x00" + strconv.Itoa(len(s.holds)-1) + "\x00"
+}
+
+func (r *mdRenderer) renderInline(text string) string {
+	st := &inlineState{}
+	text = r.extractCodeSpans(st, text)
+
+	text = reBackslashBreak.ReplaceAllStringFunc(text, func(string) string {
+		return st.hold("<br>") + "\n"
+	})
+	text = reEscapedChar.ReplaceAllStringFunc(text, func(m string) string {
+		return st.hold(html.EscapeString(m[1:]))
+	})
+	text = reAutolink.ReplaceAllStringFunc(text, func(m string) string {
+		u := m[1 : len(m)-1]
+		return st.hold(linkHTML(u, "", html.EscapeString(u)))
+	})
+	text = reImage.ReplaceAllStringFunc(text, func(m string) string {
+		sm := reImage.FindStringSubmatch(m)
+		src := SafeURL(html.UnescapeString(sm[2]))
+		if src == "" {
+			return st.hold(html.EscapeString(sm[1]))
+		}
+		img := `<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(sm[1]) + `"`
+		if sm[3] != "" {
+			img += ` title="` + html.EscapeString(sm[3]) + `"`
+		}
+		return st.hold(img + ">")
+	})
+	// 链接地址中允许一层成对的括号，如 https://example.com/a_(b)，不合法的地址只保留文字
+	text = reLink.ReplaceAllStringFunc(text, func(m string) string {
+		sm := reLink.FindStringSubmatch(m)
+		return st.hold(linkHTML(html.UnescapeString(sm[2]), sm[3], r.renderSpans(st, sm[1])))
+	})
+	text = reBareURL.ReplaceAllStringFunc(text, func(m string) string {
+		u := strings.TrimRight(m, ".,;:!?)")
+		return st.hold(linkHTML(u, "", html.EscapeString(u))) + m[len(u):]
+	})
+	if r.opts.Mentions {
+		text = reMention.ReplaceAllStringFunc(text, func(m string) string {
+			sm := reMention.FindStringSubmatch(m)
+			return sm[1] + st.hold(`<a href="/user/`+html.EscapeString(sm[2])+`">@`+html.EscapeString(sm[2])+`</a>`)
+		})
+		text = reCommentRef.ReplaceAllStringFunc(text, func(m string) string {
+			sm := reCommentRef.FindStringSubmatch(m)
+			return sm[1] + st.hold(`<a href="#comment-`+sm[2]+`">#`+sm[2]+`</a>`)
+		})
+	}
+
+	text = r.renderSpans(st, text)
+
+	// 还原占位符（链接文本中可能嵌套占位符，需要多轮替换）
+	for strings.Contains(text, "\x00") {
+		replaced := rePlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+			idx, _ := strconv.Atoi(m[1 : len(m)-1])
+			return st.holds[idx]
+		})
+		if replaced == text {
+			break
+		}
+		text = replaced
+	}
+	return text
+}
+
+// renderSpans 转义文本并处理强调、删除线和换行
+func (r *mdRenderer) renderSpans(st *inlineState, text string) string {
+	text = html.EscapeString(text)
+	text = reStrong.ReplaceAllString(text, "<strong>$1</strong>")
+	text = reStrongUnder.ReplaceAllString(text, "$1<strong>$2</strong>$3")
+	text = reStrike.ReplaceAllString(text, "<del>$1</del>")
+	text = reEm.ReplaceAllString(text, "<em>$1</em>")
+	text = reEmUnder.ReplaceAllString(text, "$1<em>$2</em>$3")
+	if r.opts.HardWraps {
+		text = strings.ReplaceAll(text, "\n", "<br>\n")
+	} else {
+		text = reSoftBreak.ReplaceAllString(text, "<br>\n")
+	}
+	return text
+}
+
+// extractCodeSpans 提取行内代码，反引号数量需前后一致
+func (r *mdRenderer) extractCodeSpans(st *inlineState, text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		if text[i] != '`' || (i > 0 && text[i-1] == '\\') {
+			out.WriteByte(text[i])
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(text) && text[i+n] == '`' {
+			n++
+		}
+		marker := text[i : i+n]
+		end := -1
+		for j := i + n; j < len(text); {
+			k := strings.Index(text[j:], marker)
+			if k < 0 {
+				break
+			}
+			k += j
+			m := 0
+			for k+m < len(text) && text[k+m] == '`' {
+				m++
+			}
+			if m == n {
+				end = k
+				break
+			}
+			j = k + m
+		}
+		if end < 0 {
+			out.WriteString(marker)
+			i += n
+			continue
+		}
+		code := strings.ReplaceAll(text[i+n:end], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+			code = code[1 : len(code)-1]
+		}
+		out.WriteString(st.hold("<code>" + html.EscapeString(code) + "</code>"))
+		i = end + n
+	}
+	return out.String()
+}
+
+func linkHTML(href, title, inner string) string {
+	u := SafeURL(href)
+	if u == "" {
+		return inner
+	}
+	a := `<a href="` + html.EscapeString(u) + `"`
+	if title != "" {
+		a += ` title="` + html.EscapeString(title) + `"`
+	}
+	return a + ">" + inner + "</a>"
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts MarkdownOptions
+		want string
+	}{
+		{"段落和强调", "a **b** *c* ~~d~~", MarkdownOptions{}, "<p>a <strong>b</strong> <em>c</em> <del>d</del></p>\n"},
+		{"标题", "# 标题", MarkdownOptions{}, "<h1>标题</h1>\n"},
+		{"原始 HTML 按文本转义", "<script>alert(1)</script>", MarkdownOptions{}, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"原始事件属性按文本转义", `<img src=x onerror=alert(1)>`, MarkdownOptions{}, "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"未闭合的标签按文本转义", "<b><i>x</b>", MarkdownOptions{}, "<p>&lt;b&gt;&lt;i&gt;x&lt;/b&gt;</p>\n"},
+		{"代码块中的 HTML", "```html\n<script>x</script>\n```", MarkdownOptions{}, "<pre><code class=\"language-html\">&lt;script&gt;x&lt;/script&gt;\n</code></pre>\n"},
+		{"代码块语言不能逃逸属性", "```\"onmouseover=x\n1\n```", MarkdownOptions{}, "<pre><code>1\n</code></pre>\n"},
+		{"链接", "[x](https://example.com)", MarkdownOptions{}, `<p><a href="https://example.com" rel="nofollow noopener noreferrer">x</a></p>` + "\n"},
+		{"链接标题", `[x](/a "t")`, MarkdownOptions{}, `<p><a href="/a" title="t" rel="nofollow noopener noreferrer">x</a></p>` + "\n"},
+		{"链接地址中的括号", "[x](https://example.com/a_(b))", MarkdownOptions{}, `<p><a href="https://example.com/a_(b)" rel="nofollow noopener noreferrer">x</a></p>` + "\n"},
+		{"javascript 链接只保留文字", "[x](javascript:alert(1))", MarkdownOptions{}, "<p>x</p>\n"},
+		{"大写的 javascript 链接", "[x](JAVASCRIPT:alert(1))", MarkdownOptions{}, "<p>x</p>\n"},
+		{"实体编码的 javascript 链接", "[x](jav&#x61;script:alert(1))", MarkdownOptions{}, "<p>x</p>\n"},
+		{"javascript 图片只保留替代文字", "![a](javascript:alert(1))", MarkdownOptions{}, "<p>a</p>\n"},
+		{"data 图片只保留替代文字", "![a](data:image/png;base64,AAAA)", MarkdownOptions{}, "<p>a</p>\n"},
+		{"图片", "![a](/a.png)", MarkdownOptions{}, `<p><img src="/a.png" alt="a"></p>` + "\n"},
+		{"图片替代文字中的引号", `![a" onerror="x](/a.png)`, MarkdownOptions{}, `<p><img src="/a.png" alt="a&#34; onerror=&#34;x"></p>` + "\n"},
+		{"链接地址中的引号", `[x]("onmouseover=alert(1))`, MarkdownOptions{}, `<p><a href="&#34;onmouseover=alert(1)" rel="nofollow noopener noreferrer">x</a></p>` + "\n"},
+		{"自动链接", "<https://example.com>", MarkdownOptions{}, `<p><a href="https://example.com" rel="nofollow noopener noreferrer">https://example.com</a></p>` + "\n"},
+		{"javascript 不是自动链接", "<javascript:alert(1)>", MarkdownOptions{}, "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{"裸地址", "见 https://example.com/a.", MarkdownOptions{}, `<p>见 <a href="https://example.com/a" rel="nofollow noopener noreferrer">https://example.com/a</a>.</p>` + "\n"},
+		{"提及和评论引用", "@bob 见 #12", MarkdownOptions{Mentions: true}, `<p><a href="/user/bob" rel="nofollow noopener noreferrer">@bob</a> 见 <a href="#comment-12" rel="nofollow noopener noreferrer">#12</a></p>` + "\n"},
+		{"未开启提及", "@bob 见 #12", MarkdownOptions{}, "<p>@bob 见 #12</p>\n"},
+		{"邮箱不是提及", "a@bob.com", MarkdownOptions{Mentions: true}, "<p>a@bob.com</p>\n"},
+		{"行内代码中的提及", "`@bob`", MarkdownOptions{Mentions: true}, "<p><code>@bob</code></p>\n"},
+		{"硬换行", "a\nb", MarkdownOptions{HardWraps: true}, "<p>a<br>\nb</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderMarkdown(tt.in, tt.opts)
+			if got != tt.want {
+				t.Errorf("RenderMarkdown(%q) = %q，期望 %q", tt.in, got, tt.want)
+			}
+			assertSafeHTML(t, got)
+		})
+	}
+}
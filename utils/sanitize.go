@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// 白名单：允许的标签及其允许的属性
+var allowedTags = map[string]map[string]bool{
+	"p": {}, "br": {}, "hr": {},
+	"h1": {}, "h2": {}, "h3": {}, "h4": {}, "h5": {}, "h6": {},
+	"strong": {}, "b": {}, "em": {}, "i": {}, "del": {}, "s": {}, "sup": {}, "sub": {},
+	"blockquote": {}, "ul": {}, "ol": {"start": true}, "li": {},
+	"pre": {"class": true}, "code": {"class": true}, "span": {"class": true},
+	"a":     {"href": true, "title": true},
+	"img":   {"src": true, "alt": true, "title": true},
+	"table": {}, "thead": {}, "tbody": {}, "tr": {},
+	"th": {"align": true}, "td": {"align": true},
+}
+
+// 自闭合标签
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// 行内标签，提取文字时前后不加空格
+var inlineTags = map[string]bool{
+	"a": true, "strong": true, "b": true, "em": true, "i": true, "del": true, "s": true,
+	"sup": true, "sub": true, "code": true, "span": true,
+}
+
+// 连同内容一起丢弃的标签
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "title": true,
+	"svg": true, "math": true, "select": true, "frame": true, "frameset": true,
+}
+
+var (
+	reLanguageClass = regexp.MustCompile(`^language-[A-Za-z0-9_+#-]+$`)
+	reDigits        = regexp.MustCompile(`^\d{1,9}$`)
+)
+
+// SanitizeHTML 按白名单过滤HTML，未允许的标签会被去除（保留文本），危险标签连同内容一起丢弃
+func SanitizeHTML(s string) string {
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	var stack []string
+	skipDepth := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case nethtml.ErrorToken:
+			// 补全未闭合的标签
+			for i := len(stack) - 1; i >= 0; i-- {
+				b.WriteString("</" + stack[i] + ">")
+			}
+			return b.String()
+
+		case nethtml.TextToken:
+			if skipDepth == 0 {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			tok := z.Token()
+			name := tok.Data
+			if droppedTags[name] {
+				if tt == nethtml.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			allowedAttrs, ok := allowedTags[name]
+			if !ok {
+				continue
+			}
+
+			var attrs strings.Builder
+			hasSrc := false
+			for _, attr := range tok.Attr {
+				if attr.Namespace != "" || !allowedAttrs[attr.Key] {
+					continue
+				}
+				if val, ok := sanitizeAttr(attr.Key, attr.Val); ok {
+					attrs.WriteString(" " + attr.Key + `="` + html.EscapeString(val) + `"`)
+					hasSrc = hasSrc || attr.Key == "src"
+				}
+			}
+			// 没有合法地址的图片直接丢弃
+			if name == "img" && !hasSrc {
+				continue
+			}
+			b.WriteString("<" + name + attrs.String())
+			if name == "a" {
+				b.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+			b.WriteString(">")
+
+			if !voidTags[name] && tt == nethtml.StartTagToken {
+				stack = append(stack, name)
+			}
+
+		case nethtml.EndTagToken:
+			name, _ := z.TagName()
+			tagName := string(name)
+			if droppedTags[tagName] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 || voidTags[tagName] {
+				continue
+			}
+			if _, ok := allowedTags[tagName]; !ok {
+				continue
+			}
+			// 只闭合已打开的标签，多余的结束标签直接忽略
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == tagName {
+					for j := len(stack) - 1; j >= i; j-- {
+						b.WriteString("</" + stack[j] + ">")
+					}
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+	}
+}
+
+// PlainText 提取HTML中的文字，丢弃标签和危险标签的内容，块级标签前后用空格分隔，连续的空白合并为一个空格
+func PlainText(s string) string {
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	skipDepth := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case nethtml.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+
+		case nethtml.TextToken:
+			if skipDepth == 0 {
+				b.Write(z.Text())
+			}
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken, nethtml.EndTagToken:
+			name, _ := z.TagName()
+			tagName := string(name)
+			if droppedTags[tagName] {
+				if tt == nethtml.StartTagToken {
+					skipDepth++
+				} else if tt == nethtml.EndTagToken && skipDepth > 0 {
+					skipDepth--
+				}
+			}
+			if !inlineTags[tagName] {
+				b.WriteString(" ")
+			}
+		}
+	}
+}
+
+// sanitizeAttr 校验属性值，返回是否保留
+func sanitizeAttr(key, val string) (string, bool) {
+	switch key {
+	case "href", "src":
+		u := SafeURL(val)
+		return u, u != ""
+	case "class":
+		var classes []string
+		for _, class := range strings.Fields(val) {
+			if reLanguageClass.MatchString(class) {
+				classes = append(classes, class)
+			}
+		}
+		return strings.Join(classes, " "), len(classes) > 0
+	case "align":
+		val = strings.ToLower(strings.TrimSpace(val))
+		return val, val == "left" || val == "center" || val == "right"
+	case "start":
+		return val, reDigits.MatchString(val)
+	default:
+		return val, true
+	}
+}
+
+// SafeURL 只允许相对地址以及 http、https、mailto 协议，其他返回空字符串
+func SafeURL(raw string) string {
+	u := strings.TrimSpace(raw)
+	if u == "" {
+		return ""
+	}
+	// 去除控制字符和空白后再判断协议，防止 "java\tscript:" 之类的绕过
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	lower := strings.ToLower(cleaned)
+
+	colon := strings.Index(lower, ":")
+	if colon < 0 {
+		return u
+	}
+	// 冒号出现在路径、查询或锚点之后时属于相对地址
+	if sep := strings.IndexAny(lower, "/?#"); sep >= 0 && sep < colon {
+		return u
+	}
+	switch lower[:colon] {
+	case "http", "https", "mailto":
+		return u
+	}
+	return ""
+}
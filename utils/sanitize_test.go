@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// assertSafeHTML 检查输出中没有脚本、事件属性和危险协议的地址
+func assertSafeHTML(t *testing.T, out string) {
+	t.Helper()
+	z := nethtml.NewTokenizer(strings.NewReader(out))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			return
+		}
+		if tt != nethtml.StartTagToken && tt != nethtml.SelfClosingTagToken {
+			continue
+		}
+		tok := z.Token()
+		if droppedTags[tok.Data] {
+			t.Errorf("输出包含 <%s>：%q", tok.Data, out)
+		}
+		for _, attr := range tok.Attr {
+			if strings.HasPrefix(attr.Key, "on") {
+				t.Errorf("输出包含事件属性 %s：%q", attr.Key, out)
+			}
+			if (attr.Key == "href" || attr.Key == "src") && SafeURL(attr.Val) != attr.Val {
+				t.Errorf("输出包含不安全的地址 %s=%q：%q", attr.Key, attr.Val, out)
+			}
+		}
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"保留白名单标签", `<p>a <strong>b</strong></p>`, `<p>a <strong>b</strong></p>`},
+		{"丢弃 script 及内容", `a<script>alert(1)</script>b`, `ab`},
+		{"丢弃 style 及内容", `<style>p{color:red}</style><p>x</p>`, `<p>x</p>`},
+		{"大写的 script", `<SCRIPT>alert(1)</SCRIPT>x`, `x`},
+		{"嵌套的丢弃标签", `<svg><script>alert(1)</script><g></g></svg>x`, `x`},
+		{"去除未允许的标签保留文本", `<div><span style="x">a</span></div>`, `<span>a</span>`},
+		{"去除事件属性", `<img src="a.png" onerror="alert(1)">`, `<img src="a.png">`},
+		{"去除无引号的事件属性", `<p onclick=alert(1)>x</p>`, `<p>x</p>`},
+		{"javascript 链接", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"实体编码的协议", `<a href="jav&#x61;script:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"十进制实体编码的协议", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"协议中夹杂制表符", `<a href="java&#09;script:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"协议前有空白", `<a href=" javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"data 图片被丢弃", `<img src="data:image/svg+xml;base64,AAAA">`, ``},
+		{"没有地址的图片被丢弃", `<img alt="x">`, ``},
+		{"http 链接", `<a href="https://example.com/?a=1&b=2">x</a>`, `<a href="https://example.com/?a=1&amp;b=2" rel="nofollow noopener noreferrer">x</a>`},
+		{"引号内的标签不会逃逸", `<p title='a"><script>alert(1)</script>'>x</p>`, `<p>x</p>`},
+		{"属性值中的引号被转义", `<a title='a" onclick="x' href="/">x</a>`, `<a title="a&#34; onclick=&#34;x" href="/" rel="nofollow noopener noreferrer">x</a>`},
+		{"补全未闭合的标签", `<p>a<strong>b`, `<p>a<strong>b</strong></p>`},
+		{"交错的标签", `<strong><em>x</strong>y</em>`, `<strong><em>x</em></strong>y`},
+		{"多余的结束标签", `</strong>x</p>`, `x`},
+		{"过滤 class", `<code class="language-go evil">x</code>`, `<code class="language-go">x</code>`},
+		{"过滤 align", `<td align="justify">x</td>`, `<td>x</td>`},
+		{"过滤 start", `<ol start="1; x">`, `<ol></ol>`},
+		{"文本中的尖括号被转义", `1 &lt; 2`, `1 &lt; 2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeHTML(tt.in)
+			if got != tt.want {
+				t.Errorf("SanitizeHTML(%q) = %q，期望 %q", tt.in, got, tt.want)
+			}
+			assertSafeHTML(t, got)
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com", "https://example.com"},
+		{"HTTP://example.com", "HTTP://example.com"},
+		{"mailto:a@example.com", "mailto:a@example.com"},
+		{"/post-1-1", "/post-1-1"},
+		{"#comment-1", "#comment-1"},
+		{"a/b:c", "a/b:c"},
+		{"?q=a:b", "?q=a:b"},
+		{"javascript:alert(1)", ""},
+		{"JavaScript:alert(1)", ""},
+		{" javascript:alert(1)", ""},
+		{"java\tscript:alert(1)", ""},
+		{"java\nscript:alert(1)", ""},
+		{"\x01javascript:alert(1)", ""},
+		{"data:text/html,x", ""},
+		{"vbscript:x", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SafeURL(tt.raw); got != tt.want {
+			t.Errorf("SafeURL(%q) = %q，期望 %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`<p>a<strong>b</strong></p><p>c</p>`, "ab c"},
+		{`<p>x</p><script>alert(1)</script>`, "x"},
+		{"a\n\n  b", "a b"},
+	}
+	for _, tt := range tests {
+		if got := PlainText(tt.in); got != tt.want {
+			t.Errorf("PlainText(%q) = %q，期望 %q", tt.in, got, tt.want)
+		}
+	}
+}
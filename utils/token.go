@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// AccessTokenPrefix 个人访问令牌的固定前缀，便于在日志和代码中识别泄露的令牌
+const AccessTokenPrefix = "dnai_"
+
+// GenerateAccessToken 生成新的访问令牌，返回明文和用于存储的摘要
+func GenerateAccessToken() (token string, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = AccessTokenPrefix + hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken 计算令牌的 SHA-256 摘要，令牌本身熵足够高，不需要加盐
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 密钥 "12345678901234567890" 的 Base32 编码
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// 附录 B 的 8 位验证码取后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		counter := TOTPCounter(time.Unix(tt.unix, 0))
+		got, err := TOTPCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) 失败: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %q，期望 %q", tt.unix, got, tt.want)
+		}
+		if counter, ok := ValidateTOTP(rfc6238Secret, tt.want, time.Unix(tt.unix, 0)); !ok || counter != TOTPCounter(time.Unix(tt.unix, 0)) {
+			t.Errorf("ValidateTOTP(%d) = %d, %v，期望通过", tt.unix, counter, ok)
+		}
+	}
+}
+
+func TestTOTPCodeSecretFormat(t *testing.T) {
+	// 密钥不区分大小写，忽略首尾空白
+	got, err := TOTPCode(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1)
+	if err != nil || got != "287082" {
+		t.Errorf("TOTPCode = %q, %v，期望 287082", got, err)
+	}
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode 应当拒绝无效的密钥")
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPCounter(now)
+	code := func(counter int64) string {
+		c, err := TOTPCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		counter int64
+		ok      bool
+	}{
+		{"当前窗口", current, true},
+		{"前一个窗口", current - 1, true},
+		{"后一个窗口", current + 1, true},
+		{"前两个窗口", current - 2, false},
+		{"后两个窗口", current + 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := ValidateTOTP(rfc6238Secret, code(tt.counter), now)
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP = %v，期望 %v", ok, tt.ok)
+			}
+			// 返回匹配的窗口序号，调用方据此拒绝重放
+			if ok && counter != tt.counter {
+				t.Errorf("窗口序号 = %d，期望 %d", counter, tt.counter)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		code string
+		ok   bool
+	}{
+		{"287082", true},
+		{" 287 082 ", true},
+		{"287083", false},
+		{"28708", false},
+		{"2870820", false},
+		{"94287082", false},
+		{"", false},
+		{"abcdef", false},
+	}
+	for _, tt := range tests {
+		if _, ok := ValidateTOTP(rfc6238Secret, tt.code, now); ok != tt.ok {
+			t.Errorf("ValidateTOTP(%q) = %v，期望 %v", tt.code, ok, tt.ok)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "287082", now); ok {
+		t.Error("ValidateTOTP 应当拒绝无效的密钥")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 160 位密钥编码后为 32 个字符
+	if len(secret) != 32 {
+		t.Errorf("密钥长度 = %d，期望 32", len(secret))
+	}
+	if _, err := TOTPCode(secret, 0); err != nil {
+		t.Errorf("生成的密钥无法使用: %v", err)
+	}
+	if other, _ := GenerateTOTPSecret(); other == secret {
+		t.Error("两次生成的密钥相同")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("恢复码数量 = %d，期望 10", len(codes))
+	}
+	format := regexp.MustCompile(`^[0-9a-f]{5}-[0-9a-f]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("恢复码格式错误: %q", code)
+		}
+		if seen[code] {
+			t.Errorf("恢复码重复: %q", code)
+		}
+		seen[code] = true
+		if NormalizeRecoveryCode(code) != code {
+			t.Errorf("NormalizeRecoveryCode(%q) 改变了恢复码", code)
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"a1b2c-3d4e5", "a1b2c-3d4e5"},
+		{"A1B2C-3D4E5", "a1b2c-3d4e5"},
+		{"a1b2c3d4e5", "a1b2c-3d4e5"},
+		{" a1b2c 3d4e5 ", "a1b2c-3d4e5"},
+		{"a1-b2c3-d4e5", "a1b2c-3d4e5"},
+		{"a1b2c", "a1b2c"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.code); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q，期望 %q", tt.code, got, tt.want)
+		}
+	}
+}
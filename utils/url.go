@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+const defaultBaseURL = "http://localhost:8080"
+
+var baseURLWarning sync.Once
+
+// BaseURL 站点对外访问的地址（环境变量 APP_BASE_URL），用于生成邮件等站外链接。
+// 不能根据请求的 Host 头拼接，否则攻击者可以伪造 Host 让链接指向自己的网站。
+func BaseURL() string {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("APP_BASE_URL")), "/")
+	if base == "" {
+		baseURLWarning.Do(func() {
+			fmt.Printf("警告: 未设置 APP_BASE_URL，站外链接将使用 %s\n", defaultBaseURL)
+		})
+		return defaultBaseURL
+	}
+	return base
+}
+
+// AbsoluteURL 把站内路径转换为完整地址，path 需要以 / 开头
+func AbsoluteURL(path string) string {
+	return BaseURL() + path
+}
+
+// SafeRedirectPath 校验登录后跳转的地址，只允许以单个 / 开头的站内路径，否则返回空字符串。
+// "//evil.com"、"/\evil.com" 会被浏览器当作其他网站，需要排除。
+func SafeRedirectPath(raw string) string {
+	if raw == "" || raw[0] != '/' || len(raw) > 2048 {
+		return ""
+	}
+	if strings.HasPrefix(raw, "//") || strings.ContainsRune(raw, '\\') {
+		return ""
+	}
+	for _, r := range raw {
+		if r < ' ' || r == 0x7f {
+			return ""
+		}
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	return raw
+}
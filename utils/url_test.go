@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestSafeRedirectPath(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"/", "/"},
+		{"/settings", "/settings"},
+		{"/post-1-1?page=2#comment-3", "/post-1-1?page=2#comment-3"},
+		{"", ""},
+		{"settings", ""},
+		{"//evil.com", ""},
+		{"//evil.com/path", ""},
+		{"/\\evil.com", ""},
+		{"\\\\evil.com", ""},
+		{"/path\\..\\evil", ""},
+		{"https://evil.com", ""},
+		{"http://evil.com/", ""},
+		{"javascript:alert(1)", ""},
+		{"/\tevil", ""},
+		{"/\nLocation: https://evil.com", ""},
+		{"/\x7f", ""},
+	}
+	for _, tt := range tests {
+		if got := SafeRedirectPath(tt.raw); got != tt.want {
+			t.Errorf("SafeRedirectPath(%q) = %q，期望 %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import "strings"
+
+// 浏览器和系统的识别规则，按顺序匹配（Edge、Opera 的 UA 中同时包含 Chrome）
+var (
+	uaBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"MicroMessenger", "微信"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	uaSystems = []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DescribeUserAgent 将 User-Agent 转换为便于阅读的设备描述，如 "Chrome / Windows"
+func DescribeUserAgent(ua string) string {
+	if ua == "" {
+		return "未知设备"
+	}
+	var parts []string
+	for _, b := range uaBrowsers {
+		if strings.Contains(ua, b.token) {
+			parts = append(parts, b.name)
+			break
+		}
+	}
+	for _, s := range uaSystems {
+		if strings.Contains(ua, s.token) {
+			parts = append(parts, s.name)
+			break
+		}
+	}
+	if len(parts) == 0 {
+		// 无法识别时取第一个产品标识，如 "python-requests/2.31"
+		return strings.Fields(ua)[0]
+	}
+	return strings.Join(parts, " / ")
+}
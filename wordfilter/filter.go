@@ -0,0 +1,79 @@
+package wordfilter
+
+import (
+	"sync/atomic"
+)
+
+// Result 检查结果，各类命中的词语按规则原文去重
+type Result struct {
+	Text   string   // 屏蔽后的文本，未命中屏蔽规则时与原文相同
+	Reject []string // 命中的拒绝类词语
+	Review []string // 命中的审核类词语
+	Masked []string // 已被屏蔽的词语
+}
+
+// Check 检查文本，屏蔽类规则命中的文字替换为 *
+func (m *Matcher) Check(text string) Result {
+	result := Result{Text: text}
+	hits := m.Find(text)
+	if len(hits) == 0 {
+		return result
+	}
+
+	var masked []bool
+	seen := map[Rule]bool{}
+	for _, hit := range hits {
+		if !seen[hit.Rule] {
+			seen[hit.Rule] = true
+			switch hit.Rule.Action {
+			case Reject:
+				result.Reject = append(result.Reject, hit.Rule.Word)
+			case Review:
+				result.Review = append(result.Review, hit.Rule.Word)
+			case Mask:
+				result.Masked = append(result.Masked, hit.Rule.Word)
+			}
+		}
+		if hit.Rule.Action == Mask {
+			if masked == nil {
+				masked = make([]bool, hit.End)
+			}
+			for len(masked) < hit.End {
+				masked = append(masked, false)
+			}
+			for i := hit.Start; i < hit.End; i++ {
+				masked[i] = true
+			}
+		}
+	}
+
+	if masked != nil {
+		runes := []rune(text)
+		for i := range masked {
+			if masked[i] && !isSeparator(runes[i]) && runes[i] != 0x3000 {
+				runes[i] = '*'
+			}
+		}
+		result.Text = string(runes)
+	}
+	return result
+}
+
+// 当前生效的匹配器，规则变化时整体替换
+var current atomic.Pointer[Matcher]
+
+// Load 用新的规则替换当前生效的匹配器，返回有效规则的数量
+func Load(rules []Rule) int {
+	m := New(rules)
+	current.Store(m)
+	return m.Len()
+}
+
+// Check 使用当前生效的规则检查文本，尚未加载规则时原样返回
+func Check(text string) Result {
+	m := current.Load()
+	if m == nil {
+		return Result{Text: text}
+	}
+	return m.Check(text)
+}
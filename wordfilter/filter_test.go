@@ -0,0 +1,108 @@
+package wordfilter
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCheckMask(t *testing.T) {
+	m := New(masks("敏感词", "badword", "网络赌博", "赌博网站"))
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"这是敏感词。", "这是***。"},
+		{"敏 感 词", "* * *"},
+		{"敏*感-词", "***-*"},
+		{"敏　感词", "*　**"},
+		{"a BadWord b", "a ******* b"},
+		{"ｂａｄｗｏｒｄ!", "*******!"},
+		{"网络赌博网站", "******"},
+		{"加入🙂敏感词群", "加入🙂***群"},
+		{"没有命中", "没有命中"},
+	}
+	for _, tt := range tests {
+		got := m.Check(tt.text).Text
+		if got != tt.want {
+			t.Errorf("Check(%q).Text = %q，期望 %q", tt.text, got, tt.want)
+		}
+		// 按字符替换，屏蔽前后的字符数不变
+		if utf8.RuneCountInString(got) != utf8.RuneCountInString(tt.text) {
+			t.Errorf("Check(%q) 字符数 = %d，期望 %d", tt.text, utf8.RuneCountInString(got), utf8.RuneCountInString(tt.text))
+		}
+	}
+}
+
+func TestCheckActions(t *testing.T) {
+	m := New([]Rule{
+		{Word: "赌博", Action: Mask},
+		{Word: "网络赌博", Action: Review},
+		{Word: "赌博网站", Action: Reject},
+		{Word: "代开发票", Action: Reject},
+		{Word: "加微信", Action: Review},
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want Result
+	}{
+		{
+			name: "只有屏蔽",
+			text: "不要赌博",
+			want: Result{Text: "不要**", Masked: []string{"赌博"}},
+		},
+		{
+			name: "审核词语包含屏蔽词语时按原文报告审核",
+			text: "网络赌博",
+			want: Result{Text: "网络**", Review: []string{"网络赌博"}, Masked: []string{"赌博"}},
+		},
+		{
+			name: "拒绝词语包含屏蔽词语时同时报告",
+			text: "赌博网站",
+			want: Result{Text: "**网站", Reject: []string{"赌博网站"}, Masked: []string{"赌博"}},
+		},
+		{
+			name: "三类规则重叠",
+			text: "网络赌博网站",
+			want: Result{Text: "网络**网站", Review: []string{"网络赌博"}, Reject: []string{"赌博网站"}, Masked: []string{"赌博"}},
+		},
+		{
+			name: "拒绝和审核不修改原文",
+			text: "代开发票请加微信",
+			want: Result{Text: "代开发票请加微信", Reject: []string{"代开发票"}, Review: []string{"加微信"}},
+		},
+		{
+			name: "多次命中同一规则只报告一次",
+			text: "加微信，加 微 信",
+			want: Result{Text: "加微信，加 微 信", Review: []string{"加微信"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Check(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %+v，期望 %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer current.Store(nil)
+	current.Store(nil)
+	if got := Check("敏感词"); !reflect.DeepEqual(got, Result{Text: "敏感词"}) {
+		t.Errorf("未加载规则时 Check = %+v", got)
+	}
+	if n := Load(masks("敏感词", " ")); n != 1 {
+		t.Errorf("Load = %d，期望 1", n)
+	}
+	if got := Check("敏感词").Text; got != "***" {
+		t.Errorf("Check = %q，期望 ***", got)
+	}
+	// 重新加载后旧规则不再生效
+	Load([]Rule{{Word: "其他", Action: Reject}})
+	if got := Check("敏感词"); !reflect.DeepEqual(got, Result{Text: "敏感词"}) {
+		t.Errorf("重新加载后 Check = %+v", got)
+	}
+}
@@ -0,0 +1,140 @@
+// Package wordfilter 敏感词过滤，基于 Aho-Corasick 自动机一次扫描匹配全部规则。
+//
+// 匹配前文本和规则都会经过归一化（全角转半角、大小写折叠、忽略分隔符），
+// 命中的位置映射回原文，屏蔽时只替换原文中的文字，保留分隔符。
+package wordfilter
+
+// Action 命中规则后的处理方式
+type Action string
+
+const (
+	Reject Action = "reject" // 拒绝提交
+	Mask   Action = "mask"   // 用 * 替换
+	Review Action = "review" // 进入人工审核
+)
+
+// IsValid 判断处理方式是否有效
+func (a Action) IsValid() bool {
+	return a == Reject || a == Mask || a == Review
+}
+
+// Rule 一条敏感词规则
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// Hit 一次命中，Start、End 为原文中的字符（rune）下标，区间左闭右开
+type Hit struct {
+	Rule  Rule
+	Start int
+	End   int
+}
+
+type node struct {
+	next  map[rune]int
+	fail  int
+	rules []int // 以该节点结尾的规则，包含后缀链上的规则
+}
+
+// Matcher 由一组规则构建的匹配器，构建后只读，可以并发使用
+type Matcher struct {
+	nodes   []node
+	rules   []Rule
+	lengths []int // 规则归一化后的长度
+}
+
+// New 构建匹配器，归一化后为空的规则会被忽略
+func New(rules []Rule) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int{}}}}
+	for _, rule := range rules {
+		word := normalizeWord(rule.Word)
+		if len(word) == 0 || !rule.Action.IsValid() {
+			continue
+		}
+		m.rules = append(m.rules, rule)
+		m.lengths = append(m.lengths, len(word))
+		cur := 0
+		for _, r := range word {
+			next, ok := m.nodes[cur].next[r]
+			if !ok {
+				next = len(m.nodes)
+				m.nodes = append(m.nodes, node{next: map[rune]int{}})
+				m.nodes[cur].next[r] = next
+			}
+			cur = next
+		}
+		m.nodes[cur].rules = append(m.nodes[cur].rules, len(m.rules)-1)
+	}
+	m.buildFailLinks()
+	return m
+}
+
+// buildFailLinks 按层序计算失败指针，并把后缀节点的规则合并到当前节点
+func (m *Matcher) buildFailLinks() {
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for fail != 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if next, ok := m.nodes[fail].next[r]; ok && next != child {
+				m.nodes[child].fail = next
+			}
+			m.nodes[child].rules = append(m.nodes[child].rules, m.nodes[m.nodes[child].fail].rules...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Len 有效规则的数量
+func (m *Matcher) Len() int {
+	return len(m.rules)
+}
+
+// Find 返回文本中所有命中的规则
+func (m *Matcher) Find(text string) []Hit {
+	if m == nil || len(m.rules) == 0 {
+		return nil
+	}
+	var hits []Hit
+	// positions[i] 为第 i 个参与匹配的字符在原文中的下标
+	var positions []int
+	cur := 0
+	index := 0
+	for _, r := range text {
+		n, ok := normalize(r)
+		if !ok {
+			index++
+			continue
+		}
+		positions = append(positions, index)
+		for cur != 0 {
+			if _, ok := m.nodes[cur].next[n]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if next, ok := m.nodes[cur].next[n]; ok {
+			cur = next
+		}
+		for _, ri := range m.nodes[cur].rules {
+			hits = append(hits, Hit{
+				Rule:  m.rules[ri],
+				Start: positions[len(positions)-m.lengths[ri]],
+				End:   index + 1,
+			})
+		}
+		index++
+	}
+	return hits
+}
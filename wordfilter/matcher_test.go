@@ -0,0 +1,128 @@
+package wordfilter
+
+import (
+	"reflect"
+	"testing"
+)
+
+type span struct {
+	Word  string
+	Start int
+	End   int
+}
+
+func spans(hits []Hit) []span {
+	out := make([]span, 0, len(hits))
+	for _, hit := range hits {
+		out = append(out, span{hit.Rule.Word, hit.Start, hit.End})
+	}
+	return out
+}
+
+func masks(words ...string) []Rule {
+	rules := make([]Rule, 0, len(words))
+	for _, word := range words {
+		rules = append(rules, Rule{Word: word, Action: Mask})
+	}
+	return rules
+}
+
+func TestFindOverlapping(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		text  string
+		want  []span
+	}{
+		{
+			name:  "经典 Aho-Corasick 例子",
+			rules: masks("he", "she", "his", "hers"),
+			text:  "ushers",
+			want:  []span{{"she", 1, 4}, {"he", 2, 4}, {"hers", 2, 6}},
+		},
+		{
+			name:  "前缀与完整词",
+			rules: masks("敏感", "敏感词"),
+			text:  "敏感词",
+			want:  []span{{"敏感", 0, 2}, {"敏感词", 0, 3}},
+		},
+		{
+			name:  "后缀经失败指针命中",
+			rules: masks("abcd", "bc"),
+			text:  "abce",
+			want:  []span{{"bc", 1, 3}},
+		},
+		{
+			name:  "重叠的重复出现",
+			rules: masks("aa"),
+			text:  "aaaa",
+			want:  []span{{"aa", 0, 2}, {"aa", 1, 3}, {"aa", 2, 4}},
+		},
+		{
+			name:  "中文词语互相重叠",
+			rules: masks("网络赌博", "赌博网站"),
+			text:  "网络赌博网站",
+			want:  []span{{"网络赌博", 0, 4}, {"赌博网站", 2, 6}},
+		},
+		{
+			name:  "没有命中",
+			rules: masks("敏感词"),
+			text:  "敏感的词",
+			want:  []span{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := spans(New(tt.rules).Find(tt.text))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find(%q) = %v，期望 %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNormalization(t *testing.T) {
+	m := New([]Rule{
+		{Word: "敏感词", Action: Mask},
+		{Word: "BadWord", Action: Mask},
+		{Word: "ＱＱ群", Action: Mask},
+		{Word: "***", Action: Mask}, // 归一化后为空，忽略
+		{Word: "unknown", Action: "block"},
+	})
+	if m.Len() != 3 {
+		t.Fatalf("Len() = %d，期望 3", m.Len())
+	}
+
+	tests := []struct {
+		text string
+		want []span
+	}{
+		{"这是敏感词", []span{{"敏感词", 2, 5}}},
+		{"敏 感 词", []span{{"敏感词", 0, 5}}},
+		{"敏*感-词", []span{{"敏感词", 0, 5}}},
+		{"敏​感　词", []span{{"敏感词", 0, 5}}},
+		{"a BADWORD b", []span{{"BadWord", 2, 9}}},
+		{"badword", []span{{"BadWord", 0, 7}}},
+		{"ｂａｄｗｏｒｄ", []span{{"BadWord", 0, 7}}},
+		{"bad-Word", []span{{"BadWord", 0, 8}}},
+		{"加qq群", []span{{"ＱＱ群", 1, 4}}},
+		{"中文BadWord混排", []span{{"BadWord", 2, 9}}},
+		{"bad词word", []span{}},
+	}
+	for _, tt := range tests {
+		got := spans(m.Find(tt.text))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Find(%q) = %v，期望 %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFindEmpty(t *testing.T) {
+	var m *Matcher
+	if hits := m.Find("敏感词"); hits != nil {
+		t.Errorf("nil 匹配器 Find = %v，期望 nil", hits)
+	}
+	if hits := New(nil).Find("敏感词"); hits != nil {
+		t.Errorf("没有规则时 Find = %v，期望 nil", hits)
+	}
+}
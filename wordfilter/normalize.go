@@ -0,0 +1,40 @@
+package wordfilter
+
+import (
+	"unicode"
+)
+
+// normalize 把字符转换为统一形式再参与匹配：全角字母数字转为半角，字母转为小写。
+// 空白、标点、符号和零宽字符等分隔符返回 false，匹配时直接跳过，
+// 这样 "敏 感 词"、"敏*感*词"、"ＡＢＣ" 都能命中对应的规则。
+func normalize(r rune) (rune, bool) {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E: // 全角 ASCII
+		r -= 0xFEE0
+	case r == 0x3000: // 全角空格
+		return 0, false
+	}
+	if isSeparator(r) {
+		return 0, false
+	}
+	return unicode.ToLower(r), true
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) ||
+		unicode.IsPunct(r) ||
+		unicode.IsSymbol(r) ||
+		unicode.Is(unicode.Cf, r) || // 零宽空格、零宽连接符等格式字符
+		unicode.Is(unicode.Mn, r) // 组合附加符号
+}
+
+// normalizeWord 规则中的词语按同样的方式转换，去掉其中的分隔符
+func normalizeWord(word string) []rune {
+	var out []rune
+	for _, r := range word {
+		if n, ok := normalize(r); ok {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -7,6 +7,7 @@ import (
 
 type OnlineStatusUpdate struct {
 	UserID    uint
+	SessionID string
 	IP        string
 	UserAgent string
 }
@@ -43,6 +44,6 @@ func processBatchOnlineStatus(updates []OnlineStatusUpdate) {
 	for _, update := range updates {
 		// 创建模拟的 gin.Context 用于处理
 		// 或者创建新的批量处理方法
-		handlers.UpdateUserOnlineStatusWithInfo(update.UserID, update.IP, update.UserAgent)
+		handlers.UpdateUserOnlineStatusWithInfo(update.UserID, update.SessionID, update.IP, update.UserAgent)
 	}
 }
@@ -0,0 +1,18 @@
+package workers
+
+import (
+	"time"
+
+	"gin-doniai/handlers"
+)
+
+// HandleTrashPurge 定时永久删除回收站中超过保留天数的内容，启动时先执行一次
+func HandleTrashPurge() {
+	ticker := time.NewTicker(6 * time.Hour)
+	defer ticker.Stop()
+
+	handlers.PurgeTrash()
+	for range ticker.C {
+		handlers.PurgeTrash()
+	}
+}